@@ -0,0 +1,120 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Plate lists for LicensePlate.List.
+const (
+	PlateAllowList = "whiteList"
+	PlateBlockList = "blackList"
+)
+
+// LicensePlate is an entry of the allow or block list of an ANPR camera. ID
+// is the camera's identifier of the entry, used to delete it.
+type LicensePlate struct {
+	ID    string `xml:"id,omitempty"`
+	Plate string `xml:"LicensePlate"`
+	List  string `xml:"listType"`
+}
+
+// ANPREvent is the plate an ANPR camera read, delivered with EventANPR.
+// List is the plate list it matched, PlateAllowList, PlateBlockList or
+// otherList.
+type ANPREvent struct {
+	Plate       string `xml:"licensePlate" json:"licensePlate"`
+	Country     string `xml:"country" json:"country,omitempty"`
+	Lane        int    `xml:"line" json:"line,omitempty"`
+	Direction   string `xml:"direction" json:"direction,omitempty"`
+	Confidence  int    `xml:"confidenceLevel" json:"confidenceLevel,omitempty"`
+	PlateColor  string `xml:"plateColor" json:"plateColor,omitempty"`
+	VehicleType string `xml:"vehicleType" json:"vehicleType,omitempty"`
+	List        string `xml:"vehicleListName" json:"vehicleListName,omitempty"`
+}
+
+func trafficPath(channel int, op string) string {
+	return fmt.Sprintf("/ISAPI/Traffic/channels/%d/%s", channel, op)
+}
+
+type licensePlateInfoList struct {
+	XMLName xml.Name       `xml:"LicensePlateInfoList"`
+	Plates  []LicensePlate `xml:"LicensePlateInfo"`
+}
+
+// plateSearchDescription is the body of POST
+// /ISAPI/Traffic/channels/<id>/searchLPListAudit.
+type plateSearchDescription struct {
+	XMLName  xml.Name `xml:"LPListAuditSearchDescription"`
+	SearchID string   `xml:"searchID"`
+	Max      int      `xml:"maxResult"`
+	Position int      `xml:"searchResultPosition"`
+	// Type is all, whiteList or blackList.
+	Type  string `xml:"type"`
+	Plate string `xml:"LicensePlate,omitempty"`
+}
+
+type plateSearchResult struct {
+	XMLName xml.Name       `xml:"LPListAuditSearchResult"`
+	Status  string         `xml:"responseStatusStrg"`
+	Plates  []LicensePlate `xml:"LicensePlateInfoList>LicensePlateInfo"`
+}
+
+// GetPlates returns the plates of list, or of both lists if list is empty,
+// asking again while the camera has more.
+// Calls POST /ISAPI/Traffic/channels/<id>/searchLPListAudit.
+func (c *Camera) GetPlates(ctx context.Context, channel int, list string) ([]LicensePlate, error) {
+	req := plateSearchDescription{SearchID: strings.ToUpper(newUUID()), Max: 100, Type: list}
+	if req.Type == "" {
+		req.Type = "all"
+	}
+	var plates []LicensePlate
+	for {
+		body, err := xml.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("encode search: %w", err)
+		}
+		// The search only reads, so a dry run sends it.
+		resp, err := c.do(withRead(ctx), http.MethodPost, trafficPath(channel, "searchLPListAudit"), body)
+		if err != nil {
+			return nil, err
+		}
+		var res plateSearchResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode plate search result: %w", err)
+		}
+		plates = append(plates, res.Plates...)
+		if !strings.EqualFold(res.Status, "MORE") || len(res.Plates) == 0 {
+			return plates, nil
+		}
+		req.Position += len(res.Plates)
+	}
+}
+
+// AddPlates adds plates to the lists their List names. Their IDs are left
+// for the camera to assign.
+// Calls PUT /ISAPI/Traffic/channels/<id>/licensePlateAuditData.
+func (c *Camera) AddPlates(ctx context.Context, channel int, plates []LicensePlate) error {
+	list := licensePlateInfoList{Plates: make([]LicensePlate, len(plates))}
+	for i, p := range plates {
+		list.Plates[i] = LicensePlate{Plate: p.Plate, List: p.List}
+	}
+	return c.putXML(ctx, trafficPath(channel, "licensePlateAuditData"), &list)
+}
+
+type licensePlateIDList struct {
+	XMLName xml.Name `xml:"LicensePlateIdList"`
+	IDs     []string `xml:"id"`
+}
+
+// DeletePlates removes the plate list entries of the given IDs, as returned
+// by GetPlates.
+// Calls PUT /ISAPI/Traffic/channels/<id>/DelLicensePlateAuditData.
+func (c *Camera) DeletePlates(ctx context.Context, channel int, ids []string) error {
+	return c.putXML(ctx, trafficPath(channel, "DelLicensePlateAuditData"), &licensePlateIDList{IDs: ids})
+}
@@ -0,0 +1,74 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// AudioChannel is the audio input and output configuration of one audio
+// channel, as served by /ISAPI/System/Audio/channels/<id>.
+type AudioChannel struct {
+	XMLName xml.Name `xml:"AudioChannel"`
+	ID      int      `xml:"id"`
+	Enabled bool     `xml:"enabled"`
+	// Mode is "listenonly" or "talkandlisten" on models with a speaker.
+	Mode string `xml:"audioMode,omitempty"`
+	// MicrophoneVolume and SpeakerVolume are 0-100.
+	MicrophoneVolume int          `xml:"microphoneVolume"`
+	SpeakerVolume    int          `xml:"speakerVolume,omitempty"`
+	NoiseReduction   bool         `xml:"noisereduce"`
+	Extra            []rawElement `xml:",any"`
+}
+
+type audioChannelList struct {
+	XMLName  xml.Name       `xml:"AudioChannelList"`
+	Channels []AudioChannel `xml:"AudioChannel"`
+}
+
+// GetAudioChannels returns the configuration of every audio channel.
+func (c *Camera) GetAudioChannels(ctx context.Context) ([]AudioChannel, error) {
+	var list audioChannelList
+	if err := c.getXML(ctx, "/ISAPI/System/Audio/channels", &list); err != nil {
+		return nil, err
+	}
+	return list.Channels, nil
+}
+
+// SetAudioChannel replaces the configuration of audio channel a.ID.
+func (c *Camera) SetAudioChannel(ctx context.Context, a *AudioChannel) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/Audio/channels/%d", a.ID), a)
+}
+
+type twoWayAudioSession struct {
+	XMLName   xml.Name `xml:"TwoWayAudioSession"`
+	SessionID string   `xml:"sessionId"`
+}
+
+func twoWayAudioPath(channel int, op string) string {
+	return fmt.Sprintf("/ISAPI/System/TwoWayAudio/channels/%d/%s", channel, op)
+}
+
+// OpenTwoWayAudio starts a two-way audio session on a channel and returns
+// its session ID. Only one session can be open at a time; the camera answers
+// busy while another client holds it.
+func (c *Camera) OpenTwoWayAudio(ctx context.Context, channel int) (string, error) {
+	resp, err := c.do(ctx, http.MethodPut, twoWayAudioPath(channel, "open"), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var s twoWayAudioSession
+	if err := xml.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	return s.SessionID, nil
+}
+
+// CloseTwoWayAudio ends the two-way audio session on a channel, including one
+// left open by another client.
+func (c *Camera) CloseTwoWayAudio(ctx context.Context, channel int) error {
+	return c.putEmpty(ctx, twoWayAudioPath(channel, "close"))
+}
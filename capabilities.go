@@ -0,0 +1,165 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Capabilities is the subset of /ISAPI/System/capabilities (DeviceCap) the
+// client uses to adapt to different firmware generations.
+type Capabilities struct {
+	XMLName      xml.Name `xml:"DeviceCap"`
+	VideoInputs  int      `xml:"SysCap>VideoCap>videoInputPortNums"`
+	AlarmInputs  int      `xml:"SysCap>IOCap>IOInputPortNums"`
+	AlarmOutputs int      `xml:"SysCap>IOCap>IOOutputPortNums"`
+	Snapshot     bool     `xml:"isSupportSnapshot"`
+	// SupplementLight is set by ColorVu and hybrid-light models whose
+	// illuminators are driven through supplementLight.
+	SupplementLight bool `xml:"ImageCap>isSupportSupplementLight"`
+}
+
+// IREndpoint identifies the ISAPI resource that controls a camera's IR
+// illuminator.
+type IREndpoint int
+
+const (
+	// IREndpointAuto detects the endpoint from the camera's capabilities.
+	IREndpointAuto IREndpoint = iota
+	// IREndpointHardware is IrLightSwitch under /ISAPI/System/Hardware.
+	IREndpointHardware
+	// IREndpointSupplementLight is /ISAPI/Image/channels/N/supplementLight.
+	IREndpointSupplementLight
+	// IREndpointIRCutFilter is /ISAPI/Image/channels/N/ircutFilter, for
+	// cameras whose IR LEDs simply follow the day/night mode.
+	IREndpointIRCutFilter
+	// IREndpointLegacy is the IR-cut filter of the PSIA API that firmware
+	// before 5.x serves instead of ISAPI, under /PSIA/Custom/SelfExt.
+	IREndpointLegacy
+	// IREndpointONVIF is the IrCutFilter setting of the ONVIF Imaging
+	// service, for OEM units that reject ISAPI but speak ONVIF.
+	IREndpointONVIF
+)
+
+func (e IREndpoint) String() string {
+	switch e {
+	case IREndpointAuto:
+		return "auto"
+	case IREndpointHardware:
+		return "hardware"
+	case IREndpointSupplementLight:
+		return "supplementLight"
+	case IREndpointIRCutFilter:
+		return "ircutFilter"
+	case IREndpointLegacy:
+		return "legacy"
+	case IREndpointONVIF:
+		return "onvif"
+	}
+	return fmt.Sprintf("IREndpoint(%d)", int(e))
+}
+
+// Capabilities returns the camera's capabilities, fetching them on first use
+// and caching them on the Camera afterwards.
+func (c *Camera) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.mu.Lock()
+	caps := c.caps
+	c.mu.Unlock()
+	if caps != nil {
+		return caps, nil
+	}
+
+	caps = new(Capabilities)
+	if err := c.getXML(ctx, "/ISAPI/System/capabilities", caps); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.caps = caps
+	c.mu.Unlock()
+	return caps, nil
+}
+
+// DetectIREndpoint returns the endpoint IR operations are routed to. If
+// c.IREndpoint is set it is returned as is; otherwise the camera is queried
+// once and the result cached.
+func (c *Camera) DetectIREndpoint(ctx context.Context) (IREndpoint, error) {
+	if c.IREndpoint != IREndpointAuto {
+		return c.IREndpoint, nil
+	}
+	c.mu.Lock()
+	ep := c.irEndpoint
+	c.mu.Unlock()
+	if ep != IREndpointAuto {
+		return ep, nil
+	}
+
+	ep, err := c.probeIREndpoint(ctx)
+	if err != nil {
+		return IREndpointAuto, err
+	}
+	c.mu.Lock()
+	c.irEndpoint = ep
+	c.mu.Unlock()
+	return ep, nil
+}
+
+// probeIREndpoint works out which IR endpoint the camera serves. The
+// capabilities document is consulted first; because many firmwares omit the
+// relevant flags, the candidate endpoints are then tried in turn, ending with
+// the legacy PSIA API of old firmware and ONVIF for units that reject ISAPI
+// altogether. An endpoint the camera refuses, with any HTTP error such as the
+// 403 or 400 invalidOperation OEM firmware answers ISAPI with, moves on to
+// the next; ErrUnauthorized, a camera that cannot be reached and a done ctx
+// are returned. Behind an NVR only the proxied per-channel endpoints apply:
+// the capabilities and Hardware resources would describe the NVR itself.
+func (c *Camera) probeIREndpoint(ctx context.Context) (IREndpoint, error) {
+	// stop reports whether err ends the probing: a camera that rejects
+	// the login or cannot be reached would fail every endpoint.
+	stop := func(err error) bool {
+		var ue *url.Error
+		return err != nil && (errors.Is(err, ErrUnauthorized) || errors.As(err, &ue) || ctx.Err() != nil)
+	}
+	if !c.NVR {
+		caps, err := c.Capabilities(ctx)
+		if stop(err) {
+			return IREndpointAuto, err
+		}
+		if err == nil && caps.SupplementLight {
+			return IREndpointSupplementLight, nil
+		}
+
+		var hw hardwareService
+		err = c.getXML(ctx, "/ISAPI/System/Hardware", &hw)
+		if stop(err) {
+			return IREndpointAuto, err
+		}
+		if err == nil && hw.IrLightSwitch.Mode != "" {
+			return IREndpointHardware, nil
+		}
+	}
+	sl, err := c.GetSupplementLight(ctx, c.irChannel())
+	if stop(err) {
+		return IREndpointAuto, err
+	}
+	if err == nil && sl.Mode != "" {
+		return IREndpointSupplementLight, nil
+	}
+	if _, err := c.GetIRCutFilter(ctx, c.irChannel()); err == nil {
+		return IREndpointIRCutFilter, nil
+	} else if stop(err) {
+		return IREndpointAuto, err
+	}
+	if _, err := c.PSIA().GetIRCutFilter(ctx, c.irChannel()); err == nil {
+		return IREndpointLegacy, nil
+	} else if stop(err) {
+		return IREndpointAuto, err
+	}
+	if _, err := c.ONVIF().GetIRCutFilter(ctx, c.irChannel()); err == nil {
+		return IREndpointONVIF, nil
+	} else if stop(err) {
+		return IREndpointAuto, err
+	}
+	return IREndpointAuto, fmt.Errorf("camera exposes no supported IR endpoint")
+}
@@ -0,0 +1,73 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+)
+
+const (
+	certificatePath        = "/ISAPI/Security/serverCertificate/certificate"
+	certificateRequestPath = "/ISAPI/Security/serverCertificate/certificateRequest"
+)
+
+// CertificateRequest is the subject of a certificate signing request for
+// the camera's HTTPS server. The camera generates the key pair itself, so
+// the private key never leaves it.
+type CertificateRequest struct {
+	XMLName xml.Name `xml:"CertificateReq"`
+	// Country is a two-letter country code.
+	Country      string `xml:"countryName"`
+	State        string `xml:"province,omitempty"`
+	Locality     string `xml:"locality,omitempty"`
+	Organization string `xml:"organization,omitempty"`
+	Unit         string `xml:"unit,omitempty"`
+	// CommonName is the host name or IP address clients connect to.
+	CommonName string `xml:"commonName"`
+	Email      string `xml:"email,omitempty"`
+}
+
+// CreateCertificateRequest makes the camera generate a new key pair and a
+// signing request for it, replacing any pending one. Fetch the request with
+// GetCertificateRequest.
+// Calls PUT /ISAPI/Security/serverCertificate/certificateRequest.
+func (c *Camera) CreateCertificateRequest(ctx context.Context, r *CertificateRequest) error {
+	return c.putXML(ctx, certificateRequestPath, r)
+}
+
+// GetCertificateRequest returns the pending certificate signing request of
+// the camera, PEM encoded.
+// Calls GET /ISAPI/Security/serverCertificate/certificateRequest.
+func (c *Camera) GetCertificateRequest(ctx context.Context) ([]byte, error) {
+	return c.GetRaw(ctx, certificateRequestPath)
+}
+
+// CertificateInfo describes the certificate the camera's HTTPS server
+// presents, as served by /ISAPI/Security/serverCertificate/certificate.
+type CertificateInfo struct {
+	XMLName xml.Name `xml:"CertificateInfo"`
+	Subject string   `xml:"subjectDN"`
+	Issuer  string   `xml:"issuerDN"`
+	// ValidFrom and ValidTo are dates as the camera prints them, such as
+	// 2025-01-31.
+	ValidFrom string       `xml:"startDate"`
+	ValidTo   string       `xml:"endDate"`
+	Extra     []rawElement `xml:",any"`
+}
+
+// GetCertificate returns the certificate of the camera's HTTPS server.
+func (c *Camera) GetCertificate(ctx context.Context) (*CertificateInfo, error) {
+	var info CertificateInfo
+	if err := c.getXML(ctx, certificatePath, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// InstallCertificate installs a PEM encoded certificate, signed for the
+// pending request of CreateCertificateRequest, as the certificate of the
+// camera's HTTPS server. The HTTPS server restarts with it.
+// Calls PUT /ISAPI/Security/serverCertificate/certificate.
+func (c *Camera) InstallCertificate(ctx context.Context, cert []byte) error {
+	return c.command(ctx, http.MethodPut, certificatePath, cert)
+}
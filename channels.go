@@ -0,0 +1,106 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+)
+
+// VideoInput is a video input channel of a device: the sensor of a camera,
+// each lens of a multi-sensor (PanoVu) camera, or an analog input of a DVR,
+// as served by /ISAPI/System/Video/inputs/channels.
+type VideoInput struct {
+	ID        int    `xml:"id"`
+	InputPort int    `xml:"inputPort"`
+	Name      string `xml:"name"`
+	// Enabled is false when the input is switched off; absent on most
+	// cameras, where it is always on.
+	Enabled *bool `xml:"videoInputEnabled"`
+	// Resolution describes the signal of an analog input, such as
+	// "1920*1080P25".
+	Resolution string `xml:"resDesc"`
+}
+
+type videoInputList struct {
+	XMLName xml.Name     `xml:"VideoInputChannelList"`
+	Inputs  []VideoInput `xml:"VideoInputChannel"`
+}
+
+// GetVideoInputs returns the video input channels of the device.
+// Calls GET /ISAPI/System/Video/inputs/channels.
+func (c *Camera) GetVideoInputs(ctx context.Context) ([]VideoInput, error) {
+	var list videoInputList
+	if err := c.getXML(ctx, "/ISAPI/System/Video/inputs/channels", &list); err != nil {
+		return nil, err
+	}
+	return list.Inputs, nil
+}
+
+type streamConfigList struct {
+	XMLName  xml.Name       `xml:"StreamingChannelList"`
+	Channels []StreamConfig `xml:"StreamingChannel"`
+}
+
+// GetStreamConfigs returns the configuration of every streaming channel of
+// the device. On an NVR these are the NVR's own channels, one per attached
+// camera and stream, regardless of Camera.NVR.
+// Calls GET /ISAPI/Streaming/channels.
+func (c *Camera) GetStreamConfigs(ctx context.Context) ([]StreamConfig, error) {
+	var list streamConfigList
+	if err := c.getXML(ctx, "/ISAPI/Streaming/channels", &list); err != nil {
+		return nil, err
+	}
+	return list.Channels, nil
+}
+
+// ProxyChannel is an IP camera attached to an NVR, as served by
+// /ISAPI/ContentMgmt/InputProxy/channels. Its ID is the channel to pass
+// as Camera.Channel to reach it through the NVR.
+type ProxyChannel struct {
+	ID       int    `xml:"id"`
+	Name     string `xml:"name"`
+	Address  string `xml:"sourceInputPortDescriptor>ipAddress"`
+	Port     int    `xml:"sourceInputPortDescriptor>managePortNo"`
+	Protocol string `xml:"sourceInputPortDescriptor>proxyProtocol"`
+	// Online reports whether the NVR reaches the camera. It is false on
+	// NVRs that do not report channel status.
+	Online bool `xml:"-"`
+}
+
+type proxyChannelList struct {
+	XMLName  xml.Name       `xml:"InputProxyChannelList"`
+	Channels []ProxyChannel `xml:"InputProxyChannel"`
+}
+
+type proxyChannelStatusList struct {
+	XMLName  xml.Name `xml:"InputProxyChannelStatusList"`
+	Channels []struct {
+		ID     int  `xml:"id"`
+		Online bool `xml:"online"`
+	} `xml:"InputProxyChannelStatus"`
+}
+
+// GetProxyChannels returns the IP cameras attached to an NVR with whether
+// each is online.
+// Calls GET /ISAPI/ContentMgmt/InputProxy/channels and
+// /ISAPI/ContentMgmt/InputProxy/channels/status.
+func (c *Camera) GetProxyChannels(ctx context.Context) ([]ProxyChannel, error) {
+	var list proxyChannelList
+	if err := c.getXML(ctx, "/ISAPI/ContentMgmt/InputProxy/channels", &list); err != nil {
+		return nil, err
+	}
+	var status proxyChannelStatusList
+	switch err := c.getXML(ctx, "/ISAPI/ContentMgmt/InputProxy/channels/status", &status); {
+	case err == nil:
+		online := make(map[int]bool)
+		for _, s := range status.Channels {
+			online[s.ID] = s.Online
+		}
+		for i := range list.Channels {
+			list.Channels[i].Online = online[list.Channels[i].ID]
+		}
+	case !errors.Is(err, ErrNotSupported):
+		return nil, err
+	}
+	return list.Channels, nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// dayNight prints the IR-cut filter mode of channel, or changes it when mode is set.
+func dayNight(ctx context.Context, cam *hikvision.Camera, channel int, mode, schedule string, w io.Writer) error {
+	if cam.IREndpoint == hikvision.IREndpointONVIF {
+		return dayNightONVIF(ctx, cam, channel, mode, w)
+	}
+	f, err := cam.GetIRCutFilter(ctx, channel)
+	if err != nil {
+		// Units that reject ISAPI may still take the mode over the legacy
+		// PSIA API, which GetIRCutFilter uses once detected, or over ONVIF.
+		ep, derr := cam.DetectIREndpoint(ctx)
+		switch {
+		case derr == nil && ep == hikvision.IREndpointONVIF:
+			return dayNightONVIF(ctx, cam, channel, mode, w)
+		case derr == nil && ep == hikvision.IREndpointLegacy:
+			if f, err = cam.GetIRCutFilter(ctx, channel); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	switch hikvision.IRCutFilterMode(mode) {
+	case "":
+		fmt.Fprintf(w, "day/night: %s\n", f.Type)
+		if f.Type == hikvision.IRCutSchedule && f.Schedule != nil {
+			fmt.Fprintf(w, "daytime: %s-%s\n", f.Schedule.BeginTime, f.Schedule.EndTime)
+		}
+		return nil
+	case hikvision.IRCutDay, hikvision.IRCutNight, hikvision.IRCutAuto:
+	case hikvision.IRCutSchedule:
+		begin, end, ok := strings.Cut(schedule, "-")
+		if !ok {
+			return fmt.Errorf("--mode schedule needs --schedule BEGIN-END, e.g. 07:00:00-18:00:00")
+		}
+		if f.Schedule == nil {
+			f.Schedule = &hikvision.DayNightSchedule{ScheduleType: "day"}
+		}
+		f.Schedule.BeginTime, f.Schedule.EndTime = begin, end
+	default:
+		return fmt.Errorf("unknown mode %q — must be day, night, auto, or schedule", mode)
+	}
+
+	f.Type = hikvision.IRCutFilterMode(mode)
+	if err := cam.SetIRCutFilter(ctx, channel, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "day/night: %s\n", f.Type)
+	return nil
+}
+
+// dayNightONVIF is dayNight over the ONVIF Imaging service, which has no
+// schedule mode.
+func dayNightONVIF(ctx context.Context, cam *hikvision.Camera, channel int, mode string, w io.Writer) error {
+	if mode == "" {
+		f, err := cam.ONVIF().GetIRCutFilter(ctx, channel)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "day/night: %s\n", f.Type)
+		return nil
+	}
+	switch m := hikvision.IRCutFilterMode(mode); m {
+	case hikvision.IRCutDay, hikvision.IRCutNight, hikvision.IRCutAuto:
+		if err := cam.ONVIF().SetIRCutFilterMode(ctx, channel, m); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown mode %q — must be day, night, or auto over ONVIF", mode)
+	}
+	fmt.Fprintf(w, "day/night: %s\n", mode)
+	return nil
+}
+
+// snapshot writes a JPEG from the main stream of channel to path, or to w
+// when path is "-".
+func snapshot(ctx context.Context, cam hikvision.Controller, channel int, path string, w io.Writer) error {
+	img, err := cam.Snapshot(ctx, hikvision.StreamingChannelID(channel, 1))
+	if err != nil {
+		return err
+	}
+	defer img.Close()
+
+	if path == "-" {
+		_, err = io.Copy(w, img)
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// irAction runs the on, off, auto and status actions on the IR of one video
+// channel, starting each line it prints with prefix. On and auto also apply
+// --brightness unless it is negative.
+func irAction(ctx context.Context, cam *hikvision.Camera, channel int, prefix string, a actionArgs, w io.Writer) error {
+	if a.action == "status" {
+		mode, err := cam.GetChannelIRMode(ctx, channel)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%sIR mode: %s\n", prefix, mode)
+		if b, ok, err := cam.GetChannelIRBrightness(ctx, channel); err == nil && ok {
+			fmt.Fprintf(w, "%sIR brightness: %d%%\n", prefix, b)
+		}
+		return nil
+	}
+	if err := cam.SetChannelIRMode(ctx, channel, irModes[a.action]); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%sIR light: %s\n", prefix, a.action)
+	if a.action == "off" || a.brightness < 0 {
+		return nil
+	}
+	if err := cam.SetChannelIRBrightness(ctx, channel, a.brightness); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%sIR brightness: %d%%\n", prefix, a.brightness)
+	return nil
+}
+
+// irAllLenses runs an IR action on every enabled video input of a
+// multi-sensor camera, prefixing the output of each with its lens.
+func irAllLenses(ctx context.Context, cam *hikvision.Camera, a actionArgs, w io.Writer) error {
+	inputs, err := videoInputs(ctx, cam)
+	if err != nil {
+		return err
+	}
+	for _, in := range inputs {
+		if in.Enabled != nil && !*in.Enabled {
+			continue
+		}
+		if err := irAction(ctx, cam, in.ID, fmt.Sprintf("lens %d: ", in.ID), a, w); err != nil {
+			return fmt.Errorf("lens %d: %w", in.ID, err)
+		}
+	}
+	return nil
+}
+
+// irModes maps the mode names accepted by serve and mqtt to IR modes. Both the
+// CLI names and the raw IrLightSwitch values are accepted.
+var irModes = map[string]hikvision.IRMode{
+	"on":    hikvision.IRModeOpen,
+	"off":   hikvision.IRModeClose,
+	"auto":  hikvision.IRModeAuto,
+	"open":  hikvision.IRModeOpen,
+	"close": hikvision.IRModeClose,
+}
+
+// lightModes maps the --mode names of the light action to supplementLight modes.
+var lightModes = map[string]hikvision.SupplementLightMode{
+	"ir":    hikvision.SupplementLightIR,
+	"white": hikvision.SupplementLightWhite,
+	"mixed": hikvision.SupplementLightMixed,
+	"off":   hikvision.SupplementLightOff,
+}
+
+// light prints the supplement light settings of channel, or changes the mode
+// and brightness when given. Setting a brightness switches to manual regulation.
+func light(ctx context.Context, cam *hikvision.Camera, channel int, mode string, brightness int, w io.Writer) error {
+	sl, err := cam.GetSupplementLight(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	if mode != "" || brightness >= 0 {
+		if mode != "" {
+			m, ok := lightModes[mode]
+			if !ok {
+				return fmt.Errorf("unknown light mode %q — must be ir, white, mixed, or off", mode)
+			}
+			sl.Mode = m
+		}
+		if brightness >= 0 {
+			if brightness > 100 {
+				return fmt.Errorf("brightness %d out of range 0-100", brightness)
+			}
+			sl.BrightnessMode = hikvision.BrightnessManual
+			if sl.Mode == hikvision.SupplementLightWhite {
+				sl.WhiteBrightness = &brightness
+			} else {
+				sl.IRBrightness = &brightness
+			}
+		}
+		if err := cam.SetSupplementLight(ctx, channel, sl); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "light: %s\n", sl.Mode)
+	if sl.BrightnessMode != "" {
+		fmt.Fprintf(w, "brightness: %s (IR %s, white %s)\n", sl.BrightnessMode, percent(sl.IRBrightness), percent(sl.WhiteBrightness))
+	}
+	return nil
+}
+
+// percent formats a brightness level the camera may not report.
+func percent(level *int) string {
+	if level == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", *level)
+}
+
+// raw sends an arbitrary ISAPI request given as METHOD PATH and prints the
+// response body. bodyFile supplies the request body for PUT and POST.
+func raw(ctx context.Context, cam *hikvision.Camera, positional []string, bodyFile string, w io.Writer) error {
+	if len(positional) != 2 {
+		return fmt.Errorf("raw needs <METHOD> <path>, e.g. raw GET /ISAPI/System/status")
+	}
+	method, path := strings.ToUpper(positional[0]), positional[1]
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %q must start with /", path)
+	}
+
+	var body []byte
+	if bodyFile != "" {
+		var err error
+		if bodyFile == "-" {
+			body, err = io.ReadAll(os.Stdin)
+		} else {
+			body, err = os.ReadFile(bodyFile)
+		}
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+	}
+
+	resp, err := cam.Do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// notificationFormats maps CLI names to HTTPHost.ParameterFormat values.
+var notificationFormats = map[string]string{
+	"xml":  hikvision.NotificationXML,
+	"json": hikvision.NotificationJSON,
+}
+
+// hostAuths maps CLI names to HTTPHost.Auth values.
+var hostAuths = map[string]string{
+	"none":   "none",
+	"digest": "MD5digest",
+	"basic":  "base64",
+}
+
+// alarmHost lists the alarm receivers the camera pushes notifications to or,
+// given an id and settings, shows and changes one:
+//
+//	alarmhost [<id>] url=http://192.168.1.10:8080/alarm [format=json] ...
+//	alarmhost test [<id>]
+//
+// The id defaults to 1 when settings are given.
+func alarmHost(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	if len(args) > 0 && args[0] == "test" {
+		id := 1
+		if len(args) > 2 {
+			return fmt.Errorf("alarmhost test takes at most an id")
+		}
+		if len(args) == 2 {
+			var err error
+			if id, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid alarm host id %q", args[1])
+			}
+		}
+		if err := cam.TestHTTPHost(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "alarm host %d: test notification sent\n", id)
+		return nil
+	}
+
+	hosts, err := cam.GetHTTPHosts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		for _, h := range hosts {
+			fmt.Fprintf(w, "%d: %s\n", h.ID, hostSummary(h))
+		}
+		return nil
+	}
+
+	id := 1
+	if !strings.Contains(args[0], "=") {
+		if id, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid alarm host id %q", args[0])
+		}
+		args = args[1:]
+	}
+	var h *hikvision.HTTPHost
+	for i := range hosts {
+		if hosts[i].ID == id {
+			h = &hosts[i]
+		}
+	}
+	if h == nil {
+		return fmt.Errorf("no alarm host %d", id)
+	}
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if err := applyHostSettings(h, set); err != nil {
+			return err
+		}
+		if err := cam.SetHTTPHost(ctx, h); err != nil {
+			return err
+		}
+	}
+	for _, k := range []string{"url", "format", "auth", "user"} {
+		if v := hostSettings(*h)[k]; v != "" {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+	return nil
+}
+
+// applyHostSettings applies url (protocol, address, port and path in one),
+// protocol, host, port, path, format, auth, user and pass settings. An empty
+// host clears the receiver.
+func applyHostSettings(h *hikvision.HTTPHost, set map[string]string) error {
+	if v, ok := set["url"]; ok {
+		u, err := url.Parse(v)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("url=%s: want a URL such as http://192.168.1.10:8080/alarm", v)
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		set["protocol"], set["host"], set["port"], set["path"] = u.Scheme, u.Hostname(), port, u.Path
+		if u.RawQuery != "" {
+			set["path"] += "?" + u.RawQuery
+		}
+	}
+	for k, v := range set {
+		switch k {
+		case "url":
+		case "protocol":
+			if v != "http" && v != "https" {
+				return fmt.Errorf("protocol=%s: must be http or https", v)
+			}
+			h.Protocol = strings.ToUpper(v)
+		case "host":
+			switch {
+			case v == "":
+				h.AddressingFormat, h.IPAddress, h.HostName = "ipaddress", "0.0.0.0", ""
+			case net.ParseIP(v) != nil:
+				h.AddressingFormat, h.IPAddress = "ipaddress", v
+			default:
+				h.AddressingFormat, h.HostName = "hostname", v
+			}
+		case "port":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("port=%s: want a port 1-65535", v)
+			}
+			h.Port = n
+		case "path":
+			if v == "" {
+				v = "/"
+			}
+			if !strings.HasPrefix(v, "/") {
+				return fmt.Errorf("path=%s: must start with /", v)
+			}
+			h.URL = v
+		case "format":
+			f, ok := notificationFormats[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("format=%s: must be xml or json", v)
+			}
+			h.ParameterFormat = f
+		case "auth":
+			a, ok := hostAuths[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("auth=%s: must be none, digest or basic", v)
+			}
+			h.Auth = a
+		case "user":
+			h.Username = v
+		case "pass":
+			h.Password = v
+		default:
+			return fmt.Errorf("unknown alarm host setting %q", k)
+		}
+	}
+	if h.Protocol == "" {
+		h.Protocol = hikvision.ProtocolHTTP
+	}
+	return nil
+}
+
+// hostSettings returns the settings of h keyed like applyHostSettings takes
+// them, with the address as a single url that leaves out the default port.
+func hostSettings(h hikvision.HTTPHost) map[string]string {
+	set := map[string]string{}
+	if addr := h.Address(); addr != "" && addr != "0.0.0.0" {
+		scheme := strings.ToLower(h.Protocol)
+		host := addr
+		if (scheme == "http" && h.Port != 80) || (scheme == "https" && h.Port != 443) {
+			host = net.JoinHostPort(addr, strconv.Itoa(h.Port))
+		} else if strings.Contains(addr, ":") {
+			host = "[" + addr + "]"
+		}
+		u := url.URL{Scheme: scheme, Host: host}
+		u.Path, u.RawQuery, _ = strings.Cut(h.URL, "?")
+		set["url"] = u.String()
+	}
+	if h.ParameterFormat != "" {
+		set["format"] = nameOf(notificationFormats, h.ParameterFormat)
+	}
+	if h.Auth != "" {
+		set["auth"] = nameOf(hostAuths, h.Auth)
+	}
+	set["user"] = h.Username
+	return set
+}
+
+// hostSummary formats an alarm host on one line.
+func hostSummary(h hikvision.HTTPHost) string {
+	set := hostSettings(h)
+	if set["url"] == "" {
+		return "unset"
+	}
+	s := set["url"]
+	if set["format"] != "" {
+		s += " (" + set["format"] + ")"
+	}
+	if a := set["auth"]; a != "" && a != "none" {
+		s += ", auth " + a
+		if set["user"] != "" {
+			s += " as " + set["user"]
+		}
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// plateLists maps CLI names to plate lists.
+var plateLists = map[string]string{
+	"allow": hikvision.PlateAllowList,
+	"block": hikvision.PlateBlockList,
+}
+
+// anpr manages the plate lists of an ANPR camera: "anpr plates [allow|block]"
+// prints them as "<plate>: <list>" lines, "anpr plates add allow|block
+// <plate> ..." adds plates and "anpr plates remove <plate> ..." removes
+// them from whichever list holds them.
+func anpr(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) == 0 || positional[0] != "plates" {
+		return fmt.Errorf("anpr needs plates, e.g. anpr plates add block AB12CDE")
+	}
+	positional = positional[1:]
+	op := "list"
+	if len(positional) > 0 && (positional[0] == "add" || positional[0] == "remove") {
+		op, positional = positional[0], positional[1:]
+	}
+
+	switch op {
+	case "add":
+		if len(positional) < 2 {
+			return fmt.Errorf("anpr plates add needs a list and plates, e.g. anpr plates add allow AB12CDE")
+		}
+		list, ok := plateLists[positional[0]]
+		if !ok {
+			return fmt.Errorf("unknown plate list %q — must be allow or block", positional[0])
+		}
+		plates := make([]hikvision.LicensePlate, len(positional)-1)
+		for i, p := range positional[1:] {
+			plates[i] = hikvision.LicensePlate{Plate: strings.ToUpper(p), List: list}
+		}
+		if err := cam.AddPlates(ctx, channel, plates); err != nil {
+			return err
+		}
+		for _, p := range plates {
+			fmt.Fprintf(w, "%s: added to %s list\n", p.Plate, positional[0])
+		}
+		return nil
+
+	case "remove":
+		if len(positional) == 0 {
+			return fmt.Errorf("anpr plates remove needs plates, e.g. anpr plates remove AB12CDE")
+		}
+		plates, err := cam.GetPlates(ctx, channel, "")
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, want := range positional {
+			i := slices.IndexFunc(plates, func(p hikvision.LicensePlate) bool { return strings.EqualFold(p.Plate, want) })
+			if i < 0 {
+				return fmt.Errorf("plate %s is on no list", want)
+			}
+			ids = append(ids, plates[i].ID)
+		}
+		if err := cam.DeletePlates(ctx, channel, ids); err != nil {
+			return err
+		}
+		for _, p := range positional {
+			fmt.Fprintf(w, "%s: removed\n", strings.ToUpper(p))
+		}
+		return nil
+	}
+
+	var list string
+	switch len(positional) {
+	case 0:
+	case 1:
+		var ok bool
+		if list, ok = plateLists[positional[0]]; !ok {
+			return fmt.Errorf("unknown plate list %q — must be allow or block", positional[0])
+		}
+	default:
+		return fmt.Errorf("anpr plates takes at most one list: allow or block")
+	}
+	plates, err := cam.GetPlates(ctx, channel, list)
+	if err != nil {
+		return err
+	}
+	for _, p := range plates {
+		fmt.Fprintf(w, "%s: %s\n", p.Plate, nameOf(plateLists, p.List))
+	}
+	if len(plates) == 0 {
+		fmt.Fprintln(w, "plates: none")
+	}
+	return nil
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// deviceSpec is the desired state read by the apply action:
+//
+//	ir: auto
+//	daynight: auto
+//	image:
+//	  brightness: 55
+//	  wdr: auto
+//	osd:
+//	  text: "{name}"
+//	  date: on
+//	fisheye:
+//	  mount: ceiling
+//	  display: panorama
+//	ntp:
+//	  server: pool.ntp.org
+//	  interval: 60
+//	alarmhost:
+//	  url: http://192.168.1.10:8080/alarm
+//	  format: json
+//	email:
+//	  server: smtp.example.com:587
+//	  security: tls
+//	  to: ops@example.com
+//	ftp:
+//	  server: ftp.example.com
+//	  user: cameras
+//	  pictures: on
+//	snmp:
+//	  v2c: on
+//	  read: monitoring
+//	  trap: 192.168.1.20
+//	streams:
+//	  main: {codec: h265, max-bitrate: 4096}
+//	  sub: {resolution: 640x360}
+//	record:
+//	  enabled: on
+//	  schedule: "mon-fri 07:00-19:00 motion; mon-fri 19:00-07:00 continuous; sat,sun continuous"
+//	patrols:
+//	  1: "1 10s, 2 10s, 3 15s speed=30"
+//	park:
+//	  enabled: on
+//	  wait: 60s
+//	  action: patrol 1
+//
+// Image, OSD, fisheye, alarm host, email, FTP, NAS, DDNS, SNMP, 802.1X,
+// stream, record and park settings take the keys of the image, osd, fisheye,
+// alarmhost, email, ftp, storage nas, network ddns, network snmp, network
+// 8021x, stream config, record and ptz park actions; alarmhost, ftp and nas set the first
+// receiver, server and mount. Patrols are set by id as with ptz patrol.
+// Settings left out are not touched.
+type deviceSpec struct {
+	IR        string                       `yaml:"ir"`
+	DayNight  string                       `yaml:"daynight"`
+	Image     map[string]string            `yaml:"image"`
+	OSD       map[string]string            `yaml:"osd"`
+	Fisheye   map[string]string            `yaml:"fisheye"`
+	NTP       map[string]string            `yaml:"ntp"`
+	AlarmHost map[string]string            `yaml:"alarmhost"`
+	Email     map[string]string            `yaml:"email"`
+	FTP       map[string]string            `yaml:"ftp"`
+	NAS       map[string]string            `yaml:"nas"`
+	DDNS      map[string]string            `yaml:"ddns"`
+	SNMP      map[string]string            `yaml:"snmp"`
+	Dot1X     map[string]string            `yaml:"8021x"`
+	Streams   map[string]map[string]string `yaml:"streams"`
+	Record    map[string]string            `yaml:"record"`
+	Patrols   map[string]string            `yaml:"patrols"`
+	Park      map[string]string            `yaml:"park"`
+}
+
+// loadSpec reads and validates a desired-state file.
+func loadSpec(path string) (*deviceSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var s deviceSpec
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parse spec %s: %w", path, err)
+	}
+	if s.IR != "" {
+		mode, ok := irModes[strings.ToLower(s.IR)]
+		if !ok {
+			return nil, fmt.Errorf("spec: unknown IR mode %q — must be on, off, or auto", s.IR)
+		}
+		s.IR = irModeName(mode)
+	}
+	switch hikvision.IRCutFilterMode(strings.ToLower(s.DayNight)) {
+	case "", hikvision.IRCutDay, hikvision.IRCutNight, hikvision.IRCutAuto:
+	default:
+		return nil, fmt.Errorf("spec: unknown daynight mode %q — must be day, night, or auto", s.DayNight)
+	}
+	for name := range s.Streams {
+		if _, err := streamID(1, name); err != nil {
+			return nil, fmt.Errorf("spec: %w", err)
+		}
+	}
+	if err := checkImageSettings(s.Image); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	for k := range s.NTP {
+		if k != "server" && k != "interval" {
+			return nil, fmt.Errorf("spec: unknown ntp setting %q — must be server or interval", k)
+		}
+	}
+	if len(s.NTP) > 0 && s.NTP["server"] == "" {
+		return nil, fmt.Errorf("spec: ntp needs a server")
+	}
+	for k := range s.AlarmHost {
+		switch strings.ToLower(k) {
+		case "url", "format", "auth", "user":
+		default:
+			return nil, fmt.Errorf("spec: unknown alarmhost setting %q — must be url, format, auth or user", k)
+		}
+	}
+	if err := applyHostSettings(new(hikvision.HTTPHost), lowerKeys(s.AlarmHost)); err != nil {
+		return nil, fmt.Errorf("spec: alarmhost: %w", err)
+	}
+	if s.Fisheye, err = specSettings("fisheye", "fisheye", s.Fisheye, applyFisheyeSettings, fisheyeSettings); err != nil {
+		return nil, err
+	}
+	if s.Email, err = specSettings("email", "email", s.Email, applyEmailSettings, emailSettings, "pass"); err != nil {
+		return nil, err
+	}
+	if s.FTP, err = specSettings("ftp", "ftp", s.FTP, applyFTPSettings, ftpSettings, "pass"); err != nil {
+		return nil, err
+	}
+	if s.DDNS, err = specSettings("ddns", "network ddns", s.DDNS, applyDDNSSettings, ddnsSettings, "pass"); err != nil {
+		return nil, err
+	}
+	applySNMP := func(p *snmpPair, set map[string]string) error {
+		_, _, err := applySNMPSettings(&p.v2, &p.v3, set)
+		return err
+	}
+	showSNMP := func(p snmpPair) map[string]string { return snmpSettings(p.v2, p.v3) }
+	if s.SNMP, err = specSettings("snmp", "network snmp", s.SNMP, applySNMP, showSNMP, "auth-key", "privacy-key"); err != nil {
+		return nil, err
+	}
+	if s.Dot1X, err = specSettings("8021x", "network 8021x", s.Dot1X, applyDot1XSettings, dot1xSettings, "pass"); err != nil {
+		return nil, err
+	}
+	s.NAS = lowerKeys(s.NAS)
+	if _, ok := s.NAS["pass"]; ok {
+		return nil, fmt.Errorf("spec: nas: pass cannot be compared with the camera — set it with storage nas")
+	}
+	if err := applyNASSettings(new(hikvision.NAS), s.NAS); err != nil {
+		return nil, fmt.Errorf("spec: nas: %w", err)
+	}
+	if v, ok := s.NAS["type"]; ok {
+		s.NAS["type"] = strings.ToLower(v)
+	}
+	s.Record = lowerKeys(s.Record)
+	for k := range s.Record {
+		switch k {
+		case "enabled", "schedule", "pre", "post", "audio":
+		default:
+			return nil, fmt.Errorf("spec: unknown record setting %q — must be enabled, schedule, pre, post or audio", k)
+		}
+	}
+	if v, ok := s.Record["schedule"]; ok {
+		// The camera's schedule is compared in the form record prints.
+		slots, err := parseRecordSchedule(v)
+		if err != nil {
+			return nil, fmt.Errorf("spec: record: %w", err)
+		}
+		s.Record["schedule"] = formatRecordSchedule(slots)
+	}
+	// Patrols and park settings are compared in the form ptz prints.
+	for id, v := range s.Patrols {
+		if n, err := strconv.Atoi(id); err != nil || n < 1 {
+			return nil, fmt.Errorf("spec: invalid patrol id %q", id)
+		}
+		steps, err := parsePatrol(v)
+		if err != nil {
+			return nil, fmt.Errorf("spec: patrol %s: %w", id, err)
+		}
+		s.Patrols[id] = formatPatrol(steps)
+	}
+	s.Park = lowerKeys(s.Park)
+	for k, v := range s.Park {
+		switch k {
+		case "enabled":
+		case "wait":
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Second || d%time.Second != 0 {
+				return nil, fmt.Errorf("spec: park: wait=%s: want whole seconds such as 60s", v)
+			}
+			s.Park[k] = d.String()
+		case "action":
+			t, n, err := parseParkAction(v)
+			if err != nil {
+				return nil, fmt.Errorf("spec: park: %w", err)
+			}
+			s.Park[k] = formatParkAction(t, n)
+		default:
+			return nil, fmt.Errorf("spec: unknown park setting %q — must be enabled, wait or action", k)
+		}
+	}
+	return &s, nil
+}
+
+// specSection is one part of a deviceSpec, read and written together.
+type specSection struct {
+	name string
+	want map[string]string
+	// read returns the current settings, keyed like want.
+	read func(ctx context.Context) (map[string]string, error)
+	// write applies the changed settings.
+	write func(ctx context.Context, changes map[string]string) error
+}
+
+// change is one setting apply is going to change.
+type change struct {
+	key, from, to string
+}
+
+// applySpec compares the camera against the spec file, prints the plan, and
+// unless planOnly applies the changed sections.
+func applySpec(ctx context.Context, t target, positional []string, planOnly bool, w io.Writer) error {
+	if len(positional) != 1 {
+		return fmt.Errorf("apply needs <spec.yaml>")
+	}
+	spec, err := loadSpec(positional[0])
+	if err != nil {
+		return err
+	}
+
+	sections := specSections(t, spec)
+	changes := make([][]change, len(sections))
+	total := 0
+	for i, s := range sections {
+		have, err := s.read(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		for _, k := range sortedKeys(s.want) {
+			if from := have[k]; !sameSetting(from, s.want[k]) {
+				changes[i] = append(changes[i], change{k, from, s.want[k]})
+			}
+		}
+		for _, c := range changes[i] {
+			from := c.from
+			if from == "" {
+				from = "(unset)"
+			}
+			fmt.Fprintf(w, "%s.%s: %s -> %s\n", s.name, c.key, from, c.to)
+		}
+		total += len(changes[i])
+	}
+	if total == 0 {
+		fmt.Fprintln(w, "up to date")
+		return nil
+	}
+	if planOnly {
+		fmt.Fprintf(w, "plan: %d change(s)\n", total)
+		return nil
+	}
+
+	for i, s := range sections {
+		if len(changes[i]) == 0 {
+			continue
+		}
+		set := map[string]string{}
+		for _, c := range changes[i] {
+			set[c.key] = c.to
+		}
+		if err := s.write(ctx, set); err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+	}
+	if t.cam.DryRun != nil {
+		fmt.Fprintf(w, "dry run: %d change(s) not applied\n", total)
+		return nil
+	}
+	fmt.Fprintf(w, "applied: %d change(s)\n", total)
+	return nil
+}
+
+// specSections lists the sections of spec that set anything, in apply order.
+func specSections(t target, spec *deviceSpec) []specSection {
+	cam, channel := t.cam, t.channel
+	var sections []specSection
+	if spec.IR != "" {
+		sections = append(sections, specSection{
+			name: "ir",
+			want: map[string]string{"mode": spec.IR},
+			read: func(ctx context.Context) (map[string]string, error) {
+				mode, err := cam.GetIRModeContext(ctx)
+				return map[string]string{"mode": irModeName(mode)}, err
+			},
+			write: func(ctx context.Context, set map[string]string) error {
+				return cam.SetIRModeContext(ctx, irModes[set["mode"]])
+			},
+		})
+	}
+	if spec.DayNight != "" {
+		sections = append(sections, specSection{
+			name: "daynight",
+			want: map[string]string{"mode": strings.ToLower(spec.DayNight)},
+			read: func(ctx context.Context) (map[string]string, error) {
+				f, err := cam.GetIRCutFilter(ctx, channel)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]string{"mode": string(f.Type)}, nil
+			},
+			write: func(ctx context.Context, set map[string]string) error {
+				return dayNight(ctx, cam, channel, set["mode"], "", io.Discard)
+			},
+		})
+	}
+	if len(spec.Image) > 0 {
+		sections = append(sections, shownSection("image", lowerKeys(spec.Image), func(ctx context.Context, args []string, w io.Writer) error {
+			return image(ctx, cam, channel, imageGroups, args, w)
+		}))
+	}
+	if len(spec.Fisheye) > 0 {
+		sections = append(sections, shownSection("fisheye", spec.Fisheye, func(ctx context.Context, args []string, w io.Writer) error {
+			return fisheye(ctx, cam, channel, args, w)
+		}))
+	}
+	if len(spec.OSD) > 0 {
+		want := lowerKeys(spec.OSD)
+		for k, v := range want {
+			want[k] = strings.ReplaceAll(v, "{name}", t.name)
+		}
+		sections = append(sections, shownSection("osd", want, func(ctx context.Context, args []string, w io.Writer) error {
+			return osd(ctx, cam, channel, t.name, args, w)
+		}))
+	}
+	if len(spec.NTP) > 0 {
+		sections = append(sections, specSection{
+			name: "ntp",
+			want: spec.NTP,
+			read: func(ctx context.Context) (map[string]string, error) {
+				return currentNTP(ctx, cam)
+			},
+			write: func(ctx context.Context, _ map[string]string) error {
+				args := []string{spec.NTP["server"]}
+				if v := spec.NTP["interval"]; v != "" {
+					args = append(args, v)
+				}
+				return setNTP(ctx, cam, args, io.Discard)
+			},
+		})
+	}
+	if len(spec.AlarmHost) > 0 {
+		sections = append(sections, shownSection("alarmhost", lowerKeys(spec.AlarmHost), func(ctx context.Context, args []string, w io.Writer) error {
+			return alarmHost(ctx, cam, append([]string{"1"}, args...), w)
+		}))
+	}
+	if len(spec.Email) > 0 {
+		sections = append(sections, shownSection("email", spec.Email, func(ctx context.Context, args []string, w io.Writer) error {
+			return email(ctx, cam, args, w)
+		}))
+	}
+	if len(spec.FTP) > 0 {
+		sections = append(sections, shownSection("ftp", spec.FTP, func(ctx context.Context, args []string, w io.Writer) error {
+			return ftp(ctx, cam, append([]string{"1"}, args...), w)
+		}))
+	}
+	if len(spec.NAS) > 0 {
+		sections = append(sections, shownSection("nas", spec.NAS, func(ctx context.Context, args []string, w io.Writer) error {
+			return storage(ctx, cam, append([]string{"nas", "1"}, args...), w)
+		}))
+	}
+	if len(spec.DDNS) > 0 {
+		sections = append(sections, shownSection("ddns", spec.DDNS, func(ctx context.Context, args []string, w io.Writer) error {
+			return network(ctx, cam, append([]string{"ddns"}, args...), w)
+		}))
+	}
+	if len(spec.SNMP) > 0 {
+		sections = append(sections, shownSection("snmp", spec.SNMP, func(ctx context.Context, args []string, w io.Writer) error {
+			return network(ctx, cam, append([]string{"snmp"}, args...), w)
+		}))
+	}
+	if len(spec.Dot1X) > 0 {
+		sections = append(sections, shownSection("8021x", spec.Dot1X, func(ctx context.Context, args []string, w io.Writer) error {
+			return network(ctx, cam, append([]string{"8021x"}, args...), w)
+		}))
+	}
+	if len(spec.Record) > 0 {
+		sections = append(sections, shownSection("record", spec.Record, func(ctx context.Context, args []string, w io.Writer) error {
+			return record(ctx, cam, channel, args, w)
+		}))
+	}
+	if len(spec.Patrols) > 0 {
+		sections = append(sections, shownSection("patrols", spec.Patrols, func(ctx context.Context, args []string, w io.Writer) error {
+			return patrol(ctx, cam, channel, args, w)
+		}))
+	}
+	if len(spec.Park) > 0 {
+		sections = append(sections, shownSection("park", spec.Park, func(ctx context.Context, args []string, w io.Writer) error {
+			return park(ctx, cam, channel, args, w)
+		}))
+	}
+	for _, name := range sortedKeys(spec.Streams) {
+		name := name
+		sections = append(sections, shownSection("streams."+name, lowerKeys(spec.Streams[name]), func(ctx context.Context, args []string, w io.Writer) error {
+			id, err := streamID(channel, name)
+			if err != nil {
+				return err
+			}
+			return streamConfig(ctx, cam, id, args, w)
+		}))
+	}
+	return sections
+}
+
+// specSettings validates the settings of spec section name by applying them
+// to a zero T and returns them in the form show prints, which is what the
+// camera's current settings are compared in. Secret keys are refused since
+// cameras do not give them back; command is where to set them instead.
+func specSettings[T any](name, command string, want map[string]string, apply func(*T, map[string]string) error, show func(T) map[string]string, secrets ...string) (map[string]string, error) {
+	want = lowerKeys(want)
+	for _, k := range secrets {
+		if _, ok := want[k]; ok {
+			return nil, fmt.Errorf("spec: %s: %s cannot be compared with the camera — set it with the %s command", name, k, command)
+		}
+	}
+	var v T
+	if err := apply(&v, want); err != nil {
+		return nil, fmt.Errorf("spec: %s: %w", name, err)
+	}
+	normal := show(v)
+	for k := range want {
+		want[k] = normal[k]
+	}
+	return want, nil
+}
+
+// snmpPair holds the SNMP v2c and v3 settings the snmp spec section covers.
+type snmpPair struct {
+	v2 hikvision.SNMPv2c
+	v3 hikvision.SNMPv3
+}
+
+// shownSection builds a section on an action that takes key=value settings
+// and, given none, prints the current ones as "key: value" lines.
+func shownSection(name string, want map[string]string, action func(ctx context.Context, args []string, w io.Writer) error) specSection {
+	return specSection{
+		name: name,
+		want: want,
+		read: func(ctx context.Context) (map[string]string, error) {
+			var buf bytes.Buffer
+			if err := action(ctx, nil, &buf); err != nil {
+				return nil, err
+			}
+			have := map[string]string{}
+			sc := bufio.NewScanner(&buf)
+			for sc.Scan() {
+				if k, v, ok := strings.Cut(sc.Text(), ": "); ok {
+					have[k] = v
+				}
+			}
+			return have, nil
+		},
+		write: func(ctx context.Context, set map[string]string) error {
+			args := make([]string, 0, len(set))
+			for k, v := range set {
+				args = append(args, k+"="+v)
+			}
+			return action(ctx, args, io.Discard)
+		},
+	}
+}
+
+// currentNTP returns the NTP server and interval, with no server unless the
+// camera actually keeps time by NTP.
+func currentNTP(ctx context.Context, cam *hikvision.Camera) (map[string]string, error) {
+	t, err := cam.GetTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := cam.GetNTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]string{}
+	if t.Mode == hikvision.TimeModeNTP && len(servers) > 0 {
+		have["server"] = servers[0].Address()
+		have["interval"] = strconv.Itoa(servers[0].Interval)
+	}
+	return have, nil
+}
+
+// sameSetting reports whether two setting values are equal, treating the
+// YAML spellings true/false and the CLI spellings on/off alike.
+func sameSetting(a, b string) bool {
+	norm := func(s string) string {
+		s = strings.ToLower(strings.TrimSpace(s))
+		switch s {
+		case "true":
+			return "on"
+		case "false":
+			return "off"
+		}
+		return s
+	}
+	return norm(a) == norm(b)
+}
+
+func lowerKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
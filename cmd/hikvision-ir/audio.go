@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// audio lists the audio channels, changes one with key=value settings
+// ("audio 1 mic=60 speaker=40"), or opens and closes a two-way audio session
+// ("audio talk open", "audio talk close").
+func audio(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) > 0 && positional[0] == "talk" {
+		if len(positional) != 2 {
+			return fmt.Errorf("audio talk needs open or close")
+		}
+		switch positional[1] {
+		case "open":
+			id, err := cam.OpenTwoWayAudio(ctx, channel)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "two-way audio: open (session %s)\n", id)
+		case "close":
+			if err := cam.CloseTwoWayAudio(ctx, channel); err != nil {
+				return err
+			}
+			fmt.Fprintln(w, "two-way audio: closed")
+		default:
+			return fmt.Errorf("unknown audio talk command %q — must be open or close", positional[1])
+		}
+		return nil
+	}
+
+	channels, err := cam.GetAudioChannels(ctx)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		for _, a := range channels {
+			printAudio(w, a)
+		}
+		return nil
+	}
+
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return fmt.Errorf("audio needs <id> [key=value ...] or talk open|close")
+	}
+	var a *hikvision.AudioChannel
+	for i := range channels {
+		if channels[i].ID == id {
+			a = &channels[i]
+		}
+	}
+	if a == nil {
+		return fmt.Errorf("no audio channel %d", id)
+	}
+	set, err := parseSettings(positional[1:])
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			a.Enabled, err = onOff(k, v)
+		case "mic":
+			a.MicrophoneVolume, err = level(k, v)
+		case "speaker":
+			a.SpeakerVolume, err = level(k, v)
+		case "noise-reduction":
+			a.NoiseReduction, err = onOff(k, v)
+		default:
+			return fmt.Errorf("unknown audio setting %q", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetAudioChannel(ctx, a); err != nil {
+			return err
+		}
+	}
+	printAudio(w, *a)
+	return nil
+}
+
+func printAudio(w io.Writer, a hikvision.AudioChannel) {
+	fmt.Fprintf(w, "audio %d: enabled %s, mic %d, speaker %d, noise-reduction %s\n",
+		a.ID, onOffName(a.Enabled), a.MicrophoneVolume, a.SpeakerVolume, onOffName(a.NoiseReduction))
+}
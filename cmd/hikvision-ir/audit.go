@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// defaultPassword is the factory password of pre-2016 firmwares, which
+// shipped active with admin/12345.
+const defaultPassword = "12345"
+
+// maxFirmwareAge is how old the installed firmware may be before the audit
+// flags it.
+const maxFirmwareAge = 2 * 365 * 24 * time.Hour
+
+// auditCheck is one hardening check. check reports whether the camera passes
+// and a short detail; an ErrNotSupported error skips the check.
+type auditCheck struct {
+	name   string
+	weight int
+	check  func(ctx context.Context, cam *hikvision.Camera) (bool, string, error)
+}
+
+// auditChecks are ordered by weight, most serious first. Their weights add
+// up to 100.
+var auditChecks = []auditCheck{
+	{"default admin password", 40, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		ok, err := cam.AcceptsPassword(ctx, "admin", defaultPassword)
+		if ok {
+			return false, "admin/" + defaultPassword + " logs in", err
+		}
+		return true, "", err
+	}},
+	{"HTTP without HTTPS", 15, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		ports, err := cam.GetServicePorts(ctx)
+		if err != nil {
+			return false, "", err
+		}
+		enabled := map[string]bool{}
+		for _, p := range ports {
+			enabled[p.Protocol] = p.Enabled
+		}
+		if enabled[hikvision.ProtocolHTTP] && !enabled[hikvision.ProtocolHTTPS] {
+			return false, "HTTP enabled, HTTPS disabled", nil
+		}
+		return true, "", nil
+	}},
+	{"telnet", 15, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		on, err := cam.GetTelnet(ctx)
+		return !on, "", err
+	}},
+	{"SSH", 10, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		on, err := cam.GetSSH(ctx)
+		return !on, "", err
+	}},
+	{"illegal login lock", 10, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		l, err := cam.GetIllegalLoginLock(ctx)
+		if err != nil {
+			return false, "", err
+		}
+		if l.Enabled && l.MaxAttempts > 0 {
+			return true, fmt.Sprintf("locks after %d attempts", l.MaxAttempts), nil
+		}
+		return l.Enabled, "", nil
+	}},
+	{"firmware age", 10, func(ctx context.Context, cam *hikvision.Camera) (bool, string, error) {
+		info, err := cam.GetDeviceInfo(ctx)
+		if err != nil {
+			return false, "", err
+		}
+		built, err := info.FirmwareDate()
+		if err != nil {
+			return false, "", err
+		}
+		detail := fmt.Sprintf("%s, built %s", info.FirmwareVersion, built.Format("2006-01-02"))
+		return time.Since(built) < maxFirmwareAge, detail, nil
+	}},
+}
+
+// audit runs the hardening checks and prints a scored report. The score is
+// the weight of the checks passed out of those the camera supports, as a
+// percentage.
+func audit(ctx context.Context, cam *hikvision.Camera, w io.Writer) error {
+	var earned, possible int
+	for _, c := range auditChecks {
+		ok, detail, err := c.check(ctx, cam)
+		status := "PASS"
+		switch {
+		case errors.Is(err, hikvision.ErrNotSupported):
+			status, detail = "SKIP", "not supported"
+		case err != nil:
+			return fmt.Errorf("%s: %w", c.name, err)
+		case !ok:
+			status = "FAIL"
+		}
+		if status != "SKIP" {
+			possible += c.weight
+			if ok {
+				earned += c.weight
+			}
+		}
+		if detail != "" {
+			detail = " (" + detail + ")"
+		}
+		fmt.Fprintf(w, "%s %3d  %s%s\n", status, c.weight, c.name, detail)
+	}
+	if possible == 0 {
+		return fmt.Errorf("camera supports none of the audit checks")
+	}
+	fmt.Fprintf(w, "score: %d/100\n", earned*100/possible)
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// autoLuxConfig is the auto_lux section of a --config file. auto-lux takes a
+// snapshot of each camera every interval and switches it between the day and
+// night phases on the brightness of the image instead of the camera's light
+// sensor:
+//
+//	auto_lux:
+//	  interval: 30s
+//	  stat: median       # mean | median | p10 | p90
+//	  night_below: 30    # by day, switch to night below this luminance (0-255)
+//	  day_above: 120     # by night, switch back to day above it
+//	  samples: 3         # snapshots in a row past a threshold before switching
+//	  day:   {ir: off, daynight: day}
+//	  night: {ir: on, daynight: night}
+//
+// The night image is lit by the IR, so day_above must be well above the
+// brightness of the scene under IR or the camera flips back and forth. With
+// a sensor, the thresholds are in lux and the readings switch every camera.
+type autoLuxConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	Stat       string        `yaml:"stat"`
+	NightBelow float64       `yaml:"night_below"`
+	DayAbove   float64       `yaml:"day_above"`
+	Samples    int           `yaml:"samples"`
+	Day        phaseConfig   `yaml:"day"`
+	Night      phaseConfig   `yaml:"night"`
+	// Sensor replaces the snapshots when set.
+	Sensor luxSensorConfig `yaml:"sensor"`
+}
+
+// validate checks the auto-lux settings and fills in the defaults.
+func (c *autoLuxConfig) validate() error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("auto-lux needs a positive interval")
+	}
+	if c.Samples == 0 {
+		c.Samples = 3
+	}
+	if c.Sensor.set() {
+		if c.NightBelow == 0 {
+			c.NightBelow = 10
+		}
+		if c.DayAbove == 0 {
+			c.DayAbove = max(50, c.NightBelow)
+		}
+		switch {
+		case c.Sensor.MQTTTopic != "" && c.Sensor.URL != "":
+			return fmt.Errorf("auto_lux sensor takes mqtt_topic or url, not both")
+		case c.Stat != "":
+			return fmt.Errorf("auto_lux stat does not apply to a sensor")
+		case c.NightBelow < 0 || c.DayAbove <= c.NightBelow:
+			return fmt.Errorf("auto_lux needs 0 <= night_below < day_above")
+		}
+	} else {
+		if c.Stat == "" {
+			c.Stat = "mean"
+		}
+		if c.NightBelow == 0 {
+			c.NightBelow = 30
+		}
+		if c.DayAbove == 0 {
+			c.DayAbove = max(120, c.NightBelow)
+		}
+		switch {
+		case !slices.Contains(lumaStatNames, c.Stat):
+			return fmt.Errorf("unknown auto_lux stat %q — must be %s", c.Stat, strings.Join(lumaStatNames, ", "))
+		case c.NightBelow < 0 || c.DayAbove > 255 || c.DayAbove <= c.NightBelow:
+			return fmt.Errorf("auto_lux needs 0 <= night_below < day_above <= 255")
+		}
+	}
+	switch {
+	case c.Samples < 1:
+		return fmt.Errorf("auto_lux samples must be at least 1")
+	}
+	return validatePhases("auto_lux", &c.Day, &c.Night)
+}
+
+// runAutoLux switches every target between day and night on the brightness
+// of its image, or all of them on the readings of the sensor, until ctx is
+// cancelled. mc is the broker of an mqtt_topic sensor.
+func runAutoLux(ctx context.Context, c autoLuxConfig, mc mqttConfig, targets []target, parallel int) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.Sensor.set() {
+		readings, err := c.Sensor.readings(ctx, mc, c.Interval)
+		if err != nil {
+			return err
+		}
+		source := c.Sensor.MQTTTopic
+		if source == "" {
+			source = c.Sensor.URL
+		}
+		slog.Info("auto-lux: following sensor", "cameras", len(targets), "sensor", source)
+		l := &autoLux{autoLuxConfig: c, targets: targets, parallel: parallel}
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case lux := <-readings:
+				slog.Debug("auto-lux: sensor reading", "lux", lux)
+				l.sample(ctx, "lux", lux)
+			}
+		}
+	}
+
+	slog.Info("auto-lux: watching", "cameras", len(targets), "interval", c.Interval, "stat", c.Stat)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			l := &autoLux{autoLuxConfig: c, targets: []target{t}, parallel: 1}
+			l.run(ctx)
+		}(t)
+	}
+	wg.Wait()
+	return nil
+}
+
+// autoLux is the state of auto-lux for the cameras switched together: each
+// camera on its own snapshots, or all of them on a sensor.
+type autoLux struct {
+	autoLuxConfig
+	targets  []target
+	parallel int
+
+	// night is the phase last applied, nil until the brightness first
+	// crosses a threshold: cameras started between them are left as
+	// they are.
+	night *bool
+	// past counts the samples in a row past the threshold into the
+	// night phase if toward is set, else the day phase.
+	past   int
+	toward bool
+}
+
+// run samples the snapshots of the one target every interval.
+func (l *autoLux) run(ctx context.Context) {
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+	for {
+		l.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check takes a snapshot of the target and samples its brightness.
+func (l *autoLux) check(ctx context.Context) {
+	t := l.targets[0]
+	data, err := grab(ctx, t.cam, t.channel)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("auto-lux: snapshot", "camera", t.name, "err", err)
+		}
+		return
+	}
+	stats, err := snapshotLuma(data)
+	if err != nil {
+		slog.Warn("auto-lux: snapshot", "camera", t.name, "err", err)
+		return
+	}
+	slog.Debug("auto-lux: sample", "camera", t.name, "mean", int(stats.mean), "median", int(stats.median), "p10", int(stats.p10), "p90", int(stats.p90))
+	l.sample(ctx, l.Stat, stats.get(l.Stat))
+}
+
+// sample takes one brightness sample, named stat in the log, and switches
+// the phase once enough samples in a row call for it.
+func (l *autoLux) sample(ctx context.Context, stat string, value float64) {
+	var night bool
+	switch {
+	case value < l.NightBelow:
+		night = true
+	case value > l.DayAbove:
+		night = false
+	default:
+		l.past = 0
+		return
+	}
+	if l.night != nil && *l.night == night {
+		l.past = 0
+		return
+	}
+	if l.toward != night {
+		l.past, l.toward = 0, night
+	}
+	if l.past++; l.past < l.Samples {
+		return
+	}
+
+	p, phase := l.Day, "day"
+	if night {
+		p, phase = l.Night, "night"
+	}
+	if len(l.targets) == 1 {
+		slog.Info("auto-lux: phase", "camera", l.targets[0].name, "phase", phase, stat, value)
+	} else {
+		slog.Info("auto-lux: phase", "cameras", len(l.targets), "phase", phase, stat, value)
+	}
+	l.past = 0
+	// A phase that failed to apply is tried again on the next samples.
+	if len(applyPhase(ctx, "auto-lux", p, l.targets, l.parallel)) == 0 {
+		l.night = &night
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// configBackup runs "config export", which saves the camera's encrypted
+// configuration to <camera>-<timestamp>.bin in the --out directory, and
+// "config import --file <backup>", which restores one.
+func configBackup(ctx context.Context, cam *hikvision.Camera, name string, positional []string, out, file string, w io.Writer) error {
+	if len(positional) != 1 {
+		return fmt.Errorf("config needs export or import")
+	}
+	switch positional[0] {
+	case "export":
+		data, err := cam.ExportConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if out == "-" {
+			out = "."
+		}
+		path := filepath.Join(out, backupName(name, time.Now()))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("write backup: %w", err)
+		}
+		fmt.Fprintf(w, "config: saved %s (%d KiB)\n", path, len(data)/1024)
+		return nil
+	case "import":
+		if file == "" {
+			return fmt.Errorf("config import needs --file <backup>")
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read backup: %w", err)
+		}
+		if err := cam.ImportConfig(ctx, data); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "config: restored %s, rebooting\n", file)
+		return nil
+	}
+	return fmt.Errorf("unknown config command %q — must be export or import", positional[0])
+}
+
+// backupName is the file name of a configuration backup of the named camera.
+func backupName(name string, t time.Time) string {
+	return fmt.Sprintf("%s-%s.bin", fileName(name), t.Format("20060102-150405"))
+}
+
+// fileName turns a camera name into a file name. Characters that are awkward
+// in file names, such as the colon of host:port, become dashes.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '-'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// snapshotStore is the snapshots section of the config file, where rules
+// save the snapshots they take:
+//
+//	snapshots:
+//	  dir: /var/lib/hikvision-ir/snapshots
+//	  layout: "{camera}/{date}/{time}.jpg"
+//
+// The layout may use {camera}, {channel}, {event}, {date} (2006-01-02) and
+// {time} (15-04-05.000).
+type snapshotStore struct {
+	Dir    string `yaml:"dir"`
+	Layout string `yaml:"layout"`
+}
+
+// defaultSnapshotLayout files snapshots by camera and day.
+const defaultSnapshotLayout = "{camera}/{date}/{time}.jpg"
+
+// validate fills in the defaults: a snapshots directory under the current
+// one and defaultSnapshotLayout.
+func (s *snapshotStore) validate() error {
+	if s.Dir == "" {
+		s.Dir = "snapshots"
+	}
+	if s.Layout == "" {
+		s.Layout = defaultSnapshotLayout
+	}
+	if filepath.IsAbs(s.Layout) || strings.HasPrefix(filepath.Clean(s.Layout), "..") {
+		return fmt.Errorf("snapshots: layout %q must stay inside dir", s.Layout)
+	}
+	if !strings.Contains(s.Layout, "{time}") {
+		return fmt.Errorf("snapshots: layout %q needs {time}, or snapshots overwrite each other", s.Layout)
+	}
+	return nil
+}
+
+// path returns where the snapshot of channel taken at at for event is saved.
+func (s *snapshotStore) path(camera string, channel int, event hikvision.EventType, at time.Time) string {
+	name := strings.NewReplacer(
+		"{camera}", fileSafe(camera),
+		"{channel}", strconv.Itoa(channel),
+		"{event}", fileSafe(string(event)),
+		"{date}", at.Format("2006-01-02"),
+		"{time}", at.Format("15-04-05.000"),
+	).Replace(s.Layout)
+	return filepath.Join(s.Dir, filepath.FromSlash(name))
+}
+
+// save writes a snapshot and returns its path.
+func (s *snapshotStore) save(camera string, channel int, event hikvision.EventType, f frame) (string, error) {
+	path := s.path(camera, channel, event, f.at)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, f.data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// captureConfig is the snapshot setting of a rule:
+//
+//	snapshot:
+//	  before: 2   # also keep the 2 frames taken before the event
+//	  after: 3    # and take 3 more after it
+//	  every: 1s   # apart
+//
+// "snapshot: {}" takes a single snapshot when the rule fires.
+type captureConfig struct {
+	Before int           `yaml:"before"`
+	After  int           `yaml:"after"`
+	Every  time.Duration `yaml:"every"`
+}
+
+// capture is a validated captureConfig.
+type capture struct {
+	before, after int
+	every         time.Duration
+}
+
+// compile validates a capture and fills in the default interval of a second.
+func (cc captureConfig) compile() (*capture, error) {
+	if cc.Before < 0 || cc.After < 0 {
+		return nil, fmt.Errorf("snapshot: before and after must not be negative")
+	}
+	c := &capture{before: cc.Before, after: cc.After, every: cc.Every}
+	if c.every == 0 {
+		c.every = time.Second
+	}
+	if c.every < 0 {
+		return nil, fmt.Errorf("snapshot: invalid interval %v", cc.Every)
+	}
+	return c, nil
+}
+
+// frame is one snapshot and when it was taken.
+type frame struct {
+	at   time.Time
+	data []byte
+}
+
+// grab takes a snapshot of the main stream of channel.
+func grab(ctx context.Context, cam hikvision.Controller, channel int) ([]byte, error) {
+	r, err := cam.Snapshot(ctx, hikvision.StreamingChannelID(channel, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// frameBuffer keeps the last snapshots of a camera, so that a burst can
+// start before the event that set it off.
+type frameBuffer struct {
+	size int
+
+	mu     sync.Mutex
+	frames []frame
+}
+
+// run takes a snapshot of channel every interval until ctx is cancelled.
+func (b *frameBuffer) run(ctx context.Context, t target, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		data, err := grab(ctx, t.cam, t.channel)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Debug("rules: buffered snapshot failed", "camera", t.name, "err", err)
+		} else {
+			b.mu.Lock()
+			b.frames = append(b.frames, frame{at: time.Now(), data: data})
+			if len(b.frames) > b.size {
+				b.frames = b.frames[len(b.frames)-b.size:]
+			}
+			b.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// last returns up to the n most recent frames, oldest first.
+func (b *frameBuffer) last(n int) []frame {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n = min(n, len(b.frames))
+	return append([]frame(nil), b.frames[len(b.frames)-n:]...)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// cert shows the HTTPS certificate of the camera, has it generate a signing
+// request ("cert request key=value ..."), installs the signed certificate
+// ("cert install --file <cert.pem>") or switches HTTPS-only mode ("cert
+// https-only [on|off]"). name is the camera name, used for the request and
+// certificate file names.
+func cert(ctx context.Context, cam *hikvision.Camera, name string, positional []string, out, file string, w io.Writer) error {
+	if len(positional) == 0 {
+		info, err := cam.GetCertificate(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "subject: %s\n", dash(info.Subject))
+		fmt.Fprintf(w, "issuer: %s\n", dash(info.Issuer))
+		fmt.Fprintf(w, "valid: %s to %s\n", dash(info.ValidFrom), dash(info.ValidTo))
+		return nil
+	}
+	switch positional[0] {
+	case "request":
+		return certRequest(ctx, cam, name, positional[1:], out, w)
+	case "install":
+		if len(positional) > 1 {
+			return fmt.Errorf("cert install takes the certificate as --file")
+		}
+		return certInstall(ctx, cam, name, file, w)
+	case "https-only":
+		return httpsOnly(ctx, cam, positional[1:], w)
+	}
+	return fmt.Errorf("unknown cert command %q — must be request, install or https-only", positional[0])
+}
+
+// certRequest creates a signing request from the settings cn (the camera's
+// host by default), country, state, city, org, unit and email, and saves it
+// as <name>.csr in directory out.
+func certRequest(ctx context.Context, cam *hikvision.Camera, name string, args []string, out string, w io.Writer) error {
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(cam.Host)
+	if err != nil {
+		host = cam.Host
+	}
+	r := &hikvision.CertificateRequest{CommonName: host}
+	for k, v := range set {
+		switch k {
+		case "cn":
+			r.CommonName = v
+		case "country":
+			if len(v) != 2 {
+				return fmt.Errorf("country=%s: want a two-letter country code", v)
+			}
+			r.Country = v
+		case "state":
+			r.State = v
+		case "city":
+			r.Locality = v
+		case "org":
+			r.Organization = v
+		case "unit":
+			r.Unit = v
+		case "email":
+			r.Email = v
+		default:
+			return fmt.Errorf("unknown cert request setting %q — must be cn, country, state, city, org, unit or email", k)
+		}
+	}
+	if r.Country == "" {
+		return fmt.Errorf("cert request needs country=<code>")
+	}
+	if err := cam.CreateCertificateRequest(ctx, r); err != nil {
+		return err
+	}
+	if cam.DryRun != nil {
+		return nil
+	}
+	csr, err := cam.GetCertificateRequest(ctx)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		out = "."
+	}
+	path := filepath.Join(out, fileName(name)+".csr")
+	if err := os.WriteFile(path, csr, 0o644); err != nil {
+		return fmt.Errorf("write certificate request: %w", err)
+	}
+	fmt.Fprintf(w, "cert: saved request for %s as %s\n", r.CommonName, path)
+	return nil
+}
+
+// certInstall installs the PEM certificate in file, or with a directory, in
+// <name>.crt there, so signed certificates for a fleet can be installed in
+// one go.
+func certInstall(ctx context.Context, cam *hikvision.Camera, name, file string, w io.Writer) error {
+	if file == "" {
+		return fmt.Errorf("cert install needs --file <cert.pem>")
+	}
+	if fi, err := os.Stat(file); err == nil && fi.IsDir() {
+		file = filepath.Join(file, fileName(name)+".crt")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read certificate: %w", err)
+	}
+	if b, _ := pem.Decode(data); b == nil || b.Type != "CERTIFICATE" {
+		return fmt.Errorf("%s: not a PEM certificate", file)
+	}
+	if err := cam.InstallCertificate(ctx, data); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "cert: installed %s\n", file)
+	return nil
+}
+
+// httpsOnly shows whether the camera serves only HTTPS, or with on turns
+// HTTP off and HTTPS on, and with off turns HTTP back on.
+func httpsOnly(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("cert https-only takes on or off")
+	}
+	ports, err := cam.GetServicePorts(ctx)
+	if err != nil {
+		return err
+	}
+	var plain, tls *hikvision.ServicePort
+	for i := range ports {
+		switch ports[i].Protocol {
+		case hikvision.ProtocolHTTP:
+			plain = &ports[i]
+		case hikvision.ProtocolHTTPS:
+			tls = &ports[i]
+		}
+	}
+	if plain == nil || tls == nil {
+		return fmt.Errorf("camera does not list both HTTP and HTTPS services")
+	}
+	if len(args) == 1 {
+		on, err := onOff("https-only", args[0])
+		if err != nil {
+			return err
+		}
+		plain.Enabled = !on
+		tls.Enabled = tls.Enabled || on
+		if err := cam.SetServicePorts(ctx, ports); err != nil {
+			return err
+		}
+		if on && cam.Scheme != "https" {
+			fmt.Fprintf(w, "cert: HTTP is off — reach the camera with --scheme https from now on\n")
+		}
+	}
+	fmt.Fprintf(w, "https-only: %s\n", onOffName(tls.Enabled && !plain.Enabled))
+	return nil
+}
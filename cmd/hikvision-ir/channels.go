@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// channels lists the video channels of the device with their streams: the
+// lenses of a camera, or with --nvr the cameras attached to the NVR. The
+// channel numbers are those --channel takes.
+func channels(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	if len(positional) > 1 || len(positional) == 1 && positional[0] != "list" {
+		return fmt.Errorf("channels takes no arguments other than list")
+	}
+	configs, err := cam.GetStreamConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	streams := make(map[int][]string)
+	for _, s := range configs {
+		desc := fmt.Sprintf("%d %s %dx%d", s.ID, s.Video.Codec, s.Video.Width, s.Video.Height)
+		if !s.Enabled {
+			desc += " (off)"
+		}
+		streams[s.ID/100] = append(streams[s.ID/100], desc)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if cam.NVR {
+		proxies, err := cam.GetProxyChannels(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(tw, "CHANNEL\tNAME\tCAMERA\tSTATUS\tSTREAMS")
+		for _, p := range proxies {
+			status := "offline"
+			if p.Online {
+				status = "online"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", p.ID, dash(p.Name), dash(p.Address), status, dash(strings.Join(streams[p.ID], ", ")))
+		}
+		return tw.Flush()
+	}
+
+	inputs, err := videoInputs(ctx, cam)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(tw, "CHANNEL\tNAME\tSTREAMS")
+	for _, in := range inputs {
+		name := dash(in.Name)
+		if in.Enabled != nil && !*in.Enabled {
+			name += " (off)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", in.ID, name, dash(strings.Join(streams[in.ID], ", ")))
+	}
+	return tw.Flush()
+}
+
+// videoInputs returns the video input channels of cam. Without the inputs
+// endpoint, the streaming channels tell which there are.
+func videoInputs(ctx context.Context, cam *hikvision.Camera) ([]hikvision.VideoInput, error) {
+	inputs, err := cam.GetVideoInputs(ctx)
+	if !errors.Is(err, hikvision.ErrNotSupported) {
+		return inputs, err
+	}
+	configs, err := cam.GetStreamConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	for _, s := range configs {
+		if ch := s.ID / 100; !seen[ch] {
+			seen[ch] = true
+			inputs = append(inputs, hikvision.VideoInput{ID: ch})
+		}
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].ID < inputs[j].ID })
+	return inputs, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// chatConfig is one entry of the notifiers section of a --config file, a chat
+// that cameras naming it in their notify list post messages to:
+//
+//	notifiers:
+//	  family:
+//	    type: telegram
+//	    token: 123456:ABC-DEF
+//	    chat_id: "-1001234567890"
+//	    on: [event, offline]
+//	    events: [VMD, tamperdetection]
+//	    snapshot: true
+//	  ops:
+//	    type: slack
+//	    url: https://hooks.slack.com/services/T000/B000/XXXX
+//	cameras:
+//	  garage:
+//	    host: 192.168.1.5
+//	    notify: [family, ops]
+type chatConfig struct {
+	// Type is telegram, slack or discord.
+	Type string `yaml:"type"`
+	// URL is the incoming webhook of slack and discord, and the Bot API
+	// server of telegram, by default https://api.telegram.org.
+	URL string `yaml:"url"`
+	// Token and ChatID are the bot token and the chat of telegram.
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chat_id"`
+	// On lists the notification kinds sent: ir, offline, online and
+	// event. Empty means all of them.
+	On []string `yaml:"on"`
+	// Events lists the event types sent, such as VMD; empty means all.
+	Events []string `yaml:"events"`
+	// Snapshot attaches a picture to event messages. Slack webhooks
+	// cannot take one.
+	Snapshot bool `yaml:"snapshot"`
+	// Retries is how often a failed delivery is retried; defaults to 3.
+	Retries *int `yaml:"retries"`
+}
+
+// chatSink validates c and returns its sink for cameras.
+func chatSink(name string, c chatConfig, cameras map[string]bool) (*sink, error) {
+	if err := checkKinds(c.On); err != nil {
+		return nil, err
+	}
+	s := &sink{name: name, on: c.On, events: c.Events, cameras: cameras, snapshot: c.Snapshot, retries: retries(c.Retries)}
+	switch c.Type {
+	case "telegram":
+		if c.Token == "" || c.ChatID == "" {
+			return nil, fmt.Errorf("telegram needs token and chat_id")
+		}
+		api := c.URL
+		if api == "" {
+			api = "https://api.telegram.org"
+		}
+		s.send = func(ctx context.Context, note notification, picture []byte) error {
+			return sendTelegram(ctx, strings.TrimSuffix(api, "/")+"/bot"+c.Token, c.ChatID, note.message(), picture)
+		}
+	case "slack", "discord":
+		if u, err := url.Parse(c.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("%s needs the https url of an incoming webhook", c.Type)
+		}
+		if c.Type == "slack" {
+			if c.Snapshot {
+				return nil, fmt.Errorf("slack webhooks cannot attach a snapshot")
+			}
+			s.send = func(ctx context.Context, note notification, _ []byte) error {
+				return postJSON(ctx, c.URL, map[string]string{"text": note.message()})
+			}
+		} else {
+			s.send = func(ctx context.Context, note notification, picture []byte) error {
+				return sendDiscord(ctx, c.URL, note.message(), picture)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q — must be telegram, slack, or discord", c.Type)
+	}
+	return s, nil
+}
+
+// sendTelegram posts text to a Telegram chat through the Bot API at bot, as
+// the caption of picture if there is one.
+func sendTelegram(ctx context.Context, bot, chatID, text string, picture []byte) error {
+	if picture == nil {
+		return postJSON(ctx, bot+"/sendMessage", map[string]string{"chat_id": chatID, "text": text})
+	}
+	return postMultipart(ctx, bot+"/sendPhoto", map[string]string{"chat_id": chatID, "caption": text}, "photo", picture)
+}
+
+// sendDiscord posts text to a Discord webhook, with picture attached if there
+// is one.
+func sendDiscord(ctx context.Context, webhook, text string, picture []byte) error {
+	if picture == nil {
+		return postJSON(ctx, webhook, map[string]string{"content": text})
+	}
+	payload, _ := json.Marshal(map[string]string{"content": text})
+	return postMultipart(ctx, webhook, map[string]string{"payload_json": string(payload)}, "files[0]", picture)
+}
+
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return postNotification(req)
+}
+
+// postMultipart posts fields as a form with picture as the JPEG file field
+// file.
+func postMultipart(ctx context.Context, url string, fields map[string]string, file string, picture []byte) error {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, k := range sortedKeys(fields) {
+		mw.WriteField(k, fields[k])
+	}
+	fw, err := mw.CreateFormFile(file, "snapshot.jpg")
+	if err != nil {
+		return err
+	}
+	fw.Write(picture)
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return postNotification(req)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// clock runs the time action: with no arguments it prints the camera's clock
+// and how far it is from the local host; "time sync" sets it from the local
+// host; "time ntp <server> [minutes]" switches it to NTP.
+func clock(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	if len(positional) == 0 {
+		return printClock(ctx, cam, w)
+	}
+	switch positional[0] {
+	case "sync":
+		if err := cam.SyncTime(ctx, time.Now()); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "time: set from local clock (manual mode)")
+		return nil
+	case "ntp":
+		if len(positional) < 2 || len(positional) > 3 {
+			return fmt.Errorf("time ntp needs <server> [interval minutes]")
+		}
+		return setNTP(ctx, cam, positional[1:], w)
+	}
+	return fmt.Errorf("unknown time command %q — must be sync or ntp", positional[0])
+}
+
+func printClock(ctx context.Context, cam *hikvision.Camera, w io.Writer) error {
+	start := time.Now()
+	t, err := cam.GetTime(ctx)
+	if err != nil {
+		return err
+	}
+	// Compare against the middle of the round trip.
+	host := start.Add(time.Since(start) / 2)
+
+	fmt.Fprintf(w, "mode: %s\n", t.Mode)
+	fmt.Fprintf(w, "camera time: %s\n", t.LocalTime)
+	if ts, err := t.Time(); err == nil && ts.Location() != time.UTC {
+		fmt.Fprintf(w, "drift: %+.0fs\n", ts.Sub(host).Seconds())
+	}
+	if t.TimeZone != "" {
+		fmt.Fprintf(w, "time zone: %s\n", t.TimeZone)
+	}
+	if servers, err := cam.GetNTP(ctx); err == nil {
+		for _, s := range servers {
+			if s.Address() != "" {
+				fmt.Fprintf(w, "ntp server: %s (every %dm)\n", s.Address(), s.Interval)
+			}
+		}
+	}
+	return nil
+}
+
+func setNTP(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	servers, err := cam.GetNTP(ctx)
+	if err != nil {
+		return err
+	}
+	s := &hikvision.NTPServer{ID: 1, Port: 123}
+	if len(servers) > 0 {
+		s = &servers[0]
+	}
+	if net.ParseIP(args[0]) != nil {
+		s.AddressingFormat, s.IPAddress = "ipaddress", args[0]
+	} else {
+		s.AddressingFormat, s.HostName = "hostname", args[0]
+	}
+	if len(args) == 2 {
+		if s.Interval, err = strconv.Atoi(args[1]); err != nil || s.Interval <= 0 {
+			return fmt.Errorf("invalid NTP interval %q: want minutes", args[1])
+		}
+	}
+	if err := cam.SetNTP(ctx, s); err != nil {
+		return err
+	}
+
+	t, err := cam.GetTime(ctx)
+	if err != nil {
+		return err
+	}
+	t.Mode = hikvision.TimeModeNTP
+	if err := cam.SetTime(ctx, t); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "time: NTP from %s\n", s.Address())
+	return nil
+}
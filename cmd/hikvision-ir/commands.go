@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// command describes a subcommand for help and flag checking.
+type command struct {
+	name string
+	// args is the synopsis of the arguments after the name.
+	args    string
+	summary string
+	// flags are the command's own flags; camera flags apply to every
+	// command.
+	flags []string
+	// settings marks commands that take key=value arguments, optionally
+	// after "set".
+	settings bool
+	// modes, if set, are the first arguments that stand for --mode.
+	modes []string
+}
+
+// commands lists the subcommands in the order help prints them.
+var commands = []command{
+	{name: "ir", args: "on|off|auto|status [--all-lenses]", summary: "Switch the IR illuminator or show its mode", flags: []string{"brightness", "all-lenses", "watch", "interval", "daynight"}},
+	{name: "status", args: "[--all-lenses | --watch [--interval 5s] [--daynight]]", summary: "Show the IR mode and brightness (same as ir status), or print its changes", flags: []string{"all-lenses", "watch", "interval", "daynight"}},
+	{name: "channels", args: "[list]", summary: "List the video channels (lenses, or cameras behind an NVR) and their streams"},
+	{name: "daynight", args: "[day|night|auto|schedule]", summary: "Show or set the day/night (IR-cut filter) mode", flags: []string{"mode", "schedule"}, modes: []string{"day", "night", "auto", "schedule"}},
+	{name: "light", args: "[ir|white|mixed|off]", summary: "Show or set the supplement light", flags: []string{"mode", "brightness"}, modes: []string{"ir", "white", "mixed", "off"}},
+	{name: "image", args: "[set key=value ...]", summary: "Show or change image settings", settings: true},
+	{name: "wdr", args: "[set key=value ...]", summary: "Show or change wide dynamic range", settings: true},
+	{name: "blc", args: "[set key=value ...]", summary: "Show or change backlight and highlight compensation", settings: true},
+	{name: "orientation", args: "[set key=value ...]", summary: "Show or change flip and corridor mode", settings: true},
+	{name: "osd", args: "[set key=value ...]", summary: "Show or change the on-screen display", settings: true},
+	{name: "stream", args: "config|url [key=value ...]", summary: "Show or change stream encoding, or print the RTSP URL", flags: []string{"stream", "rtsp-port"}},
+	{name: "probe", summary: "Check that the RTSP stream plays", flags: []string{"stream", "rtsp-port"}},
+	{name: "smart", args: "export|push line|field [file.yaml]", summary: "Export or push smart event rules"},
+	{name: "fisheye", args: "[set key=value ...]", summary: "Show or change the fisheye mounting and display mode", settings: true},
+	{name: "tamper", args: "[set key=value ...]", summary: "Show or change tamper detection", settings: true},
+	{name: "output", args: "[<id> on|off|pulse <duration>]", summary: "Show or drive alarm outputs"},
+	{name: "input", args: "[<id> key=value ...]", summary: "Show or change alarm inputs"},
+	{name: "audio", args: "[<id> key=value ... | talk open|close]", summary: "Show or change audio channels"},
+	{name: "time", args: "[sync | ntp <server> [minutes]]", summary: "Show the clock, sync it or set NTP"},
+	{name: "network", args: "[set [<id>] key=value ... | ports | upnp | ipfilter ... | ddns|snmp|8021x [key=value ...]]", summary: "Show or change network settings"},
+	{name: "wifi", args: "[scan | join <ssid> [<key>] | off]", summary: "Show, scan or join Wi-Fi networks"},
+	{name: "alarmhost", args: "[[<id>] key=value ... | test [<id>]]", summary: "Show or set where the camera pushes alarm notifications"},
+	{name: "email", args: "[set key=value ...] | test", summary: "Show or change alarm email settings, or send a test email", settings: true},
+	{name: "ftp", args: "[[<id>] key=value ... | test [<id>]]", summary: "Show or set the FTP servers the camera uploads pictures to"},
+	{name: "user", args: "[add|set <name> key=value ... | delete <name>]", summary: "List or manage camera accounts"},
+	{name: "passwd", args: "[<user>]", summary: "Change a camera password, read from the terminal", flags: []string{"new-pass-env", "new-pass-file", "update-config"}},
+	{name: "logs", args: "[alarm|exception|operation|information|<minor type> ...] [--from <time>] [--to <time>] [--json]", summary: "Search the camera log", flags: []string{"from", "to", "json"}},
+	{name: "health", args: "[--json]", summary: "Check reachability, login, clock, storage and stream; exit 0 OK, 1 WARN, 2 CRIT", flags: []string{"json", "rtsp-port"}},
+	{name: "audit", summary: "Check the camera for weak security settings"},
+	{name: "security", args: "[set key=value ...]", summary: "Show or change security settings", settings: true},
+	{name: "cert", args: "[request key=value ... [--out <dir>] | install --file <cert.pem> | https-only [on|off]]", summary: "Show the HTTPS certificate, request and install one, or serve HTTPS only", flags: []string{"out", "file"}},
+	{name: "firmware", args: "[status | upgrade --file <image.dav>]", summary: "Show the firmware or upgrade it", flags: []string{"file", "yes"}},
+	{name: "config", args: "export [--out <dir>] | import --file <backup.bin>", summary: "Back up or restore the camera configuration", flags: []string{"out", "file", "yes"}},
+	{name: "apply", args: "<spec.yaml>", summary: "Bring cameras to a desired state", flags: []string{"plan"}},
+	{name: "diff", args: "[save --out <file> | --file <file>] [resource ...]", summary: "Compare settings across cameras or with a saved file", flags: []string{"out", "file"}},
+	{name: "discover", args: "[sadp|ws]", summary: "Find cameras on the local network", flags: []string{"wait", "update-config"}},
+	{name: "login", args: "[<camera>]", summary: "Store a camera password in the OS keyring"},
+	{name: "snapshot", summary: "Save a JPEG snapshot", flags: []string{"out"}},
+	{name: "ptz", args: "[status | move key=value ... | zoom in|out | stop | goto key=value ... | relative key=value ... | preset [set|delete|goto <id>] | patrol [<id>=<steps> | start|stop <id>] | park [key=value ...] | aux | wiper|light|heater|fan on|off | tracking [on|off]]", summary: "Show or steer the pan, tilt and zoom of a PTZ camera, its presets, patrols, park action, auxiliary devices and auto-tracking"},
+	{name: "thermal", args: "[--json] | rules [<id> key=value ...] | alarms [<id> key=value ...]", summary: "Show temperatures, measurement rules and temperature alarms of a thermal camera", flags: []string{"json"}},
+	{name: "anpr", args: "plates [allow|block] | plates add allow|block <plate> ... | plates remove <plate> ...", summary: "List or change the plate allow and block lists of an ANPR camera"},
+	{name: "face", args: "[set detection=on|off] | libraries [add <name> [<info>] | delete <id>]", summary: "Show or change face detection and the face picture libraries", settings: true},
+	{name: "counting", args: "[hour|day|month] [--from <time>] [--to <time>] [--json|--csv]", summary: "Show how many people were counted in and out", flags: []string{"from", "to", "json", "csv"}},
+	{name: "heatmap", args: "[hour|day|month] [--from <time>] [--to <time>] [--json|--csv]", summary: "Show heat map activity", flags: []string{"from", "to", "json", "csv"}},
+	{name: "record", args: "[set key=value ...]", summary: "Show or change the recording schedule", settings: true},
+	{name: "storage", args: "[format [<id>] | nas [test] [<id>] [key=value ...]]", summary: "Show SD card and disk status, format one, or set up network storage", flags: []string{"yes"}},
+	{name: "recordings", args: "[search] [--from <time>] [--to <time>] | download [<playback-uri> ...] [--out <dir>]", summary: "List recordings or download them", flags: []string{"from", "to", "out"}},
+	{name: "info", summary: "Show the model, serial number and firmware"},
+	{name: "raw", args: "<METHOD> <path>", summary: "Send an ISAPI request", flags: []string{"body"}},
+	{name: "reboot", summary: "Reboot the camera", flags: []string{"yes"}},
+	{name: "factory-reset", args: "[basic|full]", summary: "Restore factory settings", flags: []string{"mode", "yes"}, modes: []string{"basic", "full"}},
+	{name: "serve", summary: "Serve an HTTP API and Prometheus metrics", flags: []string{"listen", "interval", "webhook"}},
+	{name: "mqtt", summary: "Bridge cameras to an MQTT broker", flags: []string{"mqtt-broker", "mqtt-user", "mqtt-pass", "interval", "ha-discovery", "webhook"}},
+	{name: "events", summary: "Print camera events as they happen", flags: []string{"webhook"}},
+	{name: "listen", summary: "Receive events cameras push to an alarm host, print them and publish them to MQTT", flags: []string{"listen", "mqtt-broker", "mqtt-user", "mqtt-pass", "webhook"}},
+	{name: "rules", summary: "Run the event-driven IR and snapshot rules of --config", flags: []string{"webhook"}},
+	{name: "schedule", summary: "Switch day/night at sunrise and sunset", flags: []string{"lat", "lon"}},
+	{name: "auto-lux", summary: "Switch day/night on the brightness of snapshots or an external lux sensor", flags: []string{"interval", "mqtt-broker", "mqtt-user", "mqtt-pass"}},
+	{name: "watchdog", summary: "Recover cameras stuck with IR off in auto mode on a dark image", flags: []string{"interval", "webhook"}},
+}
+
+// irCommands are the arguments of ir, which run as actions of their own.
+var irCommands = map[string]bool{"on": true, "off": true, "auto": true, "status": true}
+
+// commandFlags are the flags that belong to particular commands. Every other
+// flag selects or reaches the cameras.
+var commandFlags = map[string]bool{}
+
+func init() {
+	for _, c := range commands {
+		for _, f := range c.flags {
+			commandFlags[f] = true
+		}
+	}
+}
+
+// lookupCommand returns the command called name.
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// resolveCommand turns a subcommand invocation into the action run performs:
+// "ir on" becomes on, "image set k=v" becomes image with k=v and "daynight
+// night" sets --mode. set lists the command flags given on the command line,
+// which must belong to the command.
+func resolveCommand(a *actionArgs, set []string) error {
+	if irCommands[a.action] {
+		// "hikvision-ir on" from before ir grouped them.
+		a.positional = append([]string{a.action}, a.positional...)
+		a.action = "ir"
+	}
+	c, ok := lookupCommand(a.action)
+	if !ok {
+		return fmt.Errorf("unknown command %q — run hikvision-ir help for the list", a.action)
+	}
+	for _, name := range set {
+		if commandFlags[name] && !slices.Contains(c.flags, name) {
+			return fmt.Errorf("--%s does not apply to %s — run hikvision-ir help %s", name, c.name, c.name)
+		}
+	}
+
+	switch {
+	case c.name == "ir":
+		a.action = "status"
+		if len(a.positional) > 0 {
+			if !irCommands[a.positional[0]] {
+				return fmt.Errorf("unknown ir command %q — must be on, off, auto, or status", a.positional[0])
+			}
+			a.action, a.positional = a.positional[0], a.positional[1:]
+		}
+		if len(a.positional) > 0 {
+			return fmt.Errorf("ir %s takes no arguments", a.action)
+		}
+	case c.settings:
+		if len(a.positional) > 0 && a.positional[0] == "set" {
+			a.positional = a.positional[1:]
+		}
+	case c.modes != nil:
+		if len(a.positional) > 0 && slices.Contains(c.modes, a.positional[0]) {
+			if a.mode != "" {
+				return fmt.Errorf("%s: give the mode as an argument or with --mode, not both", c.name)
+			}
+			a.mode, a.positional = a.positional[0], a.positional[1:]
+		}
+		if len(a.positional) > 0 {
+			return fmt.Errorf("unknown %s mode %q — must be %s", c.name, a.positional[0], strings.Join(c.modes, ", "))
+		}
+	}
+	return nil
+}
+
+// printUsage writes the general help: the commands and the camera flags.
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprint(w, "Usage: hikvision-ir [camera flags] <command> [arguments] [flags]\n\n")
+	fmt.Fprint(w, "Camera flags: --host <address> [--user <user>] [--pass <pass>] | --config <file> --camera <name>[,<name>...]|--all\n\n")
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprint(w, "\nRun \"hikvision-ir help <command>\" for the arguments and flags of a command.\n\nCamera flags:\n")
+	printFlags(w, fs, func(name string) bool { return !commandFlags[name] && name != "action" })
+}
+
+// printCommandUsage writes the help of one command.
+func printCommandUsage(w io.Writer, fs *flag.FlagSet, c command) {
+	fmt.Fprintf(w, "Usage: hikvision-ir [camera flags] %s", c.name)
+	if c.args != "" {
+		fmt.Fprintf(w, " %s", c.args)
+	}
+	if len(c.flags) > 0 {
+		fmt.Fprint(w, " [flags]")
+	}
+	fmt.Fprintf(w, "\n\n%s.\n", c.summary)
+	if len(c.flags) > 0 {
+		fmt.Fprint(w, "\nFlags:\n")
+		printFlags(w, fs, func(name string) bool { return slices.Contains(c.flags, name) })
+	}
+	fmt.Fprint(w, "\nRun \"hikvision-ir help\" for the camera flags.\n")
+}
+
+// printFlags writes the defaults of the flags of fs that keep selects.
+func printFlags(w io.Writer, fs *flag.FlagSet, keep func(name string) bool) {
+	sub := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	sub.SetOutput(w)
+	fs.VisitAll(func(f *flag.Flag) {
+		if keep(f.Name) {
+			sub.Var(f.Value, f.Name, f.Usage)
+			sub.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	sub.PrintDefaults()
+}
+
+// commandArg returns the first argument that is neither a flag nor a flag's
+// value, which names the command, or "".
+func commandArg(fs *flag.FlagSet, args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		}
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := fs.Lookup(name); f != nil {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				continue
+			}
+			i++
+		}
+	}
+	return ""
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// cameraConfig describes how to reach one camera. The same struct holds the
+// --host/--user/... flags and each entry of a --config file.
+type cameraConfig struct {
+	Host string `yaml:"host"`
+	// Port overrides any port given in Host.
+	Port int    `yaml:"port"`
+	User string `yaml:"user"`
+	Pass string `yaml:"pass"`
+	// PassEnv names an environment variable holding the password.
+	PassEnv string `yaml:"pass_env"`
+	// PassFile is a file holding the password on its first line.
+	PassFile string `yaml:"pass_file"`
+	Scheme   string `yaml:"scheme"`
+	Insecure bool   `yaml:"insecure"`
+	CAFile   string `yaml:"ca_file"`
+	Pin      string `yaml:"pin"`
+	Channel  int    `yaml:"channel"`
+	NVR      bool   `yaml:"nvr"`
+	// Auth is a key of authModes.
+	Auth string `yaml:"auth"`
+	// Format is a key of formats.
+	Format string `yaml:"format"`
+	// Proxy is an http, https or socks5 proxy URL. Empty means the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string `yaml:"proxy"`
+	// Protocol is "auto" (or empty) to detect how IR is controlled,
+	// "legacy" for pre-5.x firmware, or "onvif" for units that only speak
+	// ONVIF.
+	Protocol string `yaml:"protocol"`
+	// Notify names the notifiers that are sent this camera's events and
+	// state changes.
+	Notify []string `yaml:"notify"`
+	// Rules are the event-driven IR rules applied by the rules action.
+	Rules []ruleConfig `yaml:"rules"`
+}
+
+// fileConfig is the layout of a --config file:
+//
+//	defaults:
+//	  user: admin
+//	  pass: shared-secret
+//	cameras:
+//	  front-door:
+//	    host: 192.168.1.4
+//	  garage:
+//	    host: 192.168.1.5
+//	    pass: other-secret
+type fileConfig struct {
+	Defaults cameraConfig            `yaml:"defaults"`
+	Cameras  map[string]cameraConfig `yaml:"cameras"`
+	MQTT     mqttConfig              `yaml:"mqtt"`
+	Schedule scheduleConfig          `yaml:"schedule"`
+	Watchdog watchdogConfig          `yaml:"watchdog"`
+	AutoLux  autoLuxConfig           `yaml:"auto_lux"`
+	Webhooks []webhookConfig         `yaml:"webhooks"`
+	// Notifiers are the chats cameras name in their notify lists.
+	Notifiers map[string]chatConfig `yaml:"notifiers"`
+	// Snapshots is where rules save their snapshots.
+	Snapshots snapshotStore `yaml:"snapshots"`
+
+	// path is the file the config was loaded from.
+	path string
+}
+
+// loadConfig reads and parses a camera configuration file.
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	cfg := fileConfig{path: path}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if len(cfg.Cameras) == 0 {
+		return nil, fmt.Errorf("config %s defines no cameras", path)
+	}
+	return &cfg, nil
+}
+
+// names returns the configured camera names in sorted order.
+func (f *fileConfig) names() []string {
+	names := make([]string, 0, len(f.Cameras))
+	for name := range f.Cameras {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withDefaults fills every unset field of c from d.
+func (c cameraConfig) withDefaults(d cameraConfig) cameraConfig {
+	if c.Host == "" {
+		c.Host = d.Host
+	}
+	if c.Port == 0 {
+		c.Port = d.Port
+	}
+	if c.User == "" {
+		c.User = d.User
+	}
+	// The password sources go together, so that a pass_env in an entry is
+	// not overridden by a shared pass in the defaults.
+	if c.Pass == "" && c.PassEnv == "" && c.PassFile == "" {
+		c.Pass, c.PassEnv, c.PassFile = d.Pass, d.PassEnv, d.PassFile
+	}
+	if c.Scheme == "" {
+		c.Scheme = d.Scheme
+	}
+	c.Insecure = c.Insecure || d.Insecure
+	if c.CAFile == "" {
+		c.CAFile = d.CAFile
+	}
+	if c.Pin == "" {
+		c.Pin = d.Pin
+	}
+	if c.Channel == 0 {
+		c.Channel = d.Channel
+	}
+	c.NVR = c.NVR || d.NVR
+	if c.Protocol == "" {
+		c.Protocol = d.Protocol
+	}
+	if c.Notify == nil {
+		c.Notify = d.Notify
+	}
+	if c.Proxy == "" {
+		c.Proxy = d.Proxy
+	}
+	if c.Auth == "" {
+		c.Auth = d.Auth
+	}
+	if c.Format == "" {
+		c.Format = d.Format
+	}
+	if len(c.Rules) == 0 {
+		c.Rules = d.Rules
+	}
+	return c
+}
+
+// target is one camera an action runs against.
+type target struct {
+	name    string
+	cam     *hikvision.Camera
+	channel int
+	rules   []ruleConfig
+	pass    passSource
+	// notify names the notifiers of the camera.
+	notify []string
+}
+
+// clientSettings are the request settings shared by every target.
+type clientSettings struct {
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+	// logger receives the HTTP exchanges of every camera.
+	logger *slog.Logger
+	// dryRun prints changes instead of sending them.
+	dryRun bool
+	// dump, if set, receives every HTTP exchange.
+	dump hikvision.DumpFunc
+}
+
+// authModes maps the auth setting to library authentication modes.
+var authModes = map[string]hikvision.AuthMode{
+	"":        hikvision.AuthAuto,
+	"auto":    hikvision.AuthAuto,
+	"digest":  hikvision.AuthDigest,
+	"basic":   hikvision.AuthBasic,
+	"session": hikvision.AuthSession,
+}
+
+// formats maps the format setting to library body formats.
+var formats = map[string]hikvision.Format{
+	"":     hikvision.FormatAuto,
+	"auto": hikvision.FormatAuto,
+	"xml":  hikvision.FormatXML,
+	"json": hikvision.FormatJSON,
+}
+
+// open builds a camera client from c.
+func (c cameraConfig) open(s clientSettings) (*hikvision.Camera, error) {
+	if c.Host == "" {
+		return nil, fmt.Errorf("no host")
+	}
+	if c.Pass == "" {
+		return nil, fmt.Errorf("no password")
+	}
+	if _, _, err := hikvision.SplitHost(c.Host); err != nil {
+		return nil, err
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", c.Port)
+	}
+
+	opts := []hikvision.Option{
+		hikvision.WithCredentials(c.User, c.Pass),
+		hikvision.WithChannel(c.Channel),
+		hikvision.WithTimeout(s.timeout),
+		hikvision.WithPort(c.Port),
+	}
+	if s.logger != nil {
+		opts = append(opts, hikvision.WithLogger(s.logger))
+	}
+	if s.dump != nil {
+		opts = append(opts, hikvision.WithDump(s.dump))
+	}
+	switch c.Scheme {
+	case "", "http":
+	case "https":
+		tlsOpts, err := tlsOptions(c.Insecure, c.CAFile, c.Pin)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, hikvision.WithTLS(tlsOpts))
+	default:
+		return nil, fmt.Errorf("unknown scheme %q — must be http or https", c.Scheme)
+	}
+	auth, ok := authModes[c.Auth]
+	if !ok {
+		return nil, fmt.Errorf("unknown auth %q — must be auto, digest, basic, or session", c.Auth)
+	}
+	opts = append(opts, hikvision.WithAuth(auth))
+	format, ok := formats[c.Format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q — must be auto, xml, or json", c.Format)
+	}
+	opts = append(opts, hikvision.WithFormat(format))
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q: want a URL such as socks5://127.0.0.1:1080", c.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q — must be http, https, or socks5", u.Scheme)
+		}
+		opts = append(opts, hikvision.WithProxy(u))
+	}
+	cam := hikvision.NewCamera(c.Host, opts...)
+	switch c.Protocol {
+	case "", "auto":
+	case "legacy":
+		cam.IREndpoint = hikvision.IREndpointLegacy
+	case "onvif":
+		cam.IREndpoint = hikvision.IREndpointONVIF
+	default:
+		return nil, fmt.Errorf("unknown protocol %q — must be auto, legacy, or onvif", c.Protocol)
+	}
+	cam.NVR = c.NVR
+	if s.dryRun {
+		cam.DryRun = os.Stdout
+	}
+	cam.Retry = hikvision.RetryPolicy{MaxAttempts: s.retries + 1, BaseDelay: s.retryDelay}
+	return cam, nil
+}
+
+// selectTargets resolves which cameras to act on. Without a config file the
+// flags describe a single camera; with one, --camera picks entries by name
+// (comma-separated) and --all picks every entry. Flag values act as the
+// lowest-priority defaults for config entries.
+func selectTargets(flags cameraConfig, cfg *fileConfig, cameras string, all bool, s clientSettings) ([]target, error) {
+	if cfg == nil {
+		if cameras != "" || all {
+			return nil, fmt.Errorf("--camera and --all need --config")
+		}
+		if flags.Host == "" {
+			return nil, fmt.Errorf("no host")
+		}
+		pass, src, err := flags.password(flags.Host)
+		if err != nil {
+			return nil, err
+		}
+		flags.Pass = pass
+		cam, err := flags.open(s)
+		if err != nil {
+			return nil, err
+		}
+		return []target{{name: flags.Host, cam: cam, channel: flags.Channel, pass: src}}, nil
+	}
+
+	var names []string
+	switch {
+	case all && cameras != "":
+		return nil, fmt.Errorf("use either --camera or --all, not both")
+	case all:
+		names = cfg.names()
+	case cameras != "":
+		names = strings.Split(cameras, ",")
+	default:
+		return nil, fmt.Errorf("--config needs --camera <name> or --all")
+	}
+
+	targets := make([]target, 0, len(names))
+	for _, name := range names {
+		entry, ok := cfg.Cameras[name]
+		if !ok {
+			return nil, fmt.Errorf("camera %q not found in %s", name, cfg.path)
+		}
+		c := entry.withDefaults(cfg.Defaults).withDefaults(flags)
+		pass, src, err := c.password(name)
+		if err != nil {
+			return nil, fmt.Errorf("camera %q: %w", name, err)
+		}
+		c.Pass = pass
+		cam, err := c.open(s)
+		if err != nil {
+			return nil, fmt.Errorf("camera %q: %w", name, err)
+		}
+		targets = append(targets, target{name: name, cam: cam, channel: c.Channel, rules: c.Rules, pass: src, notify: c.Notify})
+	}
+	return targets, nil
+}
+
+// tlsOptions builds the library TLS settings from the HTTPS settings.
+func tlsOptions(insecure bool, caFile, pin string) (hikvision.TLSOptions, error) {
+	opts := hikvision.TLSOptions{InsecureSkipVerify: insecure}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return opts, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return opts, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		opts.RootCAs = pool
+	}
+	if pin != "" {
+		sum, err := hex.DecodeString(strings.ReplaceAll(pin, ":", ""))
+		if err != nil || len(sum) != 32 {
+			return opts, fmt.Errorf("invalid pin %q: want 64 hex characters", pin)
+		}
+		opts.PinnedSHA256 = sum
+	}
+	return opts, nil
+}
+
+// editConfig rewrites the cameras mapping of a config file through edit. The
+// file goes through yaml.Node, so comments and key order survive, and is
+// replaced atomically so a crash cannot leave it half written. With create, a
+// missing file or cameras mapping is started empty.
+func editConfig(path string, create bool, edit func(cameras *yaml.Node) error) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parse config %s: %w", path, err)
+		}
+	case !create || !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("read config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		if !create {
+			return fmt.Errorf("config %s is empty", path)
+		}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	cameras := mappingValue(root, "cameras")
+	if cameras == nil && create && root.Kind == yaml.MappingNode {
+		cameras = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "cameras"}, cameras)
+	}
+	if cameras == nil || cameras.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s has no cameras", path)
+	}
+	if err := edit(cameras); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	enc := yaml.NewEncoder(tmp)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// appendPair adds a string key and value to the end of a mapping node.
+func appendPair(m *yaml.Node, key, value string) {
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
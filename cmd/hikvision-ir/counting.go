@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// statisticsReports maps the period names of counting and heatmap to report
+// types.
+var statisticsReports = map[string]hikvision.ReportType{
+	"hour":  hikvision.ReportDaily,
+	"day":   hikvision.ReportMonthly,
+	"month": hikvision.ReportYearly,
+}
+
+// statisticsSearch builds the search of counting and heatmap from --from,
+// --to and an optional period argument. The period defaults to hour for
+// windows of up to two days and day beyond.
+func statisticsSearch(a actionArgs) (hikvision.StatisticsSearch, error) {
+	now := time.Now()
+	from, err := parseWhen(a.from, now.Add(-24*time.Hour), now)
+	if err != nil {
+		return hikvision.StatisticsSearch{}, fmt.Errorf("--from: %w", err)
+	}
+	to, err := parseWhen(a.to, now, now)
+	if err != nil {
+		return hikvision.StatisticsSearch{}, fmt.Errorf("--to: %w", err)
+	}
+	if !to.After(from) {
+		return hikvision.StatisticsSearch{}, fmt.Errorf("--to must be after --from")
+	}
+	if a.json && a.csv {
+		return hikvision.StatisticsSearch{}, fmt.Errorf("give --json or --csv, not both")
+	}
+	s := hikvision.StatisticsSearch{From: from, To: to, Report: hikvision.ReportDaily}
+	if to.Sub(from) > 48*time.Hour {
+		s.Report = hikvision.ReportMonthly
+	}
+	switch len(a.positional) {
+	case 0:
+	case 1:
+		var ok bool
+		if s.Report, ok = statisticsReports[a.positional[0]]; !ok {
+			return hikvision.StatisticsSearch{}, fmt.Errorf("unknown period %q — must be hour, day or month", a.positional[0])
+		}
+	default:
+		return hikvision.StatisticsSearch{}, fmt.Errorf("%s takes at most one period: hour, day or month", a.action)
+	}
+	return s, nil
+}
+
+// counting prints the people counted on channel in each period between
+// --from and --to, as a table, --json lines or --csv rows.
+func counting(ctx context.Context, cam *hikvision.Camera, channel int, name string, a actionArgs, w io.Writer) error {
+	s, err := statisticsSearch(a)
+	if err != nil {
+		return err
+	}
+	periods, err := cam.SearchCounting(ctx, channel, s)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.json:
+		enc := json.NewEncoder(w)
+		for _, p := range periods {
+			if err := enc.Encode(struct {
+				Camera string `json:"camera"`
+				hikvision.CountingPeriod
+			}{name, p}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case a.csv:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"camera", "start", "end", "enter", "exit", "pass"})
+		for _, p := range periods {
+			cw.Write([]string{name, p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339), strconv.Itoa(p.Enter), strconv.Itoa(p.Exit), strconv.Itoa(p.Pass)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	if len(periods) == 0 {
+		fmt.Fprintln(w, "counting: nothing counted")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tENTER\tEXIT\tPASS")
+	var enter, exit, pass int
+	for _, p := range periods {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", p.Start.Format("2006-01-02 15:04"), p.End.Format("2006-01-02 15:04"), p.Enter, p.Exit, p.Pass)
+		enter, exit, pass = enter+p.Enter, exit+p.Exit, pass+p.Pass
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t%d\t%d\n", enter, exit, pass)
+	return tw.Flush()
+}
+
+// heatmap prints the heat map activity of channel in each period between
+// --from and --to, as a table, --json lines or --csv rows.
+func heatmap(ctx context.Context, cam *hikvision.Camera, channel int, name string, a actionArgs, w io.Writer) error {
+	s, err := statisticsSearch(a)
+	if err != nil {
+		return err
+	}
+	periods, err := cam.SearchHeatMap(ctx, channel, s)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.json:
+		enc := json.NewEncoder(w)
+		for _, p := range periods {
+			if err := enc.Encode(struct {
+				Camera string `json:"camera"`
+				hikvision.HeatMapPeriod
+			}{name, p}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case a.csv:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"camera", "start", "end", "max", "min", "value"})
+		for _, p := range periods {
+			cw.Write([]string{name, p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339), strconv.Itoa(p.Max), strconv.Itoa(p.Min), strconv.Itoa(p.Value)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	if len(periods) == 0 {
+		fmt.Fprintln(w, "heatmap: no activity")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tMAX\tMIN\tVALUE")
+	for _, p := range periods {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", p.Start.Format("2006-01-02 15:04"), p.End.Format("2006-01-02 15:04"), p.Max, p.Min, p.Value)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// passSource records where the password of a target came from, so that
+// passwd --update-config can store a new one in the same place.
+type passSource struct {
+	// env and file are the pass_env or pass_file setting that supplied it.
+	env, file string
+	// keyring is the keyring account it was read from.
+	keyring string
+}
+
+// inline reports whether the password was given as pass.
+func (p passSource) inline() bool {
+	return p == passSource{}
+}
+
+// password resolves the camera password: pass, the pass_env variable or the
+// pass_file contents, in that order, or with none of them set the OS keyring
+// entry for account.
+func (c cameraConfig) password(account string) (string, passSource, error) {
+	switch {
+	case c.Pass != "":
+		return c.Pass, passSource{}, nil
+	case c.PassEnv != "":
+		pass := os.Getenv(c.PassEnv)
+		if pass == "" {
+			return "", passSource{}, fmt.Errorf("pass_env: $%s is not set", c.PassEnv)
+		}
+		return pass, passSource{env: c.PassEnv}, nil
+	case c.PassFile != "":
+		data, err := os.ReadFile(c.PassFile)
+		if err != nil {
+			return "", passSource{}, fmt.Errorf("pass_file: %w", err)
+		}
+		pass := strings.TrimRight(string(data), "\r\n")
+		if pass == "" {
+			return "", passSource{}, fmt.Errorf("pass_file: %s is empty", c.PassFile)
+		}
+		return pass, passSource{file: c.PassFile}, nil
+	}
+	pass, err := keyringGet(account)
+	if err != nil {
+		return "", passSource{}, fmt.Errorf("no password: set pass, pass_env or pass_file, or store one with login (%v)", err)
+	}
+	return pass, passSource{keyring: account}, nil
+}
+
+// login stores a camera password in the OS keyring, under the camera name
+// with --config and under the host otherwise, after checking that the camera
+// accepts it. The password is read from the terminal without echo, or from
+// standard input when that is not a terminal.
+func login(ctx context.Context, flags cameraConfig, cfg *fileConfig, positional []string, s clientSettings, w io.Writer) error {
+	var account string
+	c := flags
+	switch {
+	case len(positional) > 1:
+		return fmt.Errorf("login needs at most one camera")
+	case cfg != nil:
+		if len(positional) == 0 {
+			return fmt.Errorf("login needs the camera name")
+		}
+		account = positional[0]
+		entry, ok := cfg.Cameras[account]
+		if !ok {
+			return fmt.Errorf("camera %q not found in %s", account, cfg.path)
+		}
+		c = entry.withDefaults(cfg.Defaults).withDefaults(flags)
+	case len(positional) == 1:
+		c.Host = positional[0]
+		account = c.Host
+	default:
+		account = c.Host
+	}
+	if c.Host == "" {
+		return fmt.Errorf("login needs a camera name with --config, or --host")
+	}
+
+	pass, err := readPassword(os.Stdin, os.Stderr, fmt.Sprintf("Password for %s@%s: ", c.User, account))
+	if err != nil {
+		return err
+	}
+	if pass == "" {
+		return fmt.Errorf("empty password")
+	}
+	c.Pass = pass
+	cam, err := c.open(s)
+	if err != nil {
+		return err
+	}
+	if _, err := cam.GetDeviceInfo(ctx); errors.Is(err, hikvision.ErrUnauthorized) {
+		return fmt.Errorf("%s rejected the password; not stored", account)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not check the password: %v\n", err)
+	}
+	if err := keyringSet(account, pass); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s: password stored in the keyring\n", account)
+	return nil
+}
+
+// readPassword reads one line from f, prompting on w and turning terminal
+// echo off while the password is typed.
+func readPassword(f *os.File, w io.Writer, prompt string) (string, error) {
+	if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(w, prompt)
+		if stty(f, "-echo") == nil {
+			defer func() {
+				stty(f, "echo")
+				fmt.Fprintln(w)
+			}()
+		}
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes the settings of the terminal f.
+func stty(f *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = f
+	return cmd.Run()
+}
+
+// storePassword records a new password in the place the old one of a target
+// came from: the keyring or the pass_file. Passwords given inline are left to
+// savePasswords, and a pass_env variable can only be changed by the caller,
+// so it returns a note for w instead.
+func storePassword(t target, pass string) (note string, err error) {
+	switch {
+	case t.pass.keyring != "":
+		return "keyring updated", keyringSet(t.pass.keyring, pass)
+	case t.pass.file != "":
+		if err := writeFileKeepMode(t.pass.file, []byte(pass+"\n")); err != nil {
+			return "", fmt.Errorf("pass_file: %w", err)
+		}
+		return t.pass.file + " updated", nil
+	case t.pass.env != "":
+		return fmt.Sprintf("set $%s to the new password", t.pass.env), nil
+	}
+	return "", nil
+}
+
+// writeFileKeepMode replaces the contents of an existing file, keeping its
+// permissions.
+func writeFileKeepMode(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
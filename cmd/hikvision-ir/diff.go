@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// diffResources maps the resource names the diff action takes to the ISAPI
+// paths behind them, for a video channel.
+var diffResources = map[string]func(channel int) []string{
+	"image": func(ch int) []string {
+		return []string{fmt.Sprintf("/ISAPI/Image/channels/%d", ch)}
+	},
+	"daynight": func(ch int) []string {
+		return []string{fmt.Sprintf("/ISAPI/Image/channels/%d/ircutFilter", ch)}
+	},
+	"osd": func(ch int) []string {
+		return []string{fmt.Sprintf("/ISAPI/System/Video/inputs/channels/%d/overlays", ch)}
+	},
+	"streams": func(ch int) []string {
+		return []string{
+			fmt.Sprintf("/ISAPI/Streaming/channels/%d", hikvision.StreamingChannelID(ch, 1)),
+			fmt.Sprintf("/ISAPI/Streaming/channels/%d", hikvision.StreamingChannelID(ch, 2)),
+		}
+	},
+	"time": func(int) []string {
+		return []string{"/ISAPI/System/time/ntpServers"}
+	},
+	"network": func(int) []string {
+		return []string{"/ISAPI/System/Network/interfaces", "/ISAPI/Security/adminAccesses"}
+	},
+	"record": func(ch int) []string {
+		return []string{fmt.Sprintf("/ISAPI/ContentMgmt/record/tracks/%d", hikvision.RecordingTrackID(ch))}
+	},
+	"ptz": func(ch int) []string {
+		return []string{
+			fmt.Sprintf("/ISAPI/PTZCtrl/channels/%d/presets", ch),
+			fmt.Sprintf("/ISAPI/PTZCtrl/channels/%d/patrols", ch),
+			fmt.Sprintf("/ISAPI/PTZCtrl/channels/%d/parkaction", ch),
+		}
+	},
+	"events": func(ch int) []string {
+		return []string{
+			fmt.Sprintf("/ISAPI/System/Video/inputs/channels/%d/motionDetection", ch),
+			fmt.Sprintf("/ISAPI/Smart/LineDetection/%d", ch),
+			fmt.Sprintf("/ISAPI/Smart/FieldDetection/%d", ch),
+		}
+	},
+}
+
+// defaultDiffResources are compared when diff is given none.
+var defaultDiffResources = []string{"image", "daynight", "osd", "streams", "time"}
+
+// runDiff compares the settings of two cameras, or of one camera and a file
+// saved earlier with "diff save", as a unified diff:
+//
+//	--camera a,b diff [resource ...]
+//	--camera a diff --file a.txt [resource ...]
+//	--camera a diff save --out a.txt [resource ...]
+func runDiff(ctx context.Context, targets []target, a actionArgs, w io.Writer) error {
+	resources := a.positional
+	save := len(resources) > 0 && resources[0] == "save"
+	if save {
+		resources = resources[1:]
+	}
+	if len(resources) == 0 {
+		resources = defaultDiffResources
+	}
+	for _, r := range resources {
+		if diffResources[r] == nil {
+			return fmt.Errorf("unknown diff resource %q — must be one of %s", r, strings.Join(sortedKeys(diffResources), ", "))
+		}
+	}
+
+	switch {
+	case save:
+		if len(targets) != 1 || a.out == "-" {
+			return fmt.Errorf("diff save needs one camera and --out <file>")
+		}
+		text, err := settingsText(ctx, targets[0], resources)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(a.out, []byte(text), 0o644); err != nil {
+			return fmt.Errorf("write settings: %w", err)
+		}
+		fmt.Fprintf(w, "saved %s\n", a.out)
+		return nil
+	case a.file != "":
+		if len(targets) != 1 {
+			return fmt.Errorf("diff --file needs one camera")
+		}
+		saved, err := os.ReadFile(a.file)
+		if err != nil {
+			return fmt.Errorf("read settings: %w", err)
+		}
+		text, err := settingsText(ctx, targets[0], resources)
+		if err != nil {
+			return err
+		}
+		printDiff(w, a.file, targets[0].name, string(saved), text)
+		return nil
+	case len(targets) == 2:
+		var texts [2]string
+		for i, t := range targets {
+			var err error
+			if texts[i], err = settingsText(ctx, t, resources); err != nil {
+				return fmt.Errorf("%s: %w", t.name, err)
+			}
+		}
+		printDiff(w, targets[0].name, targets[1].name, texts[0], texts[1])
+		return nil
+	}
+	return fmt.Errorf("diff needs two cameras, or one camera and --file")
+}
+
+// settingsText fetches resources from a camera as indented XML, one section
+// per ISAPI path, so that two cameras' settings line up for diffing.
+func settingsText(ctx context.Context, t target, resources []string) (string, error) {
+	var b strings.Builder
+	for _, r := range resources {
+		for _, path := range diffResources[r](t.channel) {
+			fmt.Fprintf(&b, "# %s\n", path)
+			data, err := t.cam.GetRaw(ctx, path)
+			if errors.Is(err, hikvision.ErrNotSupported) {
+				b.WriteString("(not supported)\n")
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			pretty, err := indentXML(data)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", path, err)
+			}
+			b.WriteString(pretty)
+		}
+	}
+	return b.String(), nil
+}
+
+// indentXML re-indents an XML document one element per line, without the
+// namespace and version attributes every ISAPI response repeats.
+func indentXML(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			t.Name.Space = ""
+			attrs := t.Attr[:0]
+			for _, a := range t.Attr {
+				if a.Name.Space != "xmlns" && a.Name.Local != "xmlns" && a.Name.Local != "version" {
+					attrs = append(attrs, a)
+				}
+			}
+			t.Attr = attrs
+			tok = t
+		case xml.EndElement:
+			t.Name.Space = ""
+			tok = t
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.ProcInst, xml.Comment, xml.Directive:
+			continue
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
+
+// printDiff writes a unified diff of two texts with three lines of context.
+func printDiff(w io.Writer, nameA, nameB, a, b string) {
+	hunks := unifiedDiff(lines(a), lines(b), 3)
+	if len(hunks) == 0 {
+		fmt.Fprintln(w, "no differences")
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	for _, h := range hunks {
+		io.WriteString(w, h)
+	}
+}
+
+// lines splits s into lines, keeping their newlines.
+func lines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+// unifiedDiff returns the hunks of a unified diff between two line slices,
+// from a longest common subsequence table. Settings dumps are a few hundred
+// lines, so the quadratic table is cheap.
+func unifiedDiff(a, b []string, context int) []string {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// ops holds one entry per output line: ' ', '-' or '+', with the line
+	// numbers in a and b it came from.
+	type op struct {
+		kind byte
+		i, j int
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, op{' ', i, j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', i, j})
+			j++
+		}
+	}
+
+	var hunks []string
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		start := max(k-context, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run-end > 2*context || run == len(ops) {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		countA, countB := 0, 0
+		for _, o := range ops[start:end] {
+			var line string
+			switch o.kind {
+			case ' ':
+				line = a[o.i]
+				countA++
+				countB++
+			case '-':
+				line = a[o.i]
+				countA++
+			case '+':
+				line = b[o.j]
+				countB++
+			}
+			body.WriteByte(o.kind)
+			body.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		// An empty side is numbered after the line it follows.
+		first := ops[start]
+		fromA, fromB := first.i+1, first.j+1
+		if countA == 0 {
+			fromA--
+		}
+		if countB == 0 {
+			fromB--
+		}
+		hunks = append(hunks, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", fromA, countA, fromB, countB)+body.String())
+		k = end
+	}
+	return hunks
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// discover lists the cameras that answer SADP or WS-Discovery within wait.
+// positional may restrict the search to "sadp" or "ws". With a config path,
+// cameras whose address is not configured yet are added to it.
+func discover(ctx context.Context, wait time.Duration, positional []string, configPath string, w io.Writer) error {
+	find := hikvision.Discover
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "sadp":
+			find = hikvision.DiscoverSADP
+		case "ws":
+			find = hikvision.DiscoverWS
+		default:
+			return fmt.Errorf("unknown discovery protocol %q (sadp | ws)", positional[0])
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	devices, err := find(ctx)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Fprintln(w, "no cameras found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "IP\tMODEL\tSERIAL\tFIRMWARE\tMAC\tFOUND BY")
+	for _, d := range devices {
+		ip := d.Host()
+		if !d.Activated {
+			ip += " (inactive)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", ip, dash(d.Model), dash(d.SerialNumber),
+			dash(d.FirmwareVersion), dash(d.MACAddress), d.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if configPath == "" {
+		return nil
+	}
+	added, err := addDiscovered(configPath, devices)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "added %d camera(s) to %s\n", len(added), configPath)
+	for _, name := range added {
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+	return nil
+}
+
+// addDiscovered adds an entry with just a host to the config file for every
+// activated device whose address none of its cameras uses, and returns the
+// names given to them. A device that is not activated has no password anyone
+// could put in the file yet, so it is left out.
+func addDiscovered(path string, devices []hikvision.DiscoveredDevice) ([]string, error) {
+	var added []string
+	err := editConfig(path, true, func(cameras *yaml.Node) error {
+		hosts := map[string]bool{}
+		for i := 1; i < len(cameras.Content); i += 2 {
+			if h := mappingValue(cameras.Content[i], "host"); h != nil {
+				hosts[h.Value] = true
+			}
+		}
+		for _, d := range devices {
+			if !d.Activated || hosts[d.Host()] || hosts[d.IP] {
+				continue
+			}
+			name := discoveredName(d)
+			for n := 2; mappingValue(cameras, name) != nil; n++ {
+				name = fmt.Sprintf("%s-%d", discoveredName(d), n)
+			}
+			entry := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			appendPair(entry, "host", d.Host())
+			cameras.Content = append(cameras.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, entry)
+			hosts[d.Host()] = true
+			added = append(added, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// discoveredName names a new config entry after the device's address, e.g.
+// "cam-192-168-1-64", which stays valid as a --camera argument.
+func discoveredName(d hikvision.DiscoveredDevice) string {
+	return "cam-" + strings.NewReplacer(".", "-", ":", "-").Replace(d.IP)
+}
+
+// dash stands in for an unknown value in a table.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// dumpTo returns the --dump destination: standard error for "-", or else a
+// directory receiving one file per HTTP exchange, numbered in the order the
+// exchanges started across all cameras.
+func dumpTo(dest string) (hikvision.DumpFunc, error) {
+	if dest == "-" {
+		return hikvision.DumpWriter(os.Stderr), nil
+	}
+	if err := os.MkdirAll(dest, 0o700); err != nil {
+		return nil, fmt.Errorf("dump: %w", err)
+	}
+	var n atomic.Int64
+	return func(_ int, req *http.Request) (io.WriteCloser, error) {
+		path := strings.Trim(req.URL.Path, "/")
+		name := fmt.Sprintf("%04d-%s-%s-%s.txt", n.Add(1), fileSafe(req.URL.Host), req.Method, fileSafe(path))
+		return os.OpenFile(filepath.Join(dest, name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	}, nil
+}
+
+// fileSafe replaces the characters of s that do not belong in a file name.
+func fileSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
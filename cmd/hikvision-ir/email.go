@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// emailSecurities lists the connection security email takes.
+var emailSecurities = []string{"none", "ssl", "tls"}
+
+// email shows the camera's alarm email settings or changes those given as
+// key=value arguments; "email test" sends a test email.
+func email(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	test := len(positional) > 0 && positional[0] == "test"
+	if test {
+		if len(positional) > 1 {
+			return fmt.Errorf("email test takes no arguments")
+		}
+		positional = nil
+	}
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	e, err := cam.GetEmailConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if test {
+		if err := cam.TestEmail(ctx, e); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "email: test sent to %s\n", dash(emailSettings(*e)["to"]))
+		return nil
+	}
+	if len(set) > 0 {
+		if err := applyEmailSettings(e, set); err != nil {
+			return err
+		}
+		if err := cam.SetEmailConfig(ctx, e); err != nil {
+			return err
+		}
+	}
+	printSettings(w, emailSettings(*e), "server", "security", "from", "name", "user", "to")
+	return nil
+}
+
+// applyEmailSettings applies server (host or host:port), security, from,
+// name, user, pass and to (comma-separated addresses) settings. An empty
+// user turns authentication off.
+func applyEmailSettings(e *hikvision.EmailConfig, set map[string]string) error {
+	s := &e.Sender.SMTP
+	for k, v := range set {
+		switch k {
+		case "server":
+			host, port, err := hostPort(k, v, 25)
+			if err != nil {
+				return err
+			}
+			s.Port = port
+			if net.ParseIP(host) != nil {
+				s.AddressingFormat, s.IPAddress = "ipaddress", host
+			} else {
+				s.AddressingFormat, s.HostName = "hostname", host
+			}
+		case "security":
+			switch strings.ToLower(v) {
+			case "none":
+				s.SSL, s.TLS = false, false
+			case "ssl":
+				s.SSL, s.TLS = true, false
+			case "tls":
+				s.SSL, s.TLS = false, true
+			default:
+				return fmt.Errorf("security=%s: must be one of %s", v, strings.Join(emailSecurities, ", "))
+			}
+		case "from":
+			if !strings.Contains(v, "@") {
+				return fmt.Errorf("from=%s: want an email address", v)
+			}
+			e.Sender.Address = v
+		case "name":
+			e.Sender.Name = v
+		case "user":
+			s.User, s.Auth = v, v != ""
+		case "pass":
+			s.Password = v
+		case "to":
+			var addrs []string
+			for _, a := range strings.Split(v, ",") {
+				if a = strings.TrimSpace(a); a == "" {
+					continue
+				}
+				if !strings.Contains(a, "@") {
+					return fmt.Errorf("to=%s: %q is not an email address", v, a)
+				}
+				addrs = append(addrs, a)
+			}
+			if len(e.To) > 0 && len(addrs) > len(e.To) {
+				return fmt.Errorf("to=%s: the camera takes at most %d recipients", v, len(e.To))
+			}
+			for len(e.To) < len(addrs) {
+				e.To = append(e.To, hikvision.EmailRecipient{ID: len(e.To) + 1})
+			}
+			for i := range e.To {
+				e.To[i].Address, e.To[i].Name = "", ""
+				if i < len(addrs) {
+					e.To[i].Address = addrs[i]
+					e.To[i].Name, _, _ = strings.Cut(addrs[i], "@")
+				}
+			}
+		default:
+			return fmt.Errorf("unknown email setting %q — must be server, security, from, name, user, pass or to", k)
+		}
+	}
+	return nil
+}
+
+// emailSettings returns the settings of e keyed like applyEmailSettings
+// takes them, leaving out the password and a default port of 25.
+func emailSettings(e hikvision.EmailConfig) map[string]string {
+	s := e.Sender.SMTP
+	set := map[string]string{"from": e.Sender.Address, "name": e.Sender.Name}
+	if addr := s.Address(); addr != "" && addr != "0.0.0.0" {
+		set["server"] = addr
+		if s.Port != 25 && s.Port != 0 {
+			set["server"] = net.JoinHostPort(addr, strconv.Itoa(s.Port))
+		}
+	}
+	switch {
+	case s.SSL:
+		set["security"] = "ssl"
+	case s.TLS:
+		set["security"] = "tls"
+	default:
+		set["security"] = "none"
+	}
+	if s.Auth {
+		set["user"] = s.User
+	}
+	var to []string
+	for _, r := range e.To {
+		if r.Address != "" {
+			to = append(to, r.Address)
+		}
+	}
+	set["to"] = strings.Join(to, ",")
+	return set
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// watchEvents subscribes to the alert stream of every target and calls handle
+// for each event other than heartbeats, until ctx is cancelled. Dropped
+// subscriptions are re-established after a short delay. handle may be called
+// from several goroutines at once.
+func watchEvents(ctx context.Context, targets []target, handle func(target, hikvision.Event)) {
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			for {
+				err := watchOne(ctx, t, handle)
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("alert stream lost; reconnecting", "camera", t.name, "err", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+func watchOne(ctx context.Context, t target, handle func(target, hikvision.Event)) error {
+	s, err := t.cam.SubscribeEvents(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	for e := range s.C {
+		if !e.Heartbeat() {
+			handle(t, e)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream closed by camera")
+}
+
+// printEvents writes one line per event, and notifies it, until ctx is
+// cancelled.
+func printEvents(ctx context.Context, targets []target, notify *notifier, w io.Writer) {
+	var mu sync.Mutex
+	watchEvents(ctx, targets, func(t target, e hikvision.Event) {
+		notify.event(ctx, t.name, e, nil)
+		mu.Lock()
+		defer mu.Unlock()
+		printEvent(w, t.name, e)
+	})
+}
+
+// printEvent writes an event of camera name on one line.
+func printEvent(w io.Writer, name string, e hikvision.Event) {
+	fmt.Fprintf(w, "%s %s %s %s channel %d", e.DateTime, name, e.Type, e.State, e.ChannelID)
+	if e.Description != "" {
+		fmt.Fprintf(w, ": %s", e.Description)
+	}
+	if t := e.Thermometry; t != nil {
+		fmt.Fprintf(w, " rule %d %.1f°C", t.RuleID, t.Temperature)
+	}
+	if e.AudioException != "" {
+		fmt.Fprintf(w, " (%s)", e.AudioException)
+	}
+	if p := e.ANPR; p != nil {
+		fmt.Fprintf(w, " plate %s", p.Plate)
+		if p.List == hikvision.PlateAllowList || p.List == hikvision.PlateBlockList {
+			fmt.Fprintf(w, " (%s list)", nameOf(plateLists, p.List))
+		}
+	}
+	if n := len(e.Pictures); n > 0 {
+		fmt.Fprintf(w, " (%d picture(s))", n)
+	}
+	fmt.Fprintln(w)
+}
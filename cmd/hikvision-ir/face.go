@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// face prints the face detection setting of channel or changes it with
+// detection=on|off, or lists the face picture libraries ("face libraries"),
+// adds one ("face libraries add <name> [<info>]") or deletes one ("face
+// libraries delete <id>").
+func face(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) > 0 && positional[0] == "libraries" {
+		return faceLibraries(ctx, cam, positional[1:], w)
+	}
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	fd, err := cam.GetFaceDetection(ctx, channel)
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		switch k {
+		case "detection":
+			fd.Enabled, err = onOff(k, v)
+		default:
+			return fmt.Errorf("unknown face setting %q — must be detection", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetFaceDetection(ctx, channel, fd); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "detection: %s\n", onOffName(fd.Enabled))
+	return nil
+}
+
+// faceLibraries lists, adds or deletes face picture libraries, see face.
+func faceLibraries(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "add":
+			if len(positional) < 2 || len(positional) > 3 {
+				return fmt.Errorf("face libraries add needs a name and optionally a description")
+			}
+			info := ""
+			if len(positional) == 3 {
+				info = positional[2]
+			}
+			id, err := cam.AddFaceLibrary(ctx, positional[1], info)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s: added as %s\n", positional[1], dash(id))
+			return nil
+		case "delete":
+			if len(positional) != 2 {
+				return fmt.Errorf("face libraries delete needs one library id")
+			}
+			if err := cam.DeleteFaceLibrary(ctx, positional[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s: deleted\n", positional[1])
+			return nil
+		}
+		return fmt.Errorf("unknown face libraries command %q — must be add or delete", positional[0])
+	}
+
+	libs, err := cam.GetFaceLibraries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, l := range libs {
+		line := fmt.Sprintf("%s: %s (%s)", l.ID, dash(l.Name), dash(l.Type))
+		if l.Info != "" {
+			line += " " + strings.TrimSpace(l.Info)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(libs) == 0 {
+		fmt.Fprintln(w, "libraries: none")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+const (
+	// upgradeTimeout bounds uploading and flashing a firmware image.
+	upgradeTimeout = 15 * time.Minute
+	// rebootTimeout bounds waiting for the camera to come back afterwards.
+	rebootTimeout = 10 * time.Minute
+	// pollInterval is how often an upgrade polls progress and, after the
+	// reboot, the camera.
+	pollInterval = 5 * time.Second
+)
+
+// firmware shows the installed firmware ("firmware status") or upgrades it
+// from a .dav image ("firmware upgrade --file <image>"), reboots the camera
+// and waits until it is back.
+func firmware(ctx context.Context, cam *hikvision.Camera, positional []string, file string, w io.Writer) error {
+	if len(positional) == 0 || positional[0] == "status" {
+		info, err := cam.GetDeviceInfo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "firmware: %s\n", info.FirmwareVersion)
+		if built, err := info.FirmwareDate(); err == nil {
+			fmt.Fprintf(w, "built: %s\n", built.Format("2006-01-02"))
+		}
+		fmt.Fprintf(w, "model: %s\n", info.Model)
+		return nil
+	}
+	if positional[0] != "upgrade" {
+		return fmt.Errorf("unknown firmware command %q — must be status or upgrade", positional[0])
+	}
+	if file == "" {
+		return fmt.Errorf("firmware upgrade needs --file <image.dav>")
+	}
+	image, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read firmware: %w", err)
+	}
+	before, err := cam.GetDeviceInfo(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "firmware: %s, uploading %s (%d KiB)\n", before.FirmwareVersion, file, len(image)/1024)
+
+	if err := upgrade(ctx, cam, image, w); err != nil {
+		return err
+	}
+	if err := cam.Reboot(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "upgrade: written, rebooting")
+
+	after, err := waitForReboot(ctx, cam, before.FirmwareVersion)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "firmware: %s\n", after.FirmwareVersion)
+	return nil
+}
+
+// upgrade uploads image, printing the flashing progress meanwhile. The
+// per-request timeout is lifted for the upload, which takes minutes; each
+// progress poll gets its own.
+func upgrade(ctx context.Context, cam *hikvision.Camera, image []byte, w io.Writer) error {
+	timeout := cam.Timeout
+	cam.Timeout = 0
+	defer func() { cam.Timeout = timeout }()
+	if timeout == 0 {
+		timeout = pollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, upgradeTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		last := -1
+		t := time.NewTicker(pollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+			pctx, cancel := context.WithTimeout(ctx, timeout)
+			s, err := cam.GetUpgradeStatus(pctx)
+			cancel()
+			if err == nil && s.Upgrading && s.Percent != last {
+				fmt.Fprintf(w, "upgrade: %d%%\n", s.Percent)
+				last = s.Percent
+			}
+		}
+	}()
+	err := cam.UpgradeFirmware(ctx, image)
+	close(done)
+	<-polled
+	return err
+}
+
+// waitForReboot polls the camera until it answers again after a reboot. The
+// camera counts as back once it reports a firmware other than old or answers
+// after having been unreachable.
+func waitForReboot(ctx context.Context, cam *hikvision.Camera, old string) (*hikvision.DeviceInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, rebootTimeout)
+	defer cancel()
+	wentDown := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("camera did not come back after the upgrade: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+		info, err := cam.GetDeviceInfo(ctx)
+		if err != nil {
+			wentDown = true
+			continue
+		}
+		if wentDown || info.FirmwareVersion != old {
+			return info, nil
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// fisheyeMounts and fisheyeDisplays map CLI names to fisheye settings.
+var (
+	fisheyeMounts = map[string]string{
+		"ceiling": hikvision.FisheyeMountCeiling,
+		"wall":    hikvision.FisheyeMountWall,
+		"desktop": hikvision.FisheyeMountDesktop,
+	}
+	fisheyeDisplays = map[string]string{
+		"360":      hikvision.FisheyeDisplay360,
+		"panorama": hikvision.FisheyeDisplayPanorama,
+		"ptz":      hikvision.FisheyeDisplayPTZ,
+	}
+)
+
+// fisheye shows the mounting and display mode of a fisheye channel or
+// changes them with mount=ceiling|wall|desktop, display=360|panorama|ptz
+// and views (the number of PTZ views).
+func fisheye(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	f, err := cam.GetFisheye(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if err := applyFisheyeSettings(f, set); err != nil {
+			return err
+		}
+		if err := cam.SetFisheye(ctx, channel, f); err != nil {
+			return err
+		}
+	}
+	printSettings(w, fisheyeSettings(*f), "mount", "display", "views")
+	return nil
+}
+
+func applyFisheyeSettings(f *hikvision.Fisheye, set map[string]string) error {
+	for k, v := range set {
+		switch k {
+		case "mount":
+			m, ok := fisheyeMounts[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("mount=%s: must be ceiling, wall or desktop", v)
+			}
+			f.Mount = m
+		case "display":
+			d, ok := fisheyeDisplays[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("display=%s: must be 360, panorama or ptz", v)
+			}
+			f.Display = d
+		case "views":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("views=%s: want a number of PTZ views", v)
+			}
+			f.PTZViews = n
+		default:
+			return fmt.Errorf("unknown fisheye setting %q — must be mount, display or views", k)
+		}
+	}
+	return nil
+}
+
+// fisheyeSettings returns the settings of f keyed like applyFisheyeSettings
+// takes them.
+func fisheyeSettings(f hikvision.Fisheye) map[string]string {
+	set := map[string]string{}
+	if f.Mount != "" {
+		set["mount"] = nameOf(fisheyeMounts, f.Mount)
+	}
+	if f.Display != "" {
+		set["display"] = nameOf(fisheyeDisplays, f.Display)
+	}
+	if f.PTZViews > 0 {
+		set["views"] = strconv.Itoa(f.PTZViews)
+	}
+	return set
+}
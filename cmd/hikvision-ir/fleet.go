@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// result is the outcome of running an action against one target.
+type result struct {
+	name    string
+	out     bytes.Buffer
+	err     error
+	elapsed time.Duration
+}
+
+// runAll runs an action against every target using at most parallel
+// concurrent workers. Results are returned in target order.
+func runAll(ctx context.Context, targets []target, args actionArgs, parallel int) []*result {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]*result, len(targets))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		results[i] = &result{name: t.name}
+		wg.Add(1)
+		go func(t target, r *result) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			a := args
+			if a.action == "snapshot" {
+				a.out = filepath.Join(args.out, t.name+".jpg")
+			}
+			start := time.Now()
+			r.err = run(ctx, t, a, &r.out)
+			r.elapsed = time.Since(start)
+		}(t, results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// printRawResults writes each target's output as it is, for output that is
+// meant for other programs and names the camera on each line, such as
+// --json and --csv output. A header line that repeats the first target's is
+// dropped. Failures are reported on errw, and it reports whether any target
+// failed.
+func printRawResults(w, errw io.Writer, results []*result) (failed bool) {
+	var header string
+	for _, r := range results {
+		out := r.out.String()
+		first, rest, _ := strings.Cut(out, "\n")
+		if header == "" {
+			header = first
+		} else if first == header {
+			out = rest
+		}
+		io.WriteString(w, out)
+	}
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(errw, "error: %s: %v\n", r.name, r.err)
+			failed = true
+		}
+	}
+	return failed
+}
+
+// printResults writes each target's output prefixed with its name, followed
+// by a summary table. It reports whether any target failed.
+func printResults(w io.Writer, results []*result) (failed bool) {
+	for _, r := range results {
+		sc := bufio.NewScanner(&r.out)
+		for sc.Scan() {
+			fmt.Fprintf(w, "%s: %s\n", r.name, sc.Text())
+		}
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CAMERA\tRESULT\tTIME\tERROR")
+	for _, r := range results {
+		status, msg := "ok", ""
+		if r.err != nil {
+			status, msg = "FAILED", r.err.Error()
+			failed = true
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.name, status, r.elapsed.Round(time.Millisecond), msg)
+	}
+	tw.Flush()
+	return failed
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// ftp lists the FTP servers the camera uploads pictures to or, given an id
+// and settings, shows and changes one:
+//
+//	ftp [<id>] server=ftp.lan[:21] user=cam pass=secret [pictures=on] ...
+//	ftp test [<id>]
+//
+// The id defaults to 1 when settings are given.
+func ftp(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	servers, err := cam.GetFTPServers(ctx)
+	if err != nil {
+		return err
+	}
+	test := len(args) > 0 && args[0] == "test"
+	if test {
+		args = args[1:]
+		if len(args) > 1 {
+			return fmt.Errorf("ftp test takes at most an id")
+		}
+	} else if len(args) == 0 {
+		for _, f := range servers {
+			fmt.Fprintf(w, "%d: %s\n", f.ID, ftpSummary(f))
+		}
+		return nil
+	}
+
+	id := 1
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		if id, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid ftp server id %q", args[0])
+		}
+		args = args[1:]
+	}
+	i := slices.IndexFunc(servers, func(f hikvision.FTPServer) bool { return f.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no ftp server %d", id)
+	}
+	f := &servers[i]
+	if test {
+		if err := cam.TestFTPServer(ctx, f); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "ftp %d: test upload succeeded\n", id)
+		return nil
+	}
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if err := applyFTPSettings(f, set); err != nil {
+			return err
+		}
+		if err := cam.SetFTPServer(ctx, f); err != nil {
+			return err
+		}
+	}
+	printSettings(w, ftpSettings(*f), "enabled", "server", "user", "anonymous", "pictures")
+	return nil
+}
+
+// applyFTPSettings applies enabled, server (host or host:port), user, pass,
+// anonymous and pictures settings. Setting a server also enables it.
+func applyFTPSettings(f *hikvision.FTPServer, set map[string]string) error {
+	var err error
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			f.Enabled, err = onOff(k, v)
+		case "server":
+			var host string
+			if host, f.Port, err = hostPort(k, v, 21); err != nil {
+				return err
+			}
+			if net.ParseIP(host) != nil {
+				f.AddressingFormat, f.IPAddress = "ipaddress", host
+			} else {
+				f.AddressingFormat, f.HostName = "hostname", host
+			}
+			if _, ok := set["enabled"]; !ok {
+				f.Enabled = true
+			}
+		case "user":
+			f.User = v
+		case "pass":
+			f.Password = v
+		case "anonymous":
+			f.Anonymous, err = onOff(k, v)
+		case "pictures":
+			f.Pictures, err = onOff(k, v)
+		default:
+			return fmt.Errorf("unknown ftp setting %q — must be enabled, server, user, pass, anonymous or pictures", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ftpSettings returns the settings of f keyed like applyFTPSettings takes
+// them, leaving out the password and a default port of 21.
+func ftpSettings(f hikvision.FTPServer) map[string]string {
+	set := map[string]string{
+		"enabled":   onOffName(f.Enabled),
+		"user":      f.User,
+		"anonymous": onOffName(f.Anonymous),
+		"pictures":  onOffName(f.Pictures),
+	}
+	if addr := f.Address(); addr != "" && addr != "0.0.0.0" {
+		set["server"] = addr
+		if f.Port != 21 && f.Port != 0 {
+			set["server"] = net.JoinHostPort(addr, strconv.Itoa(f.Port))
+		}
+	}
+	return set
+}
+
+// ftpSummary formats an FTP server on one line.
+func ftpSummary(f hikvision.FTPServer) string {
+	set := ftpSettings(f)
+	if set["server"] == "" {
+		return "unset"
+	}
+	s := set["server"]
+	switch {
+	case f.Anonymous:
+		s += " anonymous"
+	case f.User != "":
+		s += " as " + f.User
+	}
+	if !f.Enabled {
+		s += " (off)"
+	}
+	return s
+}
+
+// hostPort parses a host, or host:port, setting, the port defaulting to
+// port.
+func hostPort(key, value string, port int) (string, int, error) {
+	host := value
+	if h, p, err := net.SplitHostPort(value); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return "", 0, fmt.Errorf("%s=%s: want a port 1-65535", key, value)
+		}
+		host, port = h, n
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("%s=%s: want a host or host:port", key, value)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// healthState is the outcome of a health check, numbered like the exit codes
+// of Nagios and Icinga plugins.
+type healthState int
+
+const (
+	healthOK healthState = iota
+	healthWarn
+	healthCrit
+	healthUnknown
+)
+
+func (s healthState) String() string {
+	return [...]string{"OK", "WARN", "CRIT", "UNKNOWN"}[s]
+}
+
+// worse returns the worse of s and o, where CRIT outranks UNKNOWN.
+func (s healthState) worse(o healthState) healthState {
+	rank := [...]int{healthOK: 0, healthWarn: 1, healthUnknown: 2, healthCrit: 3}
+	if rank[o] > rank[s] {
+		return o
+	}
+	return s
+}
+
+// Clock drift beyond which the time check warns or fails.
+const (
+	healthDriftWarn = 30 * time.Second
+	healthDriftCrit = 5 * time.Minute
+)
+
+// healthResult is one check of a health report. A check the camera cannot
+// answer is skipped and left out of the report.
+type healthResult struct {
+	Check  string `json:"check"`
+	State  string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	state  healthState
+}
+
+// healthError is returned by health when a camera is not OK, so its state
+// can become the exit code.
+type healthError struct {
+	state healthState
+	// failed names the checks that were not OK.
+	failed []string
+}
+
+func (e *healthError) Error() string {
+	return fmt.Sprintf("health %s: %s", e.state, strings.Join(e.failed, ", "))
+}
+
+// health checks that the camera answers, accepts the login, keeps time,
+// has healthy storage and serves the main stream of channel, and prints the
+// result of each check, or with --json one object for the camera. It
+// returns a *healthError unless every check passed.
+func health(ctx context.Context, cam *hikvision.Camera, name string, channel int, a actionArgs, w io.Writer) error {
+	results := healthChecks(ctx, cam, channel, a.rtspPort)
+	worst := healthOK
+	var failed []string
+	for _, r := range results {
+		if r.state > healthOK {
+			failed = append(failed, r.Check)
+		}
+		worst = worst.worse(r.state)
+	}
+
+	if a.json {
+		if err := json.NewEncoder(w).Encode(struct {
+			Camera string         `json:"camera"`
+			State  string         `json:"status"`
+			Checks []healthResult `json:"checks"`
+		}{name, worst.String(), results}); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.State, r.Check, r.Detail)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "health: %s\n", worst)
+	}
+	if worst > healthOK {
+		return &healthError{state: worst, failed: failed}
+	}
+	return nil
+}
+
+// healthChecks runs the checks of health. A camera that does not answer or
+// rejects the login fails without the later checks.
+func healthChecks(ctx context.Context, cam *hikvision.Camera, channel, rtspPort int) []healthResult {
+	var results []healthResult
+	add := func(check string, state healthState, detail string) {
+		results = append(results, healthResult{Check: check, State: state.String(), Detail: detail, state: state})
+	}
+
+	info, err := cam.GetDeviceInfo(ctx)
+	switch {
+	case errors.Is(err, hikvision.ErrUnauthorized):
+		add("reachable", healthOK, "")
+		add("login", healthCrit, "rejected")
+		return results
+	case err != nil:
+		add("reachable", healthCrit, err.Error())
+		return results
+	}
+	add("reachable", healthOK, fmt.Sprintf("%s %s", info.Model, info.FirmwareVersion))
+	add("login", healthOK, "")
+
+	// The drift is measured against the middle of the round trip, and
+	// only for cameras that give their UTC offset.
+	start := time.Now()
+	switch t, err := cam.GetTime(ctx); {
+	case errors.Is(err, hikvision.ErrNotSupported):
+	case err != nil:
+		add("time", healthWarn, err.Error())
+	default:
+		host := start.Add(time.Since(start) / 2)
+		ts, err := t.Time()
+		if err != nil || ts.Location() == time.UTC {
+			break
+		}
+		drift := ts.Sub(host)
+		state := healthOK
+		if d := drift.Abs(); d >= healthDriftCrit {
+			state = healthCrit
+		} else if d >= healthDriftWarn {
+			state = healthWarn
+		}
+		add("time", state, fmt.Sprintf("drift %+.0fs", drift.Seconds()))
+	}
+
+	if s, err := cam.GetStorage(ctx); err == nil {
+		if len(s.HDDs) > 0 {
+			state, detail := healthOK, fmt.Sprintf("%d healthy", len(s.HDDs))
+			var bad []string
+			for _, h := range s.HDDs {
+				if !h.Healthy() {
+					bad = append(bad, fmt.Sprintf("disk %d %s", h.ID, h.Status))
+				}
+			}
+			if len(bad) > 0 {
+				state, detail = healthCrit, strings.Join(bad, ", ")
+			}
+			add("storage", state, detail)
+		}
+	} else if !errors.Is(err, hikvision.ErrNotSupported) {
+		add("storage", healthWarn, err.Error())
+	}
+
+	id := hikvision.StreamingChannelID(channel, 1)
+	if si, err := cam.ProbeStream(ctx, id, rtspPort); err != nil {
+		add("stream", healthCrit, fmt.Sprintf("%d: %v", id, err))
+	} else {
+		add("stream", healthOK, fmt.Sprintf("%d %s", id, si.Codec))
+	}
+	return results
+}
+
+// runHealth runs health against every target and prints the reports. It
+// returns the worst state as the exit code: 0 OK, 1 WARN, 2 CRIT, and 3
+// when a camera could not be checked and none is CRIT.
+func runHealth(ctx context.Context, targets []target, args actionArgs, parallel int, w io.Writer) int {
+	results := runAll(ctx, targets, args, parallel)
+	switch {
+	case len(results) == 1:
+		io.Copy(w, &results[0].out)
+	case args.json:
+		printRawResults(w, io.Discard, results)
+	default:
+		printResults(w, results)
+	}
+	worst := healthOK
+	for _, r := range results {
+		var he *healthError
+		switch {
+		case r.err == nil:
+		case errors.As(r.err, &he):
+			worst = worst.worse(he.state)
+		default:
+			if len(results) == 1 || args.json {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", r.name, r.err)
+			}
+			worst = worst.worse(healthUnknown)
+		}
+	}
+	return int(worst)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// haDevice is the device block of a Home Assistant discovery payload; it
+// groups every entity of one camera under a single device.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+type haAvailability struct {
+	Topic string `json:"topic"`
+}
+
+// haEntity is a Home Assistant MQTT discovery payload for a switch or select.
+type haEntity struct {
+	Name             string           `json:"name"`
+	UniqueID         string           `json:"unique_id"`
+	StateTopic       string           `json:"state_topic"`
+	CommandTopic     string           `json:"command_topic"`
+	PayloadOn        string           `json:"payload_on,omitempty"`
+	PayloadOff       string           `json:"payload_off,omitempty"`
+	StateOn          string           `json:"state_on,omitempty"`
+	StateOff         string           `json:"state_off,omitempty"`
+	Options          []string         `json:"options,omitempty"`
+	Icon             string           `json:"icon,omitempty"`
+	Availability     []haAvailability `json:"availability"`
+	AvailabilityMode string           `json:"availability_mode"`
+	Device           haDevice         `json:"device"`
+}
+
+func (c mqttConfig) discoveryPrefix() string {
+	if c.DiscoveryPrefix == "" {
+		return "homeassistant"
+	}
+	return strings.TrimSuffix(c.DiscoveryPrefix, "/")
+}
+
+var unsafeObjectID = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// objectID turns a camera name into a Home Assistant object ID.
+func objectID(name string) string {
+	return strings.Trim(unsafeObjectID.ReplaceAllString(name, "_"), "_")
+}
+
+// subscribeHAStatus republishes discovery whenever Home Assistant announces
+// it has (re)started, since it may have lost non-retained state.
+func (b *bridge) subscribeHAStatus(c mqtt.Client) {
+	c.Subscribe(b.cfg.discoveryPrefix()+"/status", b.cfg.QoS, func(_ mqtt.Client, m mqtt.Message) {
+		if string(m.Payload()) == "online" {
+			b.mu.Lock()
+			b.announced = make(map[string]bool)
+			b.mu.Unlock()
+			go b.pollAll(context.Background())
+		}
+	})
+}
+
+// announce publishes the discovery payloads for one camera: a switch for the
+// IR light and a select for the IR mode, both tied to the bridge and camera
+// availability topics.
+func (b *bridge) announce(ctx context.Context, t target) {
+	id := objectID(t.name)
+	dev := haDevice{Identifiers: []string{"hikvision-ir_" + id}, Name: t.name, Manufacturer: "Hikvision"}
+	if info, err := t.cam.GetDeviceInfo(ctx); err == nil {
+		if info.SerialNumber != "" {
+			dev.Identifiers = []string{"hikvision_" + info.SerialNumber}
+		}
+		dev.Model, dev.SWVersion = info.Model, info.FirmwareVersion
+	}
+	avail := []haAvailability{{Topic: b.statusTopic()}, {Topic: b.topic(t.name, "availability")}}
+
+	entities := map[string]haEntity{
+		"switch/" + id + "/ir/config": {
+			Name:         "IR light",
+			UniqueID:     dev.Identifiers[0] + "_ir",
+			StateTopic:   b.topic(t.name, "ir/state"),
+			CommandTopic: b.topic(t.name, "ir/set"),
+			PayloadOn:    "on",
+			PayloadOff:   "off",
+			StateOn:      "on",
+			StateOff:     "off",
+			Icon:         "mdi:lightbulb-night",
+		},
+		"select/" + id + "/ir_mode/config": {
+			Name:         "IR mode",
+			UniqueID:     dev.Identifiers[0] + "_ir_mode",
+			StateTopic:   b.topic(t.name, "ir/state"),
+			CommandTopic: b.topic(t.name, "ir/set"),
+			Options:      []string{"on", "off", "auto"},
+			Icon:         "mdi:theme-light-dark",
+		},
+	}
+	for topic, e := range entities {
+		e.Availability, e.AvailabilityMode, e.Device = avail, "all", dev
+		payload, err := json.Marshal(e)
+		if err != nil {
+			slog.Error("mqtt: encode discovery", "camera", t.name, "err", err)
+			continue
+		}
+		if err := wait(b.client.Publish(b.cfg.discoveryPrefix()+"/"+topic, b.cfg.QoS, true, payload)); err != nil {
+			slog.Error("mqtt: publish discovery", "camera", t.name, "err", err)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// imageGroup is a set of image settings stored on one ISAPI endpoint, shown
+// and changed together by the image action.
+type imageGroup struct {
+	name string
+	keys []string
+	// apply reads the group, changes the settings in set, and prints the
+	// result. set only holds keys of this group and may be empty.
+	apply func(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error
+}
+
+// imageGroups lists the groups in the order image prints them.
+var imageGroups = []imageGroup{
+	{"color", []string{"brightness", "contrast", "saturation"}, colorSettings},
+	{"sharpness", []string{"sharpness"}, sharpnessSettings},
+	{"exposure", []string{"exposure", "shutter", "gain"}, exposureSettings},
+	{"wdr", []string{"wdr", "wdr-level"}, wdrSettings},
+	{"blc", []string{"blc"}, blcSettings},
+	{"hlc", []string{"hlc", "hlc-level"}, hlcSettings},
+	{"dnr", []string{"dnr", "dnr-level", "dnr-spatial", "dnr-temporal"}, dnrSettings},
+	{"wb", []string{"wb", "wb-red", "wb-blue"}, whiteBalanceSettings},
+	{"flip", []string{"flip"}, flipSettings},
+	{"corridor", []string{"corridor"}, corridorSettings},
+	{"defog", []string{"defog", "defog-level"}, defogSettings},
+	{"eis", []string{"eis"}, eisSettings},
+}
+
+// imageGroupsNamed returns the named groups, for actions such as wdr that
+// cover a subset of the image settings.
+func imageGroupsNamed(names ...string) []imageGroup {
+	var groups []imageGroup
+	for _, g := range imageGroups {
+		for _, n := range names {
+			if g.name == n {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups
+}
+
+// image prints the image settings of channel, or changes those given as
+// key=value arguments, e.g. "image contrast=60 sharpness=40". Groups the
+// camera does not support are left out of the listing. Only keys of groups
+// are accepted.
+func image(ctx context.Context, cam *hikvision.Camera, channel int, groups []imageGroup, positional []string, w io.Writer) error {
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+
+	sets := make([]map[string]string, len(groups))
+	for k, v := range set {
+		i, ok := imageGroupOf(groups, k)
+		if !ok {
+			return fmt.Errorf("unknown image setting %q", k)
+		}
+		if sets[i] == nil {
+			sets[i] = map[string]string{}
+		}
+		sets[i][k] = v
+	}
+
+	for i, g := range groups {
+		if len(set) > 0 && sets[i] == nil {
+			continue
+		}
+		err := g.apply(ctx, cam, channel, sets[i], w)
+		if len(set) == 0 && errors.Is(err, hikvision.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageGroupOf returns the index in groups of the group owning key.
+func imageGroupOf(groups []imageGroup, key string) (int, bool) {
+	for i, g := range groups {
+		for _, k := range g.keys {
+			if k == key {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// checkImageSettings reports the first key no image group accepts.
+func checkImageSettings(set map[string]string) error {
+	for k := range set {
+		if _, ok := imageGroupOf(imageGroups, strings.ToLower(k)); !ok {
+			return fmt.Errorf("unknown image setting %q", k)
+		}
+	}
+	return nil
+}
+
+// printSettings writes the non-empty settings of have in the order of keys,
+// as "key: value" lines.
+func printSettings(w io.Writer, have map[string]string, keys ...string) {
+	for _, k := range keys {
+		if v := have[k]; v != "" {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+}
+
+// parseSettings parses key=value arguments into a map.
+func parseSettings(args []string) (map[string]string, error) {
+	set := map[string]string{}
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid setting %q: want key=value", a)
+		}
+		set[strings.ToLower(k)] = v
+	}
+	return set, nil
+}
+
+// onOff parses an on/off setting value.
+func onOff(key, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s=%s: must be on or off", key, value)
+}
+
+// onOffName is the inverse of onOff.
+func onOffName(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// nameOf returns the key of names that maps to v, or v itself for values the
+// CLI has no name for.
+func nameOf[T ~string](names map[string]T, v T) string {
+	for name, x := range names {
+		if x == v {
+			return name
+		}
+	}
+	return string(v)
+}
+
+// level parses a 0-100 setting value.
+func level(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("%s=%s: want a level 0-100", key, value)
+	}
+	return n, nil
+}
+
+func colorSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	col, err := cam.GetImageColor(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		fields := map[string]*int{"brightness": &col.Brightness, "contrast": &col.Contrast, "saturation": &col.Saturation}
+		for k, v := range set {
+			if *fields[k], err = level(k, v); err != nil {
+				return err
+			}
+		}
+		if err := cam.SetImageColor(ctx, channel, col); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "brightness: %d\n", col.Brightness)
+	fmt.Fprintf(w, "contrast: %d\n", col.Contrast)
+	fmt.Fprintf(w, "saturation: %d\n", col.Saturation)
+	return nil
+}
+
+func sharpnessSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	if v, ok := set["sharpness"]; ok {
+		n, err := level("sharpness", v)
+		if err != nil {
+			return err
+		}
+		if err := cam.SetSharpness(ctx, channel, n); err != nil {
+			return err
+		}
+	}
+	n, err := cam.GetSharpness(ctx, channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "sharpness: %d\n", n)
+	return nil
+}
+
+// exposureModes maps the exposure= values of the image action to exposure modes.
+var exposureModes = map[string]hikvision.ExposureMode{
+	"auto":    hikvision.ExposureAuto,
+	"iris":    hikvision.ExposureIrisFirst,
+	"shutter": hikvision.ExposureShutterFirst,
+	"gain":    hikvision.ExposureGainFirst,
+	"manual":  hikvision.ExposureManual,
+}
+
+func exposureSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	e, err := cam.GetExposure(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["exposure"]; ok {
+			if e.Mode, ok = exposureModes[strings.ToLower(v)]; !ok {
+				return fmt.Errorf("exposure=%s: must be auto, iris, shutter, gain, or manual", v)
+			}
+		}
+		if v, ok := set["shutter"]; ok {
+			if _, err := strconv.Atoi(strings.TrimPrefix(v, "1/")); err != nil {
+				return fmt.Errorf("shutter=%s: want a shutter time such as 1/25", v)
+			}
+			e.Shutter = v
+		}
+		if v, ok := set["gain"]; ok {
+			if e.Gain, err = level("gain", v); err != nil {
+				return err
+			}
+		}
+		if err := cam.SetExposure(ctx, channel, e); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "exposure: %s\n", nameOf(exposureModes, e.Mode))
+	if e.Shutter != "" {
+		fmt.Fprintf(w, "shutter: %s\n", e.Shutter)
+	}
+	if e.Gain >= 0 {
+		fmt.Fprintf(w, "gain: %d\n", e.Gain)
+	}
+	return nil
+}
+
+// wdrModes maps the wdr= values of the image action to WDR modes.
+var wdrModes = map[string]hikvision.WDRMode{
+	"on":   hikvision.WDROn,
+	"off":  hikvision.WDROff,
+	"auto": hikvision.WDRAuto,
+}
+
+func wdrSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	wdr, err := cam.GetWDR(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["wdr"]; ok {
+			if wdr.Mode, ok = wdrModes[strings.ToLower(v)]; !ok {
+				return fmt.Errorf("wdr=%s: must be on, off, or auto", v)
+			}
+		}
+		if v, ok := set["wdr-level"]; ok {
+			if wdr.Level, err = level("wdr-level", v); err != nil {
+				return err
+			}
+		}
+		if err := cam.SetWDR(ctx, channel, wdr); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "wdr: %s\n", nameOf(wdrModes, wdr.Mode))
+	fmt.Fprintf(w, "wdr-level: %d\n", wdr.Level)
+	return nil
+}
+
+// blcAreas maps the blc= values of the image action to BLC areas.
+var blcAreas = map[string]hikvision.BLCArea{
+	"up":     hikvision.BLCUp,
+	"down":   hikvision.BLCDown,
+	"left":   hikvision.BLCLeft,
+	"right":  hikvision.BLCRight,
+	"center": hikvision.BLCCenter,
+}
+
+func blcSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	blc, err := cam.GetBLC(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if v, ok := set["blc"]; ok {
+		if strings.ToLower(v) == "off" {
+			blc.Enabled = false
+		} else if blc.Area, ok = blcAreas[strings.ToLower(v)]; ok {
+			blc.Enabled = true
+		} else {
+			return fmt.Errorf("blc=%s: must be off, up, down, left, right, or center", v)
+		}
+		if err := cam.SetBLC(ctx, channel, blc); err != nil {
+			return err
+		}
+	}
+	area := "off"
+	if blc.Enabled {
+		area = nameOf(blcAreas, blc.Area)
+	}
+	fmt.Fprintf(w, "blc: %s\n", area)
+	return nil
+}
+
+func hlcSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	hlc, err := cam.GetHLC(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["hlc"]; ok {
+			if hlc.Enabled, err = onOff("hlc", v); err != nil {
+				return err
+			}
+		}
+		if v, ok := set["hlc-level"]; ok {
+			if hlc.Level, err = level("hlc-level", v); err != nil {
+				return err
+			}
+		}
+		if err := cam.SetHLC(ctx, channel, hlc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "hlc: %s\n", onOffName(hlc.Enabled))
+	fmt.Fprintf(w, "hlc-level: %d\n", hlc.Level)
+	return nil
+}
+
+// dnrModes maps the dnr= values of the image action to noise reduction modes.
+var dnrModes = map[string]hikvision.NoiseReductionMode{
+	"off":     hikvision.NoiseReductionOff,
+	"general": hikvision.NoiseReductionGeneral,
+	"expert":  hikvision.NoiseReductionExpert,
+}
+
+func dnrSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	nr, err := cam.GetNoiseReduction(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["dnr"]; ok {
+			if nr.Mode, ok = dnrModes[strings.ToLower(v)]; !ok {
+				return fmt.Errorf("dnr=%s: must be off, general, or expert", v)
+			}
+		}
+		// A block is only added when one of its levels is set, so that the
+		// camera is not sent zeros it never reported.
+		_, general := set["dnr-level"]
+		_, spatial := set["dnr-spatial"]
+		_, temporal := set["dnr-temporal"]
+		if general && nr.General == nil {
+			nr.General = &hikvision.GeneralNoiseReduction{}
+		}
+		if (spatial || temporal) && nr.Expert == nil {
+			nr.Expert = &hikvision.ExpertNoiseReduction{}
+		}
+		levels := map[string]*int{}
+		if nr.General != nil {
+			levels["dnr-level"] = &nr.General.Level
+		}
+		if nr.Expert != nil {
+			levels["dnr-spatial"], levels["dnr-temporal"] = &nr.Expert.SpatialLevel, &nr.Expert.TemporalLevel
+		}
+		for k, p := range levels {
+			if v, ok := set[k]; ok {
+				if *p, err = level(k, v); err != nil {
+					return err
+				}
+			}
+		}
+		if err := cam.SetNoiseReduction(ctx, channel, nr); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "dnr: %s\n", nameOf(dnrModes, nr.Mode))
+	switch nr.Mode {
+	case hikvision.NoiseReductionGeneral:
+		if nr.General != nil {
+			fmt.Fprintf(w, "dnr-level: %d\n", nr.General.Level)
+		}
+	case hikvision.NoiseReductionExpert:
+		if nr.Expert != nil {
+			fmt.Fprintf(w, "dnr-spatial: %d\n", nr.Expert.SpatialLevel)
+			fmt.Fprintf(w, "dnr-temporal: %d\n", nr.Expert.TemporalLevel)
+		}
+	}
+	return nil
+}
+
+// whiteBalanceModes maps the wb= values of the image action to white balance
+// modes.
+var whiteBalanceModes = map[string]hikvision.WhiteBalanceMode{
+	"auto":         hikvision.WhiteBalanceAuto,
+	"manual":       hikvision.WhiteBalanceManual,
+	"locked":       hikvision.WhiteBalanceLocked,
+	"indoor":       hikvision.WhiteBalanceIndoor,
+	"outdoor":      hikvision.WhiteBalanceOutdoor,
+	"fluorescent":  hikvision.WhiteBalanceFluorescent,
+	"incandescent": hikvision.WhiteBalanceIncandescent,
+	"warm":         hikvision.WhiteBalanceWarmLight,
+	"natural":      hikvision.WhiteBalanceNaturalLight,
+	"sodium":       hikvision.WhiteBalanceSodiumLamp,
+	"mercury":      hikvision.WhiteBalanceMercuryLamp,
+}
+
+func whiteBalanceSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	wb, err := cam.GetWhiteBalance(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["wb"]; ok {
+			if wb.Mode, ok = whiteBalanceModes[strings.ToLower(v)]; !ok {
+				return fmt.Errorf("wb=%s: must be auto, manual, locked, indoor, outdoor, fluorescent, incandescent, warm, natural, sodium, or mercury", v)
+			}
+		}
+		_, red := set["wb-red"]
+		_, blue := set["wb-blue"]
+		if red || blue {
+			// Channel gains only apply in manual mode.
+			wb.Mode = hikvision.WhiteBalanceManual
+		}
+		gains := map[string]**int{"wb-red": &wb.Red, "wb-blue": &wb.Blue}
+		for k, p := range gains {
+			if v, ok := set[k]; ok {
+				n, err := level(k, v)
+				if err != nil {
+					return err
+				}
+				*p = &n
+			}
+		}
+		if err := cam.SetWhiteBalance(ctx, channel, wb); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "wb: %s\n", nameOf(whiteBalanceModes, wb.Mode))
+	if wb.Mode == hikvision.WhiteBalanceManual {
+		fmt.Fprintf(w, "wb-red: %s\n", gain(wb.Red))
+		fmt.Fprintf(w, "wb-blue: %s\n", gain(wb.Blue))
+	}
+	return nil
+}
+
+// gain formats a white balance gain, "-" when the camera reports none.
+func gain(g *int) string {
+	if g == nil {
+		return "-"
+	}
+	return strconv.Itoa(*g)
+}
+
+// flipStyles maps the flip= values of the image action to flip styles.
+var flipStyles = map[string]hikvision.FlipStyle{
+	"mirror":     hikvision.FlipMirror,
+	"upsidedown": hikvision.FlipUpsideDown,
+	"180":        hikvision.FlipRotate180,
+}
+
+func flipSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	f, err := cam.GetImageFlip(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if v, ok := set["flip"]; ok {
+		if strings.ToLower(v) == "off" {
+			f.Enabled = false
+		} else if f.Style, ok = flipStyles[strings.ToLower(v)]; ok {
+			f.Enabled = true
+		} else {
+			return fmt.Errorf("flip=%s: must be off, mirror, upsidedown, or 180", v)
+		}
+		if err := cam.SetImageFlip(ctx, channel, f); err != nil {
+			return err
+		}
+	}
+	style := "off"
+	if f.Enabled {
+		style = nameOf(flipStyles, f.Style)
+	}
+	fmt.Fprintf(w, "flip: %s\n", style)
+	return nil
+}
+
+func corridorSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	if v, ok := set["corridor"]; ok {
+		on, err := onOff("corridor", v)
+		if err != nil {
+			return err
+		}
+		if err := cam.SetCorridorMode(ctx, channel, on); err != nil {
+			return err
+		}
+	}
+	on, err := cam.GetCorridorMode(ctx, channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "corridor: %s\n", onOffName(on))
+	return nil
+}
+
+// defogModes maps the defog= values of the image action to defog modes.
+var defogModes = map[string]hikvision.DefogMode{
+	"on":   hikvision.DefogOn,
+	"off":  hikvision.DefogOff,
+	"auto": hikvision.DefogAuto,
+}
+
+func defogSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	d, err := cam.GetDefog(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if v, ok := set["defog"]; ok {
+			if d.Mode, ok = defogModes[strings.ToLower(v)]; !ok {
+				return fmt.Errorf("defog=%s: must be on, off, or auto", v)
+			}
+		}
+		if v, ok := set["defog-level"]; ok {
+			if d.Level, err = level("defog-level", v); err != nil {
+				return err
+			}
+		}
+		if err := cam.SetDefog(ctx, channel, d); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "defog: %s\n", nameOf(defogModes, d.Mode))
+	if d.Mode == hikvision.DefogOn {
+		fmt.Fprintf(w, "defog-level: %d\n", d.Level)
+	}
+	return nil
+}
+
+func eisSettings(ctx context.Context, cam *hikvision.Camera, channel int, set map[string]string, w io.Writer) error {
+	if v, ok := set["eis"]; ok {
+		on, err := onOff("eis", v)
+		if err != nil {
+			return err
+		}
+		if err := cam.SetEIS(ctx, channel, on); err != nil {
+			return err
+		}
+	}
+	on, err := cam.GetEIS(ctx, channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "eis: %s\n", onOffName(on))
+	return nil
+}
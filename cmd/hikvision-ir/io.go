@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// output lists the alarm outputs, or drives one: "output 1 on", "output 1
+// off", or "output 1 pulse 5s".
+func output(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	if len(positional) == 0 {
+		outputs, err := cam.GetAlarmOutputs(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range outputs {
+			fmt.Fprintf(w, "output %d: %s\n", o.ID, onOffName(o.Active))
+		}
+		return nil
+	}
+
+	id, err := strconv.Atoi(positional[0])
+	if err != nil || id < 1 || len(positional) < 2 {
+		return fmt.Errorf("output needs <id> on|off|pulse <duration>, e.g. output 1 pulse 5s")
+	}
+	switch positional[1] {
+	case "on", "off":
+		on := positional[1] == "on"
+		if err := cam.SetAlarmOutput(ctx, id, on); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "output %d: %s\n", id, onOffName(on))
+	case "pulse":
+		if len(positional) != 3 {
+			return fmt.Errorf("output pulse needs a duration, e.g. output %d pulse 5s", id)
+		}
+		d, err := time.ParseDuration(positional[2])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid pulse duration %q", positional[2])
+		}
+		if err := cam.PulseAlarmOutput(ctx, id, d); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "output %d: pulsed for %s\n", id, d)
+	default:
+		return fmt.Errorf("unknown output command %q — must be on, off, or pulse", positional[1])
+	}
+	return nil
+}
+
+// inputTypes maps the type= values of the input action to triggering levels.
+var inputTypes = map[string]string{
+	"no": hikvision.TriggerLow,
+	"nc": hikvision.TriggerHigh,
+}
+
+// input lists the alarm inputs, or configures one with key=value settings:
+// "input 1 enabled=on type=nc name=garden-pir".
+func input(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	inputs, err := cam.GetAlarmInputs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		for _, in := range inputs {
+			printInput(w, in)
+		}
+		return nil
+	}
+
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return fmt.Errorf("input needs <id> [key=value ...], e.g. input 1 type=nc")
+	}
+	var in *hikvision.AlarmInput
+	for i := range inputs {
+		if inputs[i].ID == id {
+			in = &inputs[i]
+		}
+	}
+	if in == nil {
+		return fmt.Errorf("no alarm input %d", id)
+	}
+	set, err := parseSettings(positional[1:])
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		var ok bool
+		switch k {
+		case "enabled":
+			in.Enabled, err = onOff(k, v)
+		case "type":
+			if in.Triggering, ok = inputTypes[strings.ToLower(v)]; !ok {
+				err = fmt.Errorf("type=%s: must be no (normally open) or nc (normally closed)", v)
+			}
+		case "name":
+			in.Name = v
+		default:
+			return fmt.Errorf("unknown input setting %q", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetAlarmInput(ctx, in); err != nil {
+			return err
+		}
+	}
+	printInput(w, *in)
+	return nil
+}
+
+func printInput(w io.Writer, in hikvision.AlarmInput) {
+	state := "inactive"
+	if in.Active {
+		state = "active"
+	}
+	name := ""
+	if in.Name != "" {
+		name = " (" + in.Name + ")"
+	}
+	fmt.Fprintf(w, "input %d%s: enabled %s, type %s, %s\n", in.ID, name, onOffName(in.Enabled), nameOf(inputTypes, in.Triggering), state)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service name camera passwords are stored under in
+// the OS keyring.
+const keyringService = "hikvision-ir"
+
+// errNotInKeyring is returned by keyringGet when no password is stored for
+// the account.
+var errNotInKeyring = errors.New("not in keyring")
+
+// keyringGet returns the password stored for account. It uses the macOS
+// keychain through security(1) and the Secret Service (GNOME Keyring, KWallet)
+// through secret-tool(1) elsewhere.
+func keyringGet(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case "windows", "plan9":
+		return "", fmt.Errorf("no OS keyring support on %s", runtime.GOOS)
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		// security exits with 44 and secret-tool with 1 when the item
+		// does not exist; both say nothing else then.
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && !strings.Contains(msg, "could not be found") {
+			return "", fmt.Errorf("keyring: %s", msg)
+		}
+		return "", errNotInKeyring
+	case err != nil:
+		return "", fmt.Errorf("keyring: %w", err)
+	}
+	pass := strings.TrimSuffix(string(out), "\n")
+	if pass == "" {
+		return "", errNotInKeyring
+	}
+	return pass, nil
+}
+
+// keyringSet stores password for account, replacing any stored before.
+func keyringSet(account, password string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security only takes the password as an argument.
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", account, "-l", keyringService+" "+account, "-w", password)
+	case "windows", "plan9":
+		return fmt.Errorf("no OS keyring support on %s", runtime.GOOS)
+	default:
+		cmd = exec.Command("secret-tool", "store", "--label", keyringService+" "+account, "service", keyringService, "account", account)
+		cmd.Stdin = strings.NewReader(password)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("keyring: %s", msg)
+		}
+		return fmt.Errorf("keyring: %w", err)
+	}
+	return nil
+}
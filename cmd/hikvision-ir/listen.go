@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// listenEvents runs an alarm receiver on addr until ctx is cancelled. Cameras
+// pointed at it with alarmhost push their events, which are printed to w,
+// notified and, if mc names a broker, published to
+//
+//	hikvision/<camera>/event/<type>   the event as JSON
+//
+// Events are attributed to the target whose host matches the address in the
+// event, or the address it came from; others go by that address.
+func listenEvents(ctx context.Context, addr string, targets []target, mc mqttConfig, notify *notifier, w io.Writer) error {
+	names := make(map[string]string)
+	for _, t := range targets {
+		if host, _, err := hikvision.SplitHost(t.cam.Host); err == nil {
+			names[host] = t.name
+		}
+	}
+	var client mqtt.Client
+	if mc.Broker != "" {
+		opts, err := mc.clientOptions("hikvision-ir-listen")
+		if err != nil {
+			return err
+		}
+		client = mqtt.NewClient(opts)
+		if err := wait(client.Connect()); err != nil {
+			return fmt.Errorf("mqtt connect %s: %w", mc.Broker, err)
+		}
+		defer client.Disconnect(250)
+	}
+
+	var mu sync.Mutex
+	receiver := hikvision.NewEventReceiver(func(r *http.Request, e hikvision.Event) {
+		if e.Heartbeat() {
+			return
+		}
+		name := names[e.IPAddress]
+		if name == "" {
+			name = names[hostOnly(r.RemoteAddr)]
+		}
+		if name == "" {
+			name = e.IPAddress
+		}
+		if name == "" {
+			name = hostOnly(r.RemoteAddr)
+		}
+		notify.event(ctx, name, e, nil)
+		mu.Lock()
+		printEvent(w, name, e)
+		mu.Unlock()
+		if client != nil {
+			payload, _ := json.Marshal(e)
+			topic := fmt.Sprintf("%s/%s/event/%s", mc.prefix(), name, e.Type)
+			if err := wait(client.Publish(topic, mc.QoS, false, payload)); err != nil {
+				slog.Warn("mqtt: publish failed", "topic", topic, "err", err)
+			}
+		}
+	})
+	srv := &http.Server{Addr: addr, Handler: receiver}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	slog.Info("listening for camera notifications", "cameras", len(targets), "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// hostOnly returns the host of a request's RemoteAddr.
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// logTypes maps CLI names to the major log types.
+var logTypes = map[string]hikvision.LogType{
+	"alarm":       hikvision.LogAlarm,
+	"exception":   hikvision.LogException,
+	"operation":   hikvision.LogOperation,
+	"information": hikvision.LogInformation,
+}
+
+// cameraLogs prints the entries of the camera log between --from and --to,
+// as a table or with --json one JSON object per line. positional filters
+// the entries by major type, such as operation, or minor type, such as
+// motionStart; an entry is printed if it matches any of them.
+func cameraLogs(ctx context.Context, cam *hikvision.Camera, name string, a actionArgs, w io.Writer) error {
+	now := time.Now()
+	from, err := parseWhen(a.from, now.Add(-24*time.Hour), now)
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	to, err := parseWhen(a.to, now, now)
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if !to.After(from) {
+		return fmt.Errorf("--to must be after --from")
+	}
+
+	search := hikvision.LogSearch{From: from, To: to}
+	var majors []hikvision.LogType
+	var minors []string
+	for _, f := range a.positional {
+		if t, ok := logTypes[strings.ToLower(f)]; ok {
+			majors = append(majors, t)
+		} else {
+			minors = append(minors, f)
+		}
+	}
+	// A single major type is left to the camera to filter.
+	if len(majors) == 1 && len(minors) == 0 {
+		search.Type = majors[0]
+	}
+	entries, err := cam.SearchLogs(ctx, search)
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(e hikvision.LogEntry) bool {
+		if len(majors) == 0 && len(minors) == 0 {
+			return false
+		}
+		return !slices.Contains(majors, e.Type) && !slices.ContainsFunc(minors, func(m string) bool {
+			return strings.EqualFold(m, e.Minor)
+		})
+	})
+
+	if a.json {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(struct {
+				Camera string `json:"camera"`
+				hikvision.LogEntry
+			}{name, e}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "logs: none found")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tMINOR\tCHANNEL\tUSER\tADDRESS\tINFO")
+	for _, e := range entries {
+		channel := "-"
+		if e.Channel > 0 {
+			channel = strconv.Itoa(e.Channel)
+		}
+		info := strings.Join(strings.Fields(e.Info), " ")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Time.Format(time.DateTime), dash(string(e.Type)), dash(e.Minor),
+			channel, dash(e.User), dash(e.Address), info)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	goimage "image" // image is the image action
+	"image/color"
+	"image/jpeg"
+)
+
+// lumaStats are brightness statistics of a snapshot, each a luminance from
+// 0 (black) to 255 (white).
+type lumaStats struct {
+	mean   float64
+	median float64
+	// p10 and p90 are the luminance below which 10% and 90% of the
+	// pixels fall: the shadows and the highlights.
+	p10, p90 float64
+}
+
+// lumaStatNames are the names of the statistics that get picks.
+var lumaStatNames = []string{"mean", "median", "p10", "p90"}
+
+// get returns the statistic named name, one of lumaStatNames.
+func (s lumaStats) get(name string) float64 {
+	switch name {
+	case "median":
+		return s.median
+	case "p10":
+		return s.p10
+	case "p90":
+		return s.p90
+	}
+	return s.mean
+}
+
+// snapshotLuma returns the brightness statistics of a JPEG snapshot.
+func snapshotLuma(data []byte) (lumaStats, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return lumaStats{}, fmt.Errorf("decode snapshot: %w", err)
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return lumaStats{}, fmt.Errorf("empty snapshot")
+	}
+	var hist [256]int
+	// Camera JPEGs decode to YCbCr, whose Y plane is the luminance.
+	if y, ok := img.(*goimage.YCbCr); ok {
+		for row := b.Min.Y; row < b.Max.Y; row++ {
+			i := y.YOffset(b.Min.X, row)
+			for _, v := range y.Y[i : i+b.Dx()] {
+				hist[v]++
+			}
+		}
+	} else {
+		for row := b.Min.Y; row < b.Max.Y; row++ {
+			for col := b.Min.X; col < b.Max.X; col++ {
+				hist[color.GrayModel.Convert(img.At(col, row)).(color.Gray).Y]++
+			}
+		}
+	}
+
+	n := b.Dx() * b.Dy()
+	// quantile returns the luminance below which the fraction q of the
+	// pixels fall.
+	quantile := func(q float64) float64 {
+		seen := 0
+		for v, c := range hist {
+			if seen += c; float64(seen) >= q*float64(n) {
+				return float64(v)
+			}
+		}
+		return 255
+	}
+	sum := 0
+	for v, c := range hist {
+		sum += v * c
+	}
+	return lumaStats{
+		mean:   float64(sum) / float64(n),
+		median: quantile(0.5),
+		p10:    quantile(0.1),
+		p90:    quantile(0.9),
+	}, nil
+}
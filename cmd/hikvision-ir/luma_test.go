@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	goimage "image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"testing"
+)
+
+func TestSnapshotLuma(t *testing.T) {
+	black, white := color.Gray{Y: 0}, color.Gray{Y: 255}
+	tests := []struct {
+		name        string
+		left, right color.Gray
+		want        lumaStats
+	}{
+		{name: "black", left: black, right: black, want: lumaStats{mean: 0, median: 0, p10: 0, p90: 0}},
+		{name: "white", left: white, right: white, want: lumaStats{mean: 255, median: 255, p10: 255, p90: 255}},
+		{name: "half and half", left: black, right: white, want: lumaStats{mean: 127.5, median: 0, p10: 0, p90: 255}},
+	}
+	// Camera snapshots decode to YCbCr; grayscale JPEGs take the
+	// color-model fallback.
+	encodings := []struct {
+		name string
+		img  func(b goimage.Rectangle) draw.Image
+	}{
+		{name: "ycbcr", img: func(b goimage.Rectangle) draw.Image { return goimage.NewRGBA(b) }},
+		{name: "gray", img: func(b goimage.Rectangle) draw.Image { return goimage.NewGray(b) }},
+	}
+	for _, tt := range tests {
+		for _, enc := range encodings {
+			t.Run(tt.name+"/"+enc.name, func(t *testing.T) {
+				b := goimage.Rect(0, 0, 64, 64)
+				img := enc.img(b)
+				draw.Draw(img, goimage.Rect(0, 0, 32, 64), goimage.NewUniform(tt.left), goimage.Point{}, draw.Src)
+				draw.Draw(img, goimage.Rect(32, 0, 64, 64), goimage.NewUniform(tt.right), goimage.Point{}, draw.Src)
+				var buf bytes.Buffer
+				if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ycbcr := decoded.(*goimage.YCbCr); ycbcr != (enc.name == "ycbcr") {
+					t.Fatalf("snapshot decodes to %T", decoded)
+				}
+
+				got, err := snapshotLuma(buf.Bytes())
+				if err != nil {
+					t.Fatalf("snapshotLuma: %v", err)
+				}
+				// JPEG rounding and ringing at the edge shift values a little.
+				for _, name := range lumaStatNames {
+					if g, w := got.get(name), tt.want.get(name); math.Abs(g-w) > 3 {
+						t.Errorf("%s = %.1f, want %.1f", name, g, w)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSnapshotLumaNotJPEG(t *testing.T) {
+	if _, err := snapshotLuma([]byte("<html>login</html>")); err == nil {
+		t.Error("snapshotLuma(html) succeeded, want a decode error")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// luxSensorConfig is the sensor of the auto_lux section: an external light
+// sensor whose readings, in lux, switch every camera at once. The reading is
+// a plain number, or with json the field of a JSON object holding it:
+//
+//	sensor:
+//	  mqtt_topic: garden/sensor/illuminance   # on the broker of the mqtt section
+//	  # url: http://192.168.1.20/api/states/sensor.garden_lux   # polled every interval
+//	  # headers: {Authorization: "Bearer ..."}
+//	  # json: state                            # nested fields as attributes.lux
+type luxSensorConfig struct {
+	MQTTTopic string            `yaml:"mqtt_topic"`
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	JSON      string            `yaml:"json"`
+}
+
+func (s *luxSensorConfig) set() bool {
+	return s.MQTTTopic != "" || s.URL != ""
+}
+
+// sensorClient fetches the readings of url sensors.
+var sensorClient = &http.Client{Timeout: 10 * time.Second}
+
+// readings returns the readings of the sensor until ctx is cancelled: each
+// message on the topic, or the url polled every interval. Readings that
+// cannot be parsed are logged and dropped.
+func (s *luxSensorConfig) readings(ctx context.Context, mc mqttConfig, interval time.Duration) (<-chan float64, error) {
+	ch := make(chan float64, 1)
+	send := func(payload []byte) {
+		lux, err := parseLux(payload, s.JSON)
+		if err != nil {
+			slog.Warn("auto-lux: sensor", "err", err)
+			return
+		}
+		select {
+		case ch <- lux:
+		default: // the last reading is still unread: drop it for this one
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- lux
+		}
+	}
+
+	if s.MQTTTopic != "" {
+		if mc.Broker == "" {
+			return nil, fmt.Errorf("auto_lux sensor mqtt_topic needs a broker (--mqtt-broker or mqtt.broker in --config)")
+		}
+		opts, err := mc.clientOptions("hikvision-ir-auto-lux")
+		if err != nil {
+			return nil, err
+		}
+		// Subscribing on each connect also subscribes again after a
+		// reconnect.
+		opts.SetOnConnectHandler(func(c mqtt.Client) {
+			c.Subscribe(s.MQTTTopic, mc.QoS, func(_ mqtt.Client, m mqtt.Message) {
+				send(m.Payload())
+			})
+		})
+		client := mqtt.NewClient(opts)
+		if err := wait(client.Connect()); err != nil {
+			return nil, fmt.Errorf("mqtt connect %s: %w", mc.Broker, err)
+		}
+		go func() {
+			<-ctx.Done()
+			client.Disconnect(250)
+		}()
+		return ch, nil
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if payload, err := s.fetch(ctx); err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("auto-lux: sensor", "url", s.URL, "err", err)
+				}
+			} else {
+				send(payload)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// fetch returns the body of the sensor url.
+func (s *luxSensorConfig) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := sensorClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return body, nil
+}
+
+// parseLux parses a sensor reading: a number, or with field the number, or
+// numeric string, at that dotted path of a JSON object.
+func parseLux(payload []byte, field string) (float64, error) {
+	text := strings.TrimSpace(string(payload))
+	if field != "" {
+		var v any
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return 0, fmt.Errorf("reading %q: %w", text, err)
+		}
+		for _, key := range strings.Split(field, ".") {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return 0, fmt.Errorf("reading %q has no field %s", text, field)
+			}
+			v = obj[key]
+		}
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case string:
+			text = n
+		default:
+			return 0, fmt.Errorf("reading %q has no number at %s", text, field)
+		}
+	}
+	lux, err := strconv.ParseFloat(text, 64)
+	if err != nil || lux < 0 {
+		return 0, fmt.Errorf("reading %q is not a lux value", text)
+	}
+	return lux, nil
+}
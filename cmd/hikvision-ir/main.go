@@ -0,0 +1,645 @@
+// Command hikvision-ir toggles the IR illuminator on HikVision cameras.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// actionArgs are the action-specific flags.
+type actionArgs struct {
+	action     string
+	mode       string
+	schedule   string
+	stream     string
+	rtspPort   int
+	out        string
+	brightness int
+	body       string
+	file       string
+	plan       bool
+	from, to   string
+	json, csv  bool
+	allLenses  bool
+	// newPass is the new password of passwd.
+	newPass string
+	// positional holds the non-flag arguments after the action, e.g.
+	// "GET /ISAPI/System/status" for "raw GET /ISAPI/System/status".
+	positional []string
+}
+
+func main() {
+	var flags cameraConfig
+	flag.StringVar(&flags.Host, "host", "", "Camera address: IP, hostname, host:port or [IPv6]:port (required without --config)")
+	flag.IntVar(&flags.Port, "port", 0, "Camera HTTP(S) port, overriding any port in --host")
+	flag.StringVar(&flags.User, "user", "admin", "Camera username")
+	flag.StringVar(&flags.Pass, "pass", "", "Camera password (default from --pass-env, --pass-file or the keyring)")
+	flag.StringVar(&flags.PassEnv, "pass-env", "", "Environment variable holding the camera password")
+	flag.StringVar(&flags.PassFile, "pass-file", "", "File holding the camera password")
+	flag.StringVar(&flags.Scheme, "scheme", "http", "Protocol: http | https")
+	flag.BoolVar(&flags.Insecure, "insecure", false, "Skip HTTPS certificate verification")
+	flag.StringVar(&flags.CAFile, "ca-file", "", "PEM file of CA certificates to trust for HTTPS")
+	flag.StringVar(&flags.Pin, "pin", "", "Hex SHA-256 fingerprint of the camera certificate to trust for HTTPS")
+	flag.IntVar(&flags.Channel, "channel", 1, "Video channel for IR, image and streaming actions (camera number behind an NVR)")
+	flag.BoolVar(&flags.NVR, "nvr", false, "--host is an NVR; reach the camera on --channel through it")
+	flag.StringVar(&flags.Auth, "auth", "auto", "Authentication: auto | digest | basic | session")
+	flag.StringVar(&flags.Format, "format", "auto", "ISAPI body format where firmware offers JSON: auto | xml | json")
+	flag.StringVar(&flags.Proxy, "proxy", "", "HTTP or SOCKS5 proxy URL, e.g. socks5://127.0.0.1:1080 (default from HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	flag.StringVar(&flags.Protocol, "protocol", "auto", "IR control protocol: auto | legacy | onvif")
+
+	var args actionArgs
+	flag.StringVar(&args.action, "action", "", "Deprecated: the command to run; give it as the first argument instead")
+	flag.StringVar(&args.mode, "mode", "", "Mode for daynight (day | night | auto | schedule), light (ir | white | mixed | off), or factory-reset (basic | full)")
+	flag.IntVar(&args.brightness, "brightness", -1, "Illuminator brightness 0-100 for on, auto, and light (-1 leaves unchanged)")
+	flag.StringVar(&args.schedule, "schedule", "", "Daytime window for --mode schedule, e.g. 07:00:00-18:00:00")
+	flag.StringVar(&args.stream, "stream", "main", "Stream for stream and probe: main | sub | third | <number>")
+	flag.IntVar(&args.rtspPort, "rtsp-port", hikvision.DefaultRTSPPort, "Camera RTSP port for probe and stream url")
+	flag.StringVar(&args.out, "out", "-", "Output file for snapshot (- for stdout; a directory with several cameras), directory for config export, cert request and recordings download, or file for diff save")
+
+	configPath := flag.String("config", "", "YAML file of named cameras")
+	cameras := flag.String("camera", "", "Comma-separated camera names from --config")
+	all := flag.Bool("all", false, "Act on every camera in --config")
+	parallel := flag.Int("parallel", 8, "Maximum cameras contacted at once")
+	listen := flag.String("listen", "", "Address for serve (default 127.0.0.1:8080) or listen (default :8080)")
+	interval := flag.Duration("interval", 30*time.Second, "Camera polling interval for serve, mqtt, watchdog, auto-lux and status --watch")
+	watch := flag.Bool("watch", false, "With status, keep polling and print each change of state")
+	watchDayNight := flag.Bool("daynight", false, "With status --watch, also watch the day/night mode")
+	mqttBroker := flag.String("mqtt-broker", "", "MQTT broker URL for mqtt, e.g. tcp://broker.lan:1883")
+	mqttUser := flag.String("mqtt-user", "", "MQTT username")
+	mqttPass := flag.String("mqtt-pass", "", "MQTT password")
+	lat := flag.Float64("lat", 0, "Latitude for schedule and sunset/sunrise rules (overrides schedule.latitude)")
+	lon := flag.Float64("lon", 0, "Longitude for schedule and sunset/sunrise rules, east positive (overrides schedule.longitude)")
+	webhookURL := flag.String("webhook", "", "URL to POST JSON notifications of events, IR changes and unreachable cameras to, besides the webhooks of --config")
+	haDiscovery := flag.Bool("ha-discovery", false, "Publish Home Assistant MQTT discovery payloads in mqtt mode")
+	newPassEnv := flag.String("new-pass-env", "", "Environment variable holding the new password for passwd (default: prompt)")
+	newPassFile := flag.String("new-pass-file", "", "File holding the new password for passwd (default: prompt)")
+	updateConfig := flag.Bool("update-config", false, "After passwd, store the new password in --config for each camera changed; after discover, add the cameras found to --config")
+	wait := flag.Duration("wait", 3*time.Second, "How long discover listens for answers")
+	yes := flag.Bool("yes", false, "Do not ask for confirmation before reboot, factory-reset, firmware upgrade, config import or storage format")
+
+	logLevel := flag.String("log-level", "info", "Log level: error | warn | info | debug (HTTP requests) | trace (HTTP headers and bodies, credentials redacted)")
+	verbose := flag.Bool("v", false, "Same as --log-level debug")
+	veryVerbose := flag.Bool("vvv", false, "Same as --log-level trace")
+
+	var settings clientSettings
+	dump := flag.String("dump", "", "Write every HTTP request and response, XML and JSON indented and credentials redacted, to files in this directory (- for stderr)")
+	flag.BoolVar(&settings.dryRun, "dry-run", false, "Print the requests that would change the camera instead of sending them")
+	flag.DurationVar(&settings.timeout, "timeout", 10*time.Second, "Timeout for each HTTP request (0 disables)")
+	flag.IntVar(&settings.retries, "retries", 0, "Retries after network errors or 5xx responses")
+	flag.DurationVar(&settings.retryDelay, "retry-delay", 500*time.Millisecond, "Initial backoff between retries, doubled each attempt")
+	flag.StringVar(&args.file, "file", "", "Input file for firmware upgrade, config import, diff, and cert install (or a directory of <camera>.crt)")
+	flag.BoolVar(&args.plan, "plan", false, "Only print the changes apply would make")
+	flag.StringVar(&args.from, "from", "", "Start of the recordings or log entries searched: e.g. 2026-10-13 18:00, 18:00 or 2h ago as 2h (default 24h)")
+	flag.StringVar(&args.to, "to", "", "End of the recordings or log entries searched, as for --from (default now)")
+	flag.BoolVar(&args.json, "json", false, "Print logs, thermal readings, counting, heatmap and health as JSON, one per line")
+	flag.BoolVar(&args.csv, "csv", false, "Print counting and heatmap as CSV")
+	flag.BoolVar(&args.allLenses, "all-lenses", false, "Apply on, off, auto and status to the IR of every lens of a multi-sensor camera")
+	flag.StringVar(&args.body, "body", "", "Request body file for raw PUT/POST (- for stdin)")
+	flag.Usage = func() {
+		name := args.action
+		if name == "" {
+			name = commandArg(flag.CommandLine, os.Args[1:])
+		}
+		if irCommands[name] {
+			name = "ir"
+		}
+		if c, ok := lookupCommand(name); ok {
+			printCommandUsage(os.Stderr, flag.CommandLine, c)
+		} else {
+			printUsage(os.Stderr, flag.CommandLine)
+		}
+	}
+	positional := parseInterleaved(flag.CommandLine, os.Args[1:])
+	// --action is the flag of the tool before it had subcommands. Flags it
+	// ignored stay ignored.
+	var set []string
+	if args.action == "" && len(positional) > 0 {
+		args.action, args.positional = positional[0], positional[1:]
+		flag.Visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	} else {
+		args.positional = positional
+	}
+
+	level, err := parseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if *verbose {
+		level = min(level, slog.LevelDebug)
+	}
+	if *veryVerbose {
+		level = hikvision.LevelTrace
+	}
+	slog.SetDefault(newLogger(os.Stderr, level))
+	settings.logger = slog.Default()
+	if *dump != "" {
+		if settings.dump, err = dumpTo(*dump); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch args.action {
+	case "":
+		printUsage(os.Stderr, flag.CommandLine)
+		os.Exit(1)
+	case "help":
+		if len(args.positional) == 0 {
+			printUsage(os.Stdout, flag.CommandLine)
+			return
+		}
+		name := args.positional[0]
+		if irCommands[name] {
+			name = "ir"
+		}
+		c, ok := lookupCommand(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "error: unknown command %q — run hikvision-ir help for the list\n", name)
+			os.Exit(1)
+		}
+		printCommandUsage(os.Stdout, flag.CommandLine, c)
+		return
+	}
+	if err := resolveCommand(&args, set); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.action == "discover" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		path := ""
+		if *updateConfig {
+			path = *configPath
+		}
+		if err := discover(ctx, *wait, args.positional, path, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *configPath == "" && flags.Host == "" && args.action != "login" && args.action != "listen" {
+		fmt.Fprintln(os.Stderr, "error: no camera: give --host, or --config with --camera or --all")
+		os.Exit(1)
+	}
+
+	if longRunning[args.action] && *configPath != "" && *cameras == "" {
+		*all = true
+	}
+	var cfg *fileConfig
+	if *configPath != "" {
+		var err error
+		if cfg, err = loadConfig(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if args.action == "login" {
+		if err := login(context.Background(), flags, cfg, args.positional, settings, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	var targets []target
+	// listen names the cameras it hears from, but needs none.
+	if args.action != "listen" || cfg != nil || flags.Host != "" {
+		if targets, err = selectTargets(flags, cfg, *cameras, *all, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if args.action == "passwd" {
+		if args.newPass, err = newPassword(*newPassEnv, *newPassFile); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if what := destructiveName(args); what != "" && !*yes && !settings.dryRun && !confirm(os.Stdin, os.Stderr, fmt.Sprintf("%s %d camera(s)?", what, len(targets))) {
+		fmt.Fprintln(os.Stderr, "aborted")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// brokerConfig is the mqtt section of --config overridden by the flags.
+	brokerConfig := func() mqttConfig {
+		var mc mqttConfig
+		if cfg != nil {
+			mc = cfg.MQTT
+		}
+		if *mqttBroker != "" {
+			mc.Broker = *mqttBroker
+		}
+		if *mqttUser != "" {
+			mc.Username, mc.Password = *mqttUser, *mqttPass
+		}
+		return mc
+	}
+	var notify *notifier
+	if longRunning[args.action] {
+		sinks, err := loadSinks(cfg, targets, *webhookURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		notify = newNotifier(sinks, targets)
+	}
+	// The schedule section also gives rules the coordinates of sunset and
+	// sunrise.
+	var sc scheduleConfig
+	if cfg != nil {
+		sc = cfg.Schedule
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "lat":
+			sc.Latitude = lat
+		case "lon":
+			sc.Longitude = lon
+		}
+	})
+	switch args.action {
+	case "events":
+		printEvents(ctx, targets, notify, os.Stdout)
+	case "rules":
+		var store snapshotStore
+		if cfg != nil {
+			store = cfg.Snapshots
+		}
+		err = runRules(ctx, targets, sc.Latitude, sc.Longitude, store, notify)
+	case "schedule":
+		err = runSchedule(ctx, sc, targets, *parallel)
+	case "watchdog":
+		var wc watchdogConfig
+		if cfg != nil {
+			wc = cfg.Watchdog
+		}
+		if wc.Interval == 0 {
+			wc.Interval = *interval
+		}
+		err = runWatchdog(ctx, wc, targets, notify)
+	case "auto-lux":
+		var lc autoLuxConfig
+		if cfg != nil {
+			lc = cfg.AutoLux
+		}
+		if lc.Interval == 0 {
+			lc.Interval = *interval
+		}
+		err = runAutoLux(ctx, lc, brokerConfig(), targets, *parallel)
+	case "serve":
+		addr := *listen
+		if addr == "" {
+			addr = "127.0.0.1:8080"
+		}
+		err = serve(ctx, addr, targets, *interval, notify)
+	case "listen":
+		addr := *listen
+		if addr == "" {
+			addr = ":8080"
+		}
+		mc := brokerConfig()
+		err = listenEvents(ctx, addr, targets, mc, notify, os.Stdout)
+	case "mqtt":
+		mc := brokerConfig()
+		if mc.Interval == 0 {
+			mc.Interval = *interval
+		}
+		mc.Discovery = mc.Discovery || *haDiscovery
+		err = runMQTT(ctx, mc, targets, notify)
+	}
+	if args.action == "status" && *watch {
+		err = watchStatus(ctx, targets, *interval, *watchDayNight, os.Stdout)
+	} else if *watch {
+		err = fmt.Errorf("--watch only applies to status")
+	}
+	if longRunning[args.action] || *watch {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if args.action == "diff" {
+		if err := runDiff(ctx, targets, args, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if args.action == "health" {
+		os.Exit(runHealth(ctx, targets, args, *parallel, os.Stdout))
+	}
+
+	if args.action == "passwd" && *updateConfig {
+		err := rotatePasswords(ctx, cfg, targets, args, *parallel, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(targets) == 1 {
+		if err := run(ctx, targets[0], args, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if args.action == "snapshot" && args.out == "-" {
+		fmt.Fprintf(os.Stderr, "error: snapshot of several cameras needs --out <directory>\n")
+		os.Exit(1)
+	}
+	results := runAll(ctx, targets, args, *parallel)
+	if args.json || args.csv {
+		if printRawResults(os.Stdout, os.Stderr, results) {
+			os.Exit(1)
+		}
+		return
+	}
+	if printResults(os.Stdout, results) {
+		os.Exit(1)
+	}
+}
+
+// run performs one action against one camera, writing its output to w.
+func run(ctx context.Context, t target, a actionArgs, w io.Writer) error {
+	cam := t.cam
+	if cam.DryRun != nil {
+		cam.DryRun = w
+	}
+	switch a.action {
+	case "on", "off", "auto", "status":
+		if a.allLenses {
+			return irAllLenses(ctx, cam, a, w)
+		}
+		return irAction(ctx, cam, t.channel, "", a, w)
+
+	case "channels":
+		return channels(ctx, cam, a.positional, w)
+
+	case "daynight":
+		return dayNight(ctx, cam, t.channel, a.mode, a.schedule, w)
+
+	case "light":
+		return light(ctx, cam, t.channel, a.mode, a.brightness, w)
+
+	case "image":
+		return image(ctx, cam, t.channel, imageGroups, a.positional, w)
+
+	case "wdr":
+		return image(ctx, cam, t.channel, imageGroupsNamed("wdr"), a.positional, w)
+
+	case "blc":
+		return image(ctx, cam, t.channel, imageGroupsNamed("blc", "hlc"), a.positional, w)
+
+	case "orientation":
+		return image(ctx, cam, t.channel, imageGroupsNamed("flip", "corridor"), a.positional, w)
+
+	case "osd":
+		return osd(ctx, cam, t.channel, t.name, a.positional, w)
+
+	case "stream":
+		return stream(ctx, cam, t.channel, a, w)
+
+	case "probe":
+		return probe(ctx, cam, t.channel, a, w)
+
+	case "smart":
+		return smart(ctx, cam, t.channel, a.positional, w)
+
+	case "fisheye":
+		return fisheye(ctx, cam, t.channel, a.positional, w)
+
+	case "tamper":
+		return tamper(ctx, cam, t.channel, a.positional, w)
+
+	case "output":
+		return output(ctx, cam, a.positional, w)
+
+	case "input":
+		return input(ctx, cam, a.positional, w)
+
+	case "audio":
+		return audio(ctx, cam, t.channel, a.positional, w)
+
+	case "time":
+		return clock(ctx, cam, a.positional, w)
+
+	case "network":
+		return network(ctx, cam, a.positional, w)
+
+	case "wifi":
+		return wifi(ctx, cam, a.positional, w)
+
+	case "alarmhost":
+		return alarmHost(ctx, cam, a.positional, w)
+
+	case "user":
+		return users(ctx, cam, a.positional, w)
+
+	case "passwd":
+		return passwd(ctx, cam, a.positional, a.newPass, w)
+
+	case "health":
+		return health(ctx, cam, t.name, t.channel, a, w)
+
+	case "audit":
+		return audit(ctx, cam, w)
+
+	case "security":
+		return security(ctx, cam, a.positional, w)
+
+	case "firmware":
+		return firmware(ctx, cam, a.positional, a.file, w)
+
+	case "config":
+		return configBackup(ctx, cam, t.name, a.positional, a.out, a.file, w)
+
+	case "cert":
+		return cert(ctx, cam, t.name, a.positional, a.out, a.file, w)
+
+	case "apply":
+		return applySpec(ctx, t, a.positional, a.plan, w)
+
+	case "snapshot":
+		return snapshot(ctx, cam, t.channel, a.out, w)
+
+	case "ptz":
+		return ptz(ctx, cam, t.channel, a.positional, w)
+
+	case "thermal":
+		return thermal(ctx, cam, t.channel, t.name, a, w)
+
+	case "anpr":
+		return anpr(ctx, cam, t.channel, a.positional, w)
+
+	case "face":
+		return face(ctx, cam, t.channel, a.positional, w)
+
+	case "ftp":
+		return ftp(ctx, cam, a.positional, w)
+
+	case "email":
+		return email(ctx, cam, a.positional, w)
+
+	case "counting":
+		return counting(ctx, cam, t.channel, t.name, a, w)
+
+	case "heatmap":
+		return heatmap(ctx, cam, t.channel, t.name, a, w)
+
+	case "recordings":
+		return recordings(ctx, cam, t.channel, t.name, a, w)
+
+	case "storage":
+		return storage(ctx, cam, a.positional, w)
+
+	case "record":
+		return record(ctx, cam, t.channel, a.positional, w)
+
+	case "logs":
+		return cameraLogs(ctx, cam, t.name, a, w)
+
+	case "info":
+		info, err := cam.GetDeviceInfo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "model: %s\n", info.Model)
+		fmt.Fprintf(w, "serial: %s\n", info.SerialNumber)
+		fmt.Fprintf(w, "firmware: %s %s\n", info.FirmwareVersion, info.FirmwareReleasedDate)
+		fmt.Fprintf(w, "mac: %s\n", info.MACAddress)
+		if ep, err := cam.DetectIREndpoint(ctx); err == nil {
+			fmt.Fprintf(w, "IR endpoint: %s\n", ep)
+		}
+
+	case "raw":
+		return raw(ctx, cam, a.positional, a.body, w)
+
+	case "reboot":
+		if err := cam.Reboot(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "rebooting")
+
+	case "factory-reset":
+		mode := hikvision.FactoryResetMode(a.mode)
+		if mode == "" {
+			mode = hikvision.FactoryResetBasic
+		}
+		if err := cam.FactoryReset(ctx, mode); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "factory reset (%s), rebooting\n", mode)
+
+	default:
+		return fmt.Errorf("unknown action %q", a.action)
+	}
+	return nil
+}
+
+// longRunning lists the actions that run until interrupted. They act on every
+// configured camera unless --camera narrows the set.
+var longRunning = map[string]bool{
+	"serve":    true,
+	"mqtt":     true,
+	"events":   true,
+	"listen":   true,
+	"rules":    true,
+	"schedule": true,
+	"watchdog": true,
+	"auto-lux": true,
+}
+
+// destructive lists the actions, or action subcommands, that ask for
+// confirmation unless --yes is set.
+var destructive = map[string]bool{
+	"reboot":           true,
+	"factory-reset":    true,
+	"firmware upgrade": true,
+	"config import":    true,
+	"storage format":   true,
+}
+
+// destructiveName returns the destructive action or subcommand a runs, or ""
+// if it needs no confirmation.
+func destructiveName(a actionArgs) string {
+	if destructive[a.action] {
+		return a.action
+	}
+	if len(a.positional) > 0 && destructive[a.action+" "+a.positional[0]] {
+		return a.action + " " + a.positional[0]
+	}
+	return ""
+}
+
+// confirm asks a yes/no question on w and reports whether r answered yes.
+func confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// parseInterleaved parses flags that may appear before, between, or after
+// positional arguments, returning the positional arguments in order.
+func parseInterleaved(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseLogLevel parses a --log-level name: a slog level such as "debug" or
+// "warn", or "trace".
+func parseLogLevel(name string) (slog.Level, error) {
+	if strings.EqualFold(name, "trace") {
+		return hikvision.LevelTrace, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q — must be error, warn, info, debug, or trace", name)
+	}
+	return l, nil
+}
+
+// newLogger returns a text logger on w that names hikvision.LevelTrace TRACE.
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 && a.Value.Any() == hikvision.LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}))
+}
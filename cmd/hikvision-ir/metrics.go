@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// metrics holds the Prometheus collectors exported by serve on /metrics.
+type metrics struct {
+	registry  *prometheus.Registry
+	irOn      *prometheus.GaugeVec
+	reachable *prometheus.GaugeVec
+	lastPoll  *prometheus.GaugeVec
+	duration  *prometheus.HistogramVec
+	errors    *prometheus.CounterVec
+	// notify is told the outcome of every poll.
+	notify *notifier
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		irOn: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hikvision_ir_light_on",
+			Help: "1 if the camera's IR illuminator is forced on, 0 if off or in auto mode.",
+		}, []string{"camera"}),
+		reachable: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hikvision_camera_reachable",
+			Help: "1 if the last poll of the camera succeeded.",
+		}, []string{"camera"}),
+		lastPoll: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hikvision_last_poll_timestamp_seconds",
+			Help: "Unix time of the last poll of the camera, successful or not.",
+		}, []string{"camera"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hikvision_request_duration_seconds",
+			Help:    "Latency of ISAPI requests to the camera.",
+			Buckets: []float64{.025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"camera", "method", "path"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hikvision_request_errors_total",
+			Help: "ISAPI requests that failed, by kind: network, or the HTTP status class.",
+		}, []string{"camera", "kind"}),
+	}
+	m.registry.MustRegister(m.irOn, m.reachable, m.lastPoll, m.duration, m.errors)
+	return m
+}
+
+// instrument records the latency and failures of every request a target's
+// camera makes.
+func (m *metrics) instrument(t target) {
+	t.cam.OnRequest = func(info hikvision.RequestInfo) {
+		path, _, _ := strings.Cut(info.Path, "?")
+		m.duration.WithLabelValues(t.name, info.Method, path).Observe(info.Duration.Seconds())
+		switch {
+		case info.Err != nil && !errors.Is(info.Err, context.Canceled):
+			m.errors.WithLabelValues(t.name, "network").Inc()
+		case info.StatusCode >= 500:
+			m.errors.WithLabelValues(t.name, "5xx").Inc()
+		case info.StatusCode >= 400:
+			m.errors.WithLabelValues(t.name, "4xx").Inc()
+		}
+	}
+}
+
+// poll refreshes the per-camera gauges every interval until ctx is cancelled.
+func (m *metrics) poll(ctx context.Context, targets []target, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, t := range targets {
+			m.pollOne(ctx, t)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *metrics) pollOne(ctx context.Context, t target) {
+	mode, err := t.cam.GetIRModeContext(ctx)
+	m.notify.observe(ctx, t.name, mode, err)
+	m.lastPoll.WithLabelValues(t.name).SetToCurrentTime()
+	if err != nil {
+		m.reachable.WithLabelValues(t.name).Set(0)
+		return
+	}
+	m.reachable.WithLabelValues(t.name).Set(1)
+	on := 0.0
+	if mode == hikvision.IRModeOpen {
+		on = 1
+	}
+	m.irOn.WithLabelValues(t.name).Set(on)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// mqttConfig is the mqtt section of a --config file:
+//
+//	mqtt:
+//	  broker: ssl://broker.lan:8883
+//	  username: hikvision
+//	  password: secret
+//	  topic_prefix: hikvision
+//	  interval: 30s
+type mqttConfig struct {
+	// Broker is a paho broker URL: tcp://host:1883, ssl://host:8883, or ws://.
+	Broker   string `yaml:"broker"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	ClientID string `yaml:"client_id"`
+	// Prefix is the first topic level; defaults to "hikvision".
+	Prefix string `yaml:"topic_prefix"`
+	// CAFile and Insecure control certificate verification for ssl:// brokers.
+	CAFile   string `yaml:"ca_file"`
+	Insecure bool   `yaml:"insecure"`
+	// Retain publishes state messages retained so new subscribers see the
+	// current state immediately. Defaults to true.
+	Retain *bool `yaml:"retain"`
+	QoS    byte  `yaml:"qos"`
+	// Interval is how often camera state is polled; defaults to 30s.
+	Interval time.Duration `yaml:"interval"`
+	// Discovery publishes Home Assistant MQTT discovery payloads under
+	// DiscoveryPrefix (default "homeassistant").
+	Discovery       bool   `yaml:"discovery"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+func (c mqttConfig) retain() bool {
+	return c.Retain == nil || *c.Retain
+}
+
+func (c mqttConfig) prefix() string {
+	if c.Prefix == "" {
+		return "hikvision"
+	}
+	return strings.TrimSuffix(c.Prefix, "/")
+}
+
+// clientOptions returns the options to connect to the broker with, as the
+// client ID clientID unless the config sets one.
+func (c mqttConfig) clientOptions(clientID string) (*mqtt.ClientOptions, error) {
+	opts := mqtt.NewClientOptions().
+		AddBroker(c.Broker).
+		SetClientID(c.ClientID).
+		SetUsername(c.Username).
+		SetPassword(c.Password).
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("mqtt: connection lost", "err", err)
+		})
+	if c.ClientID == "" {
+		opts.SetClientID(clientID)
+	}
+	if strings.HasPrefix(c.Broker, "ssl://") || strings.HasPrefix(c.Broker, "tls://") || strings.HasPrefix(c.Broker, "wss://") {
+		tlsOpts, err := tlsOptions(c.Insecure, c.CAFile, "")
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(&tls.Config{RootCAs: tlsOpts.RootCAs, InsecureSkipVerify: tlsOpts.InsecureSkipVerify})
+	}
+	return opts, nil
+}
+
+// bridge mirrors camera IR state to MQTT and applies commands from it.
+//
+// For a camera named front-door and the default prefix it uses:
+//
+//	hikvision/front-door/ir/state      on | off | auto (published, retained)
+//	hikvision/front-door/ir/set        on | off | auto (subscribed)
+//	hikvision/front-door/availability  online | offline (published, retained)
+//	hikvision/status                   online | offline (retained, last will)
+type bridge struct {
+	cfg     mqttConfig
+	client  mqtt.Client
+	targets map[string]target
+	notify  *notifier
+
+	mu        sync.Mutex
+	last      map[string]string // last state published per camera
+	available map[string]string // last availability published per camera
+	announced map[string]bool   // cameras with Home Assistant discovery published
+}
+
+// runMQTT connects to the broker and bridges targets until ctx is cancelled.
+func runMQTT(ctx context.Context, cfg mqttConfig, targets []target, notify *notifier) error {
+	if cfg.Broker == "" {
+		return fmt.Errorf("mqtt needs a broker (--mqtt-broker or mqtt.broker in --config)")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	b := &bridge{
+		cfg:       cfg,
+		notify:    notify,
+		targets:   make(map[string]target),
+		last:      make(map[string]string),
+		available: make(map[string]string),
+		announced: make(map[string]bool),
+	}
+	for _, t := range targets {
+		b.targets[t.name] = t
+	}
+
+	opts, err := cfg.clientOptions("hikvision-ir")
+	if err != nil {
+		return err
+	}
+	opts.SetWill(b.statusTopic(), "offline", cfg.QoS, true).
+		SetOnConnectHandler(b.onConnect)
+
+	b.client = mqtt.NewClient(opts)
+	if err := wait(b.client.Connect()); err != nil {
+		return fmt.Errorf("mqtt connect %s: %w", cfg.Broker, err)
+	}
+	defer b.client.Disconnect(250)
+
+	slog.Info("mqtt: bridging", "cameras", len(targets), "broker", cfg.Broker)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			b.publish(b.statusTopic(), "offline")
+			return nil
+		case <-ticker.C:
+			b.pollAll(ctx)
+		}
+	}
+}
+
+func (b *bridge) statusTopic() string {
+	return b.cfg.prefix() + "/status"
+}
+
+func (b *bridge) topic(name, suffix string) string {
+	return b.cfg.prefix() + "/" + name + "/" + suffix
+}
+
+// onConnect announces the bridge, (re)subscribes to every command topic and
+// publishes the current state of every camera.
+func (b *bridge) onConnect(c mqtt.Client) {
+	b.publish(b.statusTopic(), "online")
+	for name := range b.targets {
+		name := name
+		c.Subscribe(b.topic(name, "ir/set"), b.cfg.QoS, func(_ mqtt.Client, m mqtt.Message) {
+			// Camera requests can be slow; keep paho's delivery goroutine free.
+			go b.command(name, string(m.Payload()))
+		})
+	}
+	if b.cfg.Discovery {
+		b.subscribeHAStatus(c)
+	}
+	b.mu.Lock()
+	// Republish everything after each (re)connect.
+	b.last = make(map[string]string)
+	b.available = make(map[string]string)
+	b.announced = make(map[string]bool)
+	b.mu.Unlock()
+	go b.pollAll(context.Background())
+}
+
+// command applies an IR mode received on a camera's set topic.
+func (b *bridge) command(name, payload string) {
+	t := b.targets[name]
+	mode, ok := irModes[strings.ToLower(strings.TrimSpace(payload))]
+	if !ok {
+		slog.Warn("mqtt: ignoring unknown IR command", "camera", name, "payload", payload)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := t.cam.SetIRModeContext(ctx, mode); err != nil {
+		slog.Error("mqtt: set IR", "camera", name, "mode", mode, "err", err)
+		return
+	}
+	b.poll(ctx, t)
+}
+
+// pollAll refreshes the published state of every camera.
+func (b *bridge) pollAll(ctx context.Context) {
+	for _, t := range b.targets {
+		b.poll(ctx, t)
+	}
+}
+
+// poll reads a camera's IR mode and publishes it, and the camera's
+// availability, if they changed.
+func (b *bridge) poll(ctx context.Context, t target) {
+	if b.cfg.Discovery {
+		b.mu.Lock()
+		announced := b.announced[t.name]
+		b.announced[t.name] = true
+		b.mu.Unlock()
+		if !announced {
+			b.announce(ctx, t)
+		}
+	}
+
+	mode, err := t.cam.GetIRModeContext(ctx)
+	b.notify.observe(ctx, t.name, mode, err)
+	if err != nil {
+		slog.Error("mqtt: poll", "camera", t.name, "err", err)
+		b.publishChanged(b.available, t.name, b.topic(t.name, "availability"), "offline")
+		return
+	}
+	b.publishChanged(b.available, t.name, b.topic(t.name, "availability"), "online")
+	b.publishChanged(b.last, t.name, b.topic(t.name, "ir/state"), irModeName(mode))
+}
+
+// publishChanged publishes payload to topic unless seen[name] already holds it.
+func (b *bridge) publishChanged(seen map[string]string, name, topic, payload string) {
+	b.mu.Lock()
+	changed := seen[name] != payload
+	seen[name] = payload
+	b.mu.Unlock()
+	if changed {
+		b.publish(topic, payload)
+	}
+}
+
+func (b *bridge) publish(topic, payload string) {
+	if err := wait(b.client.Publish(topic, b.cfg.QoS, b.cfg.retain(), payload)); err != nil {
+		slog.Error("mqtt: publish", "topic", topic, "err", err)
+	}
+}
+
+// wait blocks until an MQTT operation completes or times out.
+func wait(t mqtt.Token) error {
+	if !t.WaitTimeout(30 * time.Second) {
+		return fmt.Errorf("timed out")
+	}
+	return t.Error()
+}
+
+// irModeName returns the on/off/auto name of an IR mode used by the CLI and
+// the MQTT topics.
+func irModeName(mode hikvision.IRMode) string {
+	switch mode {
+	case hikvision.IRModeOpen:
+		return "on"
+	case hikvision.IRModeClose:
+		return "off"
+	}
+	return string(mode)
+}
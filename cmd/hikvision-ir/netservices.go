@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// ddnsProviders maps CLI names to DDNS providers.
+var ddnsProviders = map[string]string{
+	"dyndns":   hikvision.DDNSDynDNS,
+	"noip":     hikvision.DDNSNoIP,
+	"hiddns":   hikvision.DDNSHiDDNS,
+	"ipserver": hikvision.DDNSIPServer,
+}
+
+// snmpAuths and snmpPrivacies map CLI names to SNMP v3 methods.
+var (
+	snmpAuths = map[string]string{
+		"md5":  hikvision.SNMPAuthMD5,
+		"sha":  hikvision.SNMPAuthSHA,
+		"none": hikvision.SNMPMethodNone,
+	}
+	snmpPrivacies = map[string]string{
+		"des":  hikvision.SNMPPrivacyDES,
+		"aes":  hikvision.SNMPPrivacyAES,
+		"none": hikvision.SNMPMethodNone,
+	}
+)
+
+// eapMethods maps CLI names to 802.1X EAP methods.
+var eapMethods = map[string]string{
+	"md5":  hikvision.EAPMD5,
+	"tls":  hikvision.EAPTLS,
+	"peap": hikvision.EAPPEAP,
+}
+
+// ddns shows the first dynamic DNS registration or changes it with
+// enabled, provider, server, domain, user and pass settings.
+func ddns(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	list, err := cam.GetDDNS(ctx)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("camera has no DDNS settings")
+	}
+	d := &list[0]
+	if len(set) > 0 {
+		if err := applyDDNSSettings(d, set); err != nil {
+			return err
+		}
+		if err := cam.SetDDNS(ctx, d); err != nil {
+			return err
+		}
+	}
+	printSettings(w, ddnsSettings(*d), "enabled", "provider", "server", "domain", "user")
+	return nil
+}
+
+func applyDDNSSettings(d *hikvision.DDNS, set map[string]string) error {
+	var err error
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			d.Enabled, err = onOff(k, v)
+		case "provider":
+			p, ok := ddnsProviders[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("provider=%s: must be one of %s", v, strings.Join(sortedKeys(ddnsProviders), ", "))
+			}
+			d.Provider = p
+		case "server":
+			d.ServerFormat, d.Server = "hostname", v
+			if net.ParseIP(v) != nil {
+				d.ServerFormat = "ipaddress"
+			}
+		case "domain":
+			d.Domain = v
+		case "user":
+			d.User = v
+		case "pass":
+			d.Password = v
+		default:
+			return fmt.Errorf("unknown ddns setting %q — must be enabled, provider, server, domain, user or pass", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ddnsSettings returns the settings of d keyed like applyDDNSSettings takes
+// them, leaving out the password.
+func ddnsSettings(d hikvision.DDNS) map[string]string {
+	set := map[string]string{"enabled": onOffName(d.Enabled), "server": d.Server, "domain": d.Domain, "user": d.User}
+	if d.Provider != "" {
+		set["provider"] = nameOf(ddnsProviders, d.Provider)
+	}
+	return set
+}
+
+// snmp shows the SNMP agent or changes it with the settings v2c=on|off,
+// read and write (communities), trap=<host>[:port], v3=on|off, user,
+// auth=md5|sha|none, auth-key, privacy=des|aes|none and privacy-key.
+func snmp(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	v2, err := cam.GetSNMPv2c(ctx)
+	if err != nil {
+		return err
+	}
+	v3, err := cam.GetSNMPv3(ctx)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		v2Changed, v3Changed, err := applySNMPSettings(v2, v3, set)
+		if err != nil {
+			return err
+		}
+		if v2Changed {
+			if err := cam.SetSNMPv2c(ctx, v2); err != nil {
+				return err
+			}
+		}
+		if v3Changed {
+			if err := cam.SetSNMPv3(ctx, v3); err != nil {
+				return err
+			}
+		}
+	}
+	printSettings(w, snmpSettings(*v2, *v3), "v2c", "read", "write", "trap", "v3", "user", "auth", "privacy")
+	return nil
+}
+
+// applySNMPSettings applies the settings snmp takes and reports which of
+// v2 and v3 they changed.
+func applySNMPSettings(v2 *hikvision.SNMPv2c, v3 *hikvision.SNMPv3, set map[string]string) (v2Changed, v3Changed bool, err error) {
+	for k, v := range set {
+		switch k {
+		case "v2c":
+			v2.Enabled, err = onOff(k, v)
+		case "read":
+			v2.ReadCommunity = v
+		case "write":
+			v2.WriteCommunity = v
+		case "trap":
+			if v == "" {
+				v2.TrapAddress, v2.TrapPort = "", 0
+			} else {
+				v2.TrapAddress, v2.TrapPort, err = hostPort(k, v, 162)
+			}
+		case "v3":
+			v3.Enabled, err = onOff(k, v)
+		case "user":
+			v3.User = v
+		case "auth":
+			m, ok := snmpAuths[strings.ToLower(v)]
+			if !ok {
+				return false, false, fmt.Errorf("auth=%s: must be md5, sha or none", v)
+			}
+			v3.AuthMethod = m
+		case "auth-key":
+			v3.AuthKey = v
+		case "privacy":
+			m, ok := snmpPrivacies[strings.ToLower(v)]
+			if !ok {
+				return false, false, fmt.Errorf("privacy=%s: must be des, aes or none", v)
+			}
+			v3.PrivacyMethod = m
+		case "privacy-key":
+			v3.PrivacyKey = v
+		default:
+			return false, false, fmt.Errorf("unknown snmp setting %q — must be v2c, read, write, trap, v3, user, auth, auth-key, privacy or privacy-key", k)
+		}
+		if err != nil {
+			return false, false, err
+		}
+		switch k {
+		case "v2c", "read", "write", "trap":
+			v2Changed = true
+		default:
+			v3Changed = true
+		}
+	}
+	return v2Changed, v3Changed, nil
+}
+
+// snmpSettings returns the settings of v2 and v3 keyed like
+// applySNMPSettings takes them, leaving out the keys and a default trap
+// port of 162.
+func snmpSettings(v2 hikvision.SNMPv2c, v3 hikvision.SNMPv3) map[string]string {
+	set := map[string]string{
+		"v2c":   onOffName(v2.Enabled),
+		"read":  v2.ReadCommunity,
+		"write": v2.WriteCommunity,
+		"v3":    onOffName(v3.Enabled),
+		"user":  v3.User,
+	}
+	if v2.TrapAddress != "" && v2.TrapAddress != "0.0.0.0" {
+		set["trap"] = v2.TrapAddress
+		if v2.TrapPort != 162 && v2.TrapPort != 0 {
+			set["trap"] = net.JoinHostPort(v2.TrapAddress, strconv.Itoa(v2.TrapPort))
+		}
+	}
+	if v3.AuthMethod != "" {
+		set["auth"] = nameOf(snmpAuths, v3.AuthMethod)
+	}
+	if v3.PrivacyMethod != "" {
+		set["privacy"] = nameOf(snmpPrivacies, v3.PrivacyMethod)
+	}
+	return set
+}
+
+// dot1x shows the 802.1X settings of interface 1 or changes them with
+// enabled, protocol=md5|tls|peap, eapol=1|2, user and pass settings.
+func dot1x(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	x, err := cam.GetIEEE8021X(ctx, 1)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if err := applyDot1XSettings(x, set); err != nil {
+			return err
+		}
+		if err := cam.SetIEEE8021X(ctx, 1, x); err != nil {
+			return err
+		}
+	}
+	printSettings(w, dot1xSettings(*x), "enabled", "protocol", "eapol", "user")
+	return nil
+}
+
+func applyDot1XSettings(x *hikvision.IEEE8021X, set map[string]string) error {
+	var err error
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			x.Enabled, err = onOff(k, v)
+		case "protocol":
+			p, ok := eapMethods[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("protocol=%s: must be md5, tls or peap", v)
+			}
+			x.Protocol = p
+		case "eapol":
+			if v != "1" && v != "2" {
+				return fmt.Errorf("eapol=%s: must be 1 or 2", v)
+			}
+			x.EAPOLVersion, _ = strconv.Atoi(v)
+		case "user":
+			x.User = v
+		case "pass":
+			x.Password = v
+		default:
+			return fmt.Errorf("unknown 802.1x setting %q — must be enabled, protocol, eapol, user or pass", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dot1xSettings returns the settings of x keyed like applyDot1XSettings
+// takes them, leaving out the password.
+func dot1xSettings(x hikvision.IEEE8021X) map[string]string {
+	set := map[string]string{"enabled": onOffName(x.Enabled), "user": x.User}
+	if x.Protocol != "" {
+		set["protocol"] = nameOf(eapMethods, x.Protocol)
+	}
+	if x.EAPOLVersion != 0 {
+		set["eapol"] = strconv.Itoa(x.EAPOLVersion)
+	}
+	return set
+}
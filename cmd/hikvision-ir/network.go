@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// network lists the camera's network interfaces or, with "network set [id]
+// key=value ...", changes the addressing of one (interface 1 by default).
+// "network ports", "network upnp", "network ipfilter", "network ddns",
+// "network snmp" and "network 8021x" show and change the service ports,
+// UPnP, the IP address filter, dynamic DNS, the SNMP agent and 802.1X.
+func network(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "set":
+		case "ports":
+			return servicePorts(ctx, cam, positional[1:], w)
+		case "upnp":
+			return upnp(ctx, cam, positional[1:], w)
+		case "ipfilter":
+			return ipFilter(ctx, cam, positional[1:], w)
+		case "ddns":
+			return ddns(ctx, cam, positional[1:], w)
+		case "snmp":
+			return snmp(ctx, cam, positional[1:], w)
+		case "8021x":
+			return dot1x(ctx, cam, positional[1:], w)
+		default:
+			return fmt.Errorf("unknown network command %q — must be set, ports, upnp, ipfilter, ddns, snmp or 8021x", positional[0])
+		}
+	}
+	interfaces, err := cam.GetNetworkInterfaces(ctx)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		for _, n := range interfaces {
+			printInterface(w, n)
+		}
+		return nil
+	}
+
+	args, id := positional[1:], 1
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		if id, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid interface id %q", args[0])
+		}
+		args = args[1:]
+	}
+	var n *hikvision.NetworkInterface
+	for i := range interfaces {
+		if interfaces[i].ID == id {
+			n = &interfaces[i]
+		}
+	}
+	if n == nil {
+		return fmt.Errorf("no network interface %d", id)
+	}
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	if len(set) == 0 {
+		return fmt.Errorf("network set needs key=value settings")
+	}
+	if err := applyNetworkSettings(&n.IPAddress, set); err != nil {
+		return err
+	}
+	if err := cam.SetNetworkInterface(ctx, n); err != nil {
+		return err
+	}
+	printInterface(w, *n)
+	return nil
+}
+
+// applyNetworkSettings applies ip, mask, gateway, dns (one or two
+// comma-separated servers) and dhcp settings. Setting a static address
+// without dhcp=off implies it.
+func applyNetworkSettings(a *hikvision.IPAddress, set map[string]string) error {
+	for k, v := range set {
+		switch k {
+		case "dhcp":
+			on, err := onOff(k, v)
+			if err != nil {
+				return err
+			}
+			a.AddressingType = hikvision.AddressingStatic
+			if on {
+				a.AddressingType = hikvision.AddressingDHCP
+			}
+		case "ip", "mask", "gateway":
+			if net.ParseIP(v).To4() == nil {
+				return fmt.Errorf("%s=%s: want an IPv4 address", k, v)
+			}
+			switch k {
+			case "ip":
+				a.Address = v
+			case "mask":
+				a.SubnetMask = v
+			case "gateway":
+				a.Gateway = v
+			}
+		case "dns":
+			servers := strings.Split(v, ",")
+			if len(servers) > 2 {
+				return fmt.Errorf("dns=%s: at most two servers", v)
+			}
+			for _, s := range servers {
+				if net.ParseIP(s) == nil {
+					return fmt.Errorf("dns=%s: %q is not an IP address", v, s)
+				}
+			}
+			// Cameras show an unset server as 0.0.0.0.
+			a.PrimaryDNS, a.SecondaryDNS = servers[0], "0.0.0.0"
+			if len(servers) == 2 {
+				a.SecondaryDNS = servers[1]
+			}
+		default:
+			return fmt.Errorf("unknown network setting %q", k)
+		}
+	}
+	if _, ok := set["dhcp"]; !ok && set["ip"] != "" {
+		a.AddressingType = hikvision.AddressingStatic
+	}
+	if a.AddressingType == hikvision.AddressingDHCP && set["ip"] != "" {
+		return fmt.Errorf("ip cannot be set with dhcp=on")
+	}
+	return nil
+}
+
+func printInterface(w io.Writer, n hikvision.NetworkInterface) {
+	a := n.IPAddress
+	mode := "static"
+	if a.AddressingType == hikvision.AddressingDHCP {
+		mode = "dhcp"
+	}
+	fmt.Fprintf(w, "interface %d: %s %s/%s, gateway %s", n.ID, mode, a.Address, a.SubnetMask, a.Gateway)
+	var dns []string
+	for _, s := range []string{a.PrimaryDNS, a.SecondaryDNS} {
+		if s != "" && s != "0.0.0.0" {
+			dns = append(dns, s)
+		}
+	}
+	if len(dns) > 0 {
+		fmt.Fprintf(w, ", dns %s", strings.Join(dns, ","))
+	}
+	if n.Link.MACAddress != "" {
+		fmt.Fprintf(w, ", mac %s", n.Link.MACAddress)
+	}
+	fmt.Fprintln(w)
+}
+
+// serviceNames maps CLI names to service protocols.
+var serviceNames = map[string]string{
+	"http":  hikvision.ProtocolHTTP,
+	"https": hikvision.ProtocolHTTPS,
+	"rtsp":  hikvision.ProtocolRTSP,
+	"sdk":   hikvision.ProtocolSDK,
+}
+
+// servicePorts shows the service ports or changes them with settings such as
+// "http=8080 sdk=off". A port number also enables the service.
+func servicePorts(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	ports, err := cam.GetServicePorts(ctx)
+	if err != nil {
+		return err
+	}
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		proto, ok := serviceNames[k]
+		if !ok {
+			return fmt.Errorf("unknown service %q — must be http, https, rtsp or sdk", k)
+		}
+		var p *hikvision.ServicePort
+		for i := range ports {
+			if ports[i].Protocol == proto {
+				p = &ports[i]
+			}
+		}
+		if p == nil {
+			return fmt.Errorf("camera has no %s service", k)
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			if n < 1 || n > 65535 {
+				return fmt.Errorf("%s=%s: want a port 1-65535", k, v)
+			}
+			p.Port, p.Enabled = n, true
+			continue
+		}
+		if p.Enabled, err = onOff(k, v); err != nil {
+			return fmt.Errorf("%s=%s: want a port or on/off", k, v)
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetServicePorts(ctx, ports); err != nil {
+			return err
+		}
+	}
+	for _, p := range ports {
+		fmt.Fprintf(w, "%s: %d (%s)\n", strings.ToLower(nameOf(serviceNames, p.Protocol)), p.Port, onOffName(p.Enabled))
+	}
+	return nil
+}
+
+// upnp shows UPnP or switches it with "on" or "off".
+func upnp(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("network upnp takes on or off")
+	}
+	u, err := cam.GetUPnP(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		if u.Enabled, err = onOff("upnp", args[0]); err != nil {
+			return err
+		}
+		if err := cam.SetUPnP(ctx, u); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "upnp: %s\n", onOffName(u.Enabled))
+	return nil
+}
+
+// ipFilter lists the IP filter of interface 1 or changes it:
+//
+//	network ipfilter add <ip>|<start>-<end> ...
+//	network ipfilter remove <ip>|<start>-<end>|<id> ...
+//	network ipfilter allow|deny|off
+func ipFilter(ctx context.Context, cam *hikvision.Camera, args []string, w io.Writer) error {
+	f, err := cam.GetIPFilter(ctx, 1)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		switch cmd, addrs := args[0], args[1:]; cmd {
+		case "allow", "deny", "off":
+			if len(addrs) > 0 {
+				return fmt.Errorf("network ipfilter %s takes no addresses", cmd)
+			}
+			f.Enabled = cmd != "off"
+			if f.Enabled {
+				f.Permission = cmd
+				for i := range f.Addresses {
+					if f.Addresses[i].Permission != "" {
+						f.Addresses[i].Permission = cmd
+					}
+				}
+			}
+		case "add", "remove":
+			if len(addrs) == 0 {
+				return fmt.Errorf("network ipfilter %s needs addresses", cmd)
+			}
+			for _, a := range addrs {
+				if cmd == "add" {
+					err = addFilterAddress(f, a)
+				} else {
+					err = removeFilterAddress(f, a)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown ipfilter command %q — must be add, remove, allow, deny or off", cmd)
+		}
+		if err := cam.SetIPFilter(ctx, 1, f); err != nil {
+			return err
+		}
+	}
+
+	mode := "off"
+	if f.Enabled {
+		mode = f.Permission
+	}
+	fmt.Fprintf(w, "ip filter: %s\n", mode)
+	for _, a := range f.Addresses {
+		fmt.Fprintf(w, "%d: %s\n", a.ID, filterAddress(a))
+	}
+	return nil
+}
+
+// filterAddress formats an IP filter entry the way add and remove take it.
+func filterAddress(a hikvision.IPFilterAddress) string {
+	if a.Type == hikvision.IPFilterRange {
+		return a.Start + "-" + a.End
+	}
+	return a.Address
+}
+
+func addFilterAddress(f *hikvision.IPFilter, s string) error {
+	a := hikvision.IPFilterAddress{Type: hikvision.IPFilterSingle, Address: s}
+	if start, end, ok := strings.Cut(s, "-"); ok {
+		a = hikvision.IPFilterAddress{Type: hikvision.IPFilterRange, Start: start, End: end}
+		if net.ParseIP(start) == nil || net.ParseIP(end) == nil {
+			return fmt.Errorf("invalid address range %q", s)
+		}
+	} else if net.ParseIP(s) == nil {
+		return fmt.Errorf("invalid address %q", s)
+	}
+	for _, x := range f.Addresses {
+		if filterAddress(x) == s {
+			return nil
+		}
+		if x.ID > a.ID {
+			a.ID = x.ID
+		}
+	}
+	a.ID++
+	if len(f.Addresses) > 0 {
+		a.Permission = f.Addresses[0].Permission
+	}
+	f.Addresses = append(f.Addresses, a)
+	return nil
+}
+
+func removeFilterAddress(f *hikvision.IPFilter, s string) error {
+	for i, x := range f.Addresses {
+		if filterAddress(x) == s || strconv.Itoa(x.ID) == s {
+			f.Addresses = append(f.Addresses[:i], f.Addresses[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not in the IP filter", s)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// Notification kinds, for notification.Kind and the on lists of webhooks and
+// notifiers.
+const (
+	notifyIR      = "ir"
+	notifyOffline = "offline"
+	notifyOnline  = "online"
+	notifyEvent   = "event"
+)
+
+// notifyKinds lists the notification kinds in the order errors name them.
+var notifyKinds = []string{notifyIR, notifyOffline, notifyOnline, notifyEvent}
+
+// notification is what sinks are sent: a webhook gets it as JSON unless its
+// body template says otherwise, a chat the text of message.
+type notification struct {
+	Kind   string    `json:"kind"`
+	Camera string    `json:"camera"`
+	Time   time.Time `json:"time"`
+	// IR is the IR mode, for ir notifications.
+	IR string `json:"ir,omitempty"`
+	// Error is why the camera is offline, for offline notifications.
+	Error string           `json:"error,omitempty"`
+	Event *hikvision.Event `json:"event,omitempty"`
+	// Snapshots are the files a rule saved of the event.
+	Snapshots []string `json:"snapshots,omitempty"`
+}
+
+// message is the one-line chat text of a notification.
+func (n notification) message() string {
+	switch n.Kind {
+	case notifyIR:
+		return fmt.Sprintf("%s: IR %s", n.Camera, n.IR)
+	case notifyOffline:
+		return fmt.Sprintf("%s is offline: %s", n.Camera, n.Error)
+	case notifyOnline:
+		return fmt.Sprintf("%s is back online", n.Camera)
+	}
+	e := n.Event
+	what := e.Description
+	if what == "" {
+		what = string(e.Type)
+	}
+	return fmt.Sprintf("%s: %s (%s %s, channel %d)", n.Camera, what, e.Type, e.State, e.ChannelID)
+}
+
+// sink is a destination of notifications, such as a webhook or a chat.
+type sink struct {
+	// name identifies the sink in logs.
+	name string
+	// on lists the notification kinds sent; empty means all.
+	on []string
+	// events lists the event types sent; empty means all.
+	events []string
+	// cameras lists the cameras notified about; nil means all.
+	cameras map[string]bool
+	// snapshot attaches a picture to event notifications: the one pushed
+	// with the event, or else a snapshot.
+	snapshot bool
+	retries  int
+	send     func(ctx context.Context, note notification, picture []byte) error
+}
+
+// wants reports whether s is sent note.
+func (s *sink) wants(note notification) bool {
+	if len(s.on) > 0 && !slices.Contains(s.on, note.Kind) {
+		return false
+	}
+	if s.cameras != nil && !s.cameras[note.Camera] {
+		return false
+	}
+	if note.Event != nil && len(s.events) > 0 && !slices.ContainsFunc(s.events, func(t string) bool {
+		return strings.EqualFold(t, string(note.Event.Type))
+	}) {
+		return false
+	}
+	return true
+}
+
+// notifier delivers notifications to sinks and keeps the last state seen of
+// each camera, so that polls only notify changes. A nil notifier drops
+// everything.
+type notifier struct {
+	sinks   []*sink
+	targets map[string]target
+
+	mu    sync.Mutex
+	ir    map[string]string
+	state map[string]string
+}
+
+// newNotifier returns the notifier of sinks for targets, or nil if there are
+// no sinks.
+func newNotifier(sinks []*sink, targets []target) *notifier {
+	if len(sinks) == 0 {
+		return nil
+	}
+	n := &notifier{
+		sinks:   sinks,
+		targets: make(map[string]target),
+		ir:      make(map[string]string),
+		state:   make(map[string]string),
+	}
+	for _, t := range targets {
+		n.targets[t.name] = t
+	}
+	return n
+}
+
+// checkKinds validates the on list of a sink.
+func checkKinds(on []string) error {
+	for _, kind := range on {
+		if !slices.Contains(notifyKinds, kind) {
+			return fmt.Errorf("unknown notification %q — must be %s", kind, strings.Join(notifyKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// observe records the outcome of polling the IR mode of a camera and
+// notifies when it went offline, came back online or changed mode.
+func (n *notifier) observe(ctx context.Context, name string, mode hikvision.IRMode, err error) {
+	if n == nil {
+		return
+	}
+	state, ir := notifyOnline, irModeName(mode)
+	if err != nil {
+		state, ir = notifyOffline, ""
+	}
+	n.mu.Lock()
+	lastState, seen := n.state[name]
+	lastIR := n.ir[name]
+	n.state[name] = state
+	if ir != "" {
+		n.ir[name] = ir
+	}
+	n.mu.Unlock()
+
+	now := time.Now()
+	// A camera is online to begin with: only reaching it after failing
+	// to is news.
+	if state != lastState && (seen || state == notifyOffline) {
+		note := notification{Kind: state, Camera: name, Time: now}
+		if err != nil {
+			note.Error = err.Error()
+		}
+		n.notify(ctx, note)
+	}
+	if ir != "" && lastIR != "" && ir != lastIR {
+		n.notify(ctx, notification{Kind: notifyIR, Camera: name, Time: now, IR: ir})
+	}
+}
+
+// event notifies an event of camera name, and the snapshots saved of it.
+func (n *notifier) event(ctx context.Context, name string, e hikvision.Event, snapshots []string) {
+	if n == nil {
+		return
+	}
+	n.notify(ctx, notification{Kind: notifyEvent, Camera: name, Time: time.Now(), Event: &e, Snapshots: snapshots})
+}
+
+// notify sends note to every sink that wants it, in the background.
+func (n *notifier) notify(ctx context.Context, note notification) {
+	var picture func() []byte
+	if note.Event != nil {
+		picture = sync.OnceValue(func() []byte { return n.picture(ctx, note) })
+	}
+	for _, s := range n.sinks {
+		if s.wants(note) {
+			go func(s *sink) {
+				var data []byte
+				if s.snapshot && picture != nil {
+					data = picture()
+				}
+				n.deliver(ctx, s, note, data)
+			}(s)
+		}
+	}
+}
+
+// picture returns the first JPEG pushed with the event of note or else a
+// snapshot of its channel, or nil if that fails.
+func (n *notifier) picture(ctx context.Context, note notification) []byte {
+	for _, p := range note.Event.Pictures {
+		if strings.Contains(p.ContentType, "jpeg") {
+			return p.Data
+		}
+	}
+	t, ok := n.targets[note.Camera]
+	if !ok {
+		return nil
+	}
+	channel := note.Event.ChannelID
+	if channel == 0 {
+		channel = t.channel
+	}
+	data, err := grab(ctx, t.cam, channel)
+	if err != nil {
+		slog.Warn("notify: snapshot failed", "camera", t.name, "err", err)
+		return nil
+	}
+	return data
+}
+
+// deliver sends note to s, retrying failures with a doubling backoff.
+func (n *notifier) deliver(ctx context.Context, s *sink, note notification, picture []byte) {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err := s.send(ctx, note, picture)
+		if err == nil {
+			return
+		}
+		if attempt >= s.retries || ctx.Err() != nil {
+			slog.Error("notify: delivery failed", "sink", s.name, "camera", note.Camera, "kind", note.Kind, "attempts", attempt+1, "err", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// loadSinks returns the sinks of the webhooks and notifiers of cfg, which may
+// be nil, and of the --webhook flag. Notifiers are sent about the targets
+// whose notify list names them.
+func loadSinks(cfg *fileConfig, targets []target, webhookURL string) ([]*sink, error) {
+	var hooks []webhookConfig
+	chats := map[string]chatConfig{}
+	if cfg != nil {
+		hooks, chats = cfg.Webhooks, cfg.Notifiers
+	}
+	if webhookURL != "" {
+		hooks = append(hooks, webhookConfig{URL: webhookURL})
+	}
+	var sinks []*sink
+	for i, c := range hooks {
+		s, err := webhookSink(c)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %d: %w", i+1, err)
+		}
+		sinks = append(sinks, s)
+	}
+	cameras := map[string]map[string]bool{}
+	for _, t := range targets {
+		for _, name := range t.notify {
+			if _, ok := chats[name]; !ok {
+				return nil, fmt.Errorf("camera %s: unknown notifier %q", t.name, name)
+			}
+			if cameras[name] == nil {
+				cameras[name] = map[string]bool{}
+			}
+			cameras[name][t.name] = true
+		}
+	}
+	for _, name := range sortedKeys(chats) {
+		s, err := chatSink(name, chats[name], cameras[name])
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", name, err)
+		}
+		if len(cameras[name]) > 0 {
+			sinks = append(sinks, s)
+		}
+	}
+	return sinks, nil
+}
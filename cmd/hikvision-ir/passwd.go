@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// passwd changes the password of the account the tool logs in as
+// ("passwd") or of another account ("passwd <user>") to pass.
+func passwd(ctx context.Context, cam *hikvision.Camera, positional []string, pass string, w io.Writer) error {
+	user, err := passwdUser(cam.Username, positional)
+	if err != nil {
+		return err
+	}
+	if err := cam.ChangePassword(ctx, user, pass); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "password of %s: changed\n", user)
+	return nil
+}
+
+// passwdUser returns the account of a passwd action.
+func passwdUser(self string, positional []string) (string, error) {
+	switch len(positional) {
+	case 0:
+		return self, nil
+	case 1:
+		return positional[0], nil
+	}
+	return "", fmt.Errorf("passwd needs [<user>]; the new password comes from --new-pass-env, --new-pass-file or the terminal")
+}
+
+// newPassword returns the new password of a passwd action: the env variable,
+// the contents of file, or else read from stdin, without echo and twice on
+// a terminal. It is never taken from the command line, where every local
+// user could see it and the shell history would keep it.
+func newPassword(env, file string) (string, error) {
+	var pass string
+	switch {
+	case env != "":
+		if pass = os.Getenv(env); pass == "" {
+			return "", fmt.Errorf("new-pass-env: $%s is not set", env)
+		}
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("new-pass-file: %w", err)
+		}
+		pass = strings.TrimRight(string(data), "\r\n")
+	default:
+		var err error
+		if pass, err = readPassword(os.Stdin, os.Stderr, "New password: "); err != nil {
+			return "", err
+		}
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && pass != "" {
+			again, err := readPassword(os.Stdin, os.Stderr, "Retype new password: ")
+			if err != nil {
+				return "", err
+			}
+			if again != pass {
+				return "", fmt.Errorf("passwords do not match")
+			}
+		}
+	}
+	if pass == "" {
+		return "", fmt.Errorf("empty new password")
+	}
+	return pass, nil
+}
+
+// savePasswords records pass as the password of every named camera in the
+// config file.
+func savePasswords(path string, names []string, pass string) error {
+	return editConfig(path, false, func(cameras *yaml.Node) error {
+		for _, name := range names {
+			entry := mappingValue(cameras, name)
+			if entry == nil {
+				return fmt.Errorf("camera %q not found in %s", name, path)
+			}
+			if entry.Kind != yaml.MappingNode {
+				// An empty entry ("garage:") parses as null.
+				*entry = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			if v := mappingValue(entry, "pass"); v != nil {
+				*v = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pass, LineComment: v.LineComment}
+			} else {
+				appendPair(entry, "pass", pass)
+			}
+		}
+		return nil
+	})
+}
+
+// rotatePasswords runs passwd against every target and then stores the new
+// password where each camera that accepted it keeps its password: the config
+// file, the keyring or a pass_file. The others keep the password that still
+// works.
+func rotatePasswords(ctx context.Context, cfg *fileConfig, targets []target, args actionArgs, parallel int, w io.Writer) error {
+	if len(args.positional) != 0 {
+		return fmt.Errorf("--update-config changes the login account's own password: passwd without <user>")
+	}
+	for _, t := range targets {
+		if t.pass.inline() && cfg == nil {
+			return fmt.Errorf("--update-config needs --config for a password given with --pass")
+		}
+	}
+	pass := args.newPass
+	results := runAll(ctx, targets, args, parallel)
+	failed := printResults(w, results)
+	if len(targets) > 0 && targets[0].cam.DryRun != nil {
+		fmt.Fprintln(w, "\ndry run: passwords not stored")
+		return nil
+	}
+	var changed []string
+	var inline []string
+	var storeErr error
+	for i, r := range results {
+		if r.err != nil {
+			continue
+		}
+		changed = append(changed, r.name)
+		if targets[i].pass.inline() {
+			inline = append(inline, r.name)
+			continue
+		}
+		note, err := storePassword(targets[i], pass)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", r.name, err)
+			storeErr = err
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", r.name, note)
+		}
+	}
+	if len(inline) > 0 {
+		if err := savePasswords(cfg.path, inline, pass); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n%s: updated %d camera(s)\n", cfg.path, len(inline))
+	}
+	if storeErr != nil {
+		return fmt.Errorf("new password not stored: %w", storeErr)
+	}
+	if failed {
+		return fmt.Errorf("password not changed on %d camera(s)", len(results)-len(changed))
+	}
+	return nil
+}
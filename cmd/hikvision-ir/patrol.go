@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// defaultPatrolSpeed is the speed of patrol steps that give none, the
+// middle of the 1-40 range.
+const defaultPatrolSpeed = 20
+
+// parkTypes maps CLI names to park action types.
+var parkTypes = map[string]string{
+	"preset":   hikvision.ParkPreset,
+	"patrol":   hikvision.ParkPatrol,
+	"pattern":  hikvision.ParkPattern,
+	"autoscan": hikvision.ParkAutoScan,
+}
+
+// patrol prints the patrols of channel that have steps, as "<id>: <steps>"
+// lines, or sets them with <id>=<steps> arguments, or starts or stops one
+// ("patrol start <id>"). Steps are "<preset> [<dwell>] [speed=<1-40>]"
+// separated by commas, such as "1 10s, 3 5s speed=30", or off.
+func patrol(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) > 0 && (positional[0] == "start" || positional[0] == "stop") {
+		if len(positional) != 2 {
+			return fmt.Errorf("ptz patrol %s needs one patrol id", positional[0])
+		}
+		id, err := strconv.Atoi(positional[1])
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid patrol id %q", positional[1])
+		}
+		if positional[0] == "start" {
+			if err := cam.StartPTZPatrol(ctx, channel, id); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "patrol %d: started\n", id)
+			return nil
+		}
+		if err := cam.StopPTZPatrol(ctx, channel, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "patrol %d: stopped\n", id)
+		return nil
+	}
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	patrols, err := cam.GetPTZPatrols(ctx, channel)
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		id, err := strconv.Atoi(k)
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid patrol id %q — set patrols as <id>=<steps>", k)
+		}
+		steps, err := parsePatrol(v)
+		if err != nil {
+			return fmt.Errorf("patrol %d: %w", id, err)
+		}
+		i := slices.IndexFunc(patrols, func(p hikvision.PTZPatrol) bool { return p.ID == id })
+		if i < 0 {
+			patrols = append(patrols, hikvision.PTZPatrol{ID: id})
+			i = len(patrols) - 1
+		}
+		patrols[i].Steps = steps
+		if err := cam.SetPTZPatrol(ctx, channel, &patrols[i]); err != nil {
+			return err
+		}
+	}
+
+	n := 0
+	for _, p := range patrols {
+		if len(p.Steps) > 0 {
+			fmt.Fprintf(w, "%d: %s\n", p.ID, formatPatrol(p.Steps))
+			n++
+		}
+	}
+	if n == 0 {
+		fmt.Fprintln(w, "patrols: none")
+	}
+	return nil
+}
+
+// parsePatrol parses the steps of a patrol, see patrol.
+func parsePatrol(s string) ([]hikvision.PatrolStep, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") || s == "" {
+		return nil, nil
+	}
+	var steps []hikvision.PatrolStep
+	for _, entry := range strings.Split(s, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		step := hikvision.PatrolStep{Speed: defaultPatrolSpeed}
+		var err error
+		if step.Preset, err = strconv.Atoi(fields[0]); err != nil || step.Preset < 1 {
+			return nil, fmt.Errorf("step %q: want a preset id first", strings.TrimSpace(entry))
+		}
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(f, "speed="); ok {
+				if step.Speed, err = strconv.Atoi(v); err != nil || step.Speed < 1 || step.Speed > 40 {
+					return nil, fmt.Errorf("step %q: speed must be 1-40", strings.TrimSpace(entry))
+				}
+				continue
+			}
+			d, err := time.ParseDuration(f)
+			if err != nil || d < 0 || d%time.Second != 0 {
+				return nil, fmt.Errorf("step %q: invalid dwell %q — want whole seconds such as 10s", strings.TrimSpace(entry), f)
+			}
+			step.Dwell = int(d / time.Second)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// formatPatrol is the inverse of parsePatrol, leaving out default speeds.
+func formatPatrol(steps []hikvision.PatrolStep) string {
+	entries := make([]string, len(steps))
+	for i, s := range steps {
+		entry := fmt.Sprintf("%d %s", s.Preset, time.Duration(s.Dwell)*time.Second)
+		if s.Speed != defaultPatrolSpeed {
+			entry += fmt.Sprintf(" speed=%d", s.Speed)
+		}
+		entries[i] = entry
+	}
+	return strings.Join(entries, ", ")
+}
+
+// park prints the park action of channel, or changes the settings given as
+// key=value arguments: enabled=on|off, wait=<duration> and action=<type>
+// [<number>], such as "preset 1" or "patrol 2".
+func park(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	p, err := cam.GetParkAction(ctx, channel)
+	if err != nil {
+		return err
+	}
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			p.Enabled, err = onOff(k, v)
+		case "wait":
+			var d time.Duration
+			if d, err = time.ParseDuration(v); err != nil || d < time.Second || d%time.Second != 0 {
+				err = fmt.Errorf("wait=%s: want whole seconds such as 60s", v)
+			}
+			p.Wait = int(d / time.Second)
+		case "action":
+			p.Type, p.Number, err = parseParkAction(v)
+		default:
+			return fmt.Errorf("unknown park setting %q — must be enabled, wait or action", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetParkAction(ctx, channel, p); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "enabled: %s\n", onOffName(p.Enabled))
+	fmt.Fprintf(w, "wait: %s\n", time.Duration(p.Wait)*time.Second)
+	fmt.Fprintf(w, "action: %s\n", formatParkAction(p.Type, p.Number))
+	return nil
+}
+
+// parseParkAction parses "preset 1", "patrol 2" or "autoscan".
+func parseParkAction(s string) (string, int, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return "", 0, fmt.Errorf("action needs a type such as preset 1")
+	}
+	t, ok := parkTypes[fields[0]]
+	if !ok {
+		return "", 0, fmt.Errorf("unknown park action %q — must be one of %s", fields[0], strings.Join(sortedKeys(parkTypes), ", "))
+	}
+	if t == hikvision.ParkAutoScan {
+		if len(fields) != 1 {
+			return "", 0, fmt.Errorf("action=%s: autoscan takes no number", s)
+		}
+		return t, 0, nil
+	}
+	n := 0
+	if len(fields) == 2 {
+		n, _ = strconv.Atoi(fields[1])
+	}
+	if n < 1 {
+		return "", 0, fmt.Errorf("action=%s: want %s <number>", s, fields[0])
+	}
+	return t, n, nil
+}
+
+// formatParkAction is the inverse of parseParkAction.
+func formatParkAction(t string, n int) string {
+	name := nameOf(parkTypes, t)
+	if t == hikvision.ParkAutoScan || n == 0 {
+		return dash(name)
+	}
+	return fmt.Sprintf("%s %d", name, n)
+}
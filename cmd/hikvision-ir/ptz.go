@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// ptz prints where the PTZ of channel points ("ptz" or "ptz status"), or
+// steers it:
+//
+//	ptz move [pan=<speed>] [tilt=<speed>] [zoom=<speed>] [for=<duration>]
+//	ptz zoom in|out [speed=<speed>] [for=<duration>]
+//	ptz stop
+//	ptz goto [azimuth=<degrees>] [elevation=<degrees>] [zoom=<factor>]
+//	ptz relative [x=<n>] [y=<n>] [zoom=<n>]
+//	ptz preset [set <id> [<name>] | delete <preset> | goto <preset>]
+//	ptz patrol [<id>=<steps> ... | start <id> | stop <id>]
+//	ptz park [key=value ...]
+//	ptz aux [<id> on|off] | ptz wiper|light|heater|fan [on|off]
+//	ptz tracking [on|off]
+//
+// Speeds and relative shifts are -100 to 100. A move without for= runs until
+// ptz stop; goto keeps the axes it is not given.
+func ptz(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) == 0 || positional[0] == "status" {
+		if len(positional) > 1 {
+			return fmt.Errorf("ptz status takes no arguments")
+		}
+		return printPTZPosition(ctx, cam, channel, w)
+	}
+	cmd := positional[0]
+	switch cmd {
+	case "preset":
+		return ptzPreset(ctx, cam, channel, positional[1:], w)
+	case "patrol":
+		return patrol(ctx, cam, channel, positional[1:], w)
+	case "park":
+		return park(ctx, cam, channel, positional[1:], w)
+	case "aux", "wiper", "light", "heater", "fan":
+		return ptzAux(ctx, cam, channel, cmd, positional[1:], w)
+	case "tracking":
+		return tracking(ctx, cam, channel, positional[1:], w)
+	}
+	if cmd == "zoom" {
+		if len(positional) < 2 || (positional[1] != "in" && positional[1] != "out") {
+			return fmt.Errorf("ptz zoom needs in or out, e.g. ptz zoom in for=2s")
+		}
+		set, err := parseSettings(positional[2:])
+		if err != nil {
+			return err
+		}
+		speed, d := 50, time.Duration(0)
+		for k, v := range set {
+			switch k {
+			case "speed":
+				speed, err = level(k, v)
+			case "for":
+				d, err = ptzDuration(k, v)
+			default:
+				return fmt.Errorf("unknown ptz zoom setting %q — must be speed or for", k)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if positional[1] == "out" {
+			speed = -speed
+		}
+		return ptzMove(ctx, cam, channel, hikvision.PTZMove{Zoom: speed}, d, w)
+	}
+	set, err := parseSettings(positional[1:])
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "move":
+		var m hikvision.PTZMove
+		var d time.Duration
+		for k, v := range set {
+			switch k {
+			case "pan":
+				m.Pan, err = ptzSpeed(k, v)
+			case "tilt":
+				m.Tilt, err = ptzSpeed(k, v)
+			case "zoom":
+				m.Zoom, err = ptzSpeed(k, v)
+			case "for":
+				d, err = ptzDuration(k, v)
+			default:
+				return fmt.Errorf("unknown ptz move setting %q — must be pan, tilt, zoom or for", k)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if m == (hikvision.PTZMove{}) {
+			return fmt.Errorf("ptz move needs a pan, tilt or zoom speed, e.g. ptz move pan=50 for=1s")
+		}
+		return ptzMove(ctx, cam, channel, m, d, w)
+
+	case "stop":
+		if len(set) > 0 {
+			return fmt.Errorf("ptz stop takes no arguments")
+		}
+		if err := cam.StopPTZ(ctx, channel); err != nil {
+			return err
+		}
+		return printPTZPosition(ctx, cam, channel, w)
+
+	case "goto":
+		if len(set) == 0 {
+			return fmt.Errorf("ptz goto needs azimuth, elevation or zoom, e.g. ptz goto azimuth=90 elevation=10")
+		}
+		p, err := cam.GetPTZPosition(ctx, channel)
+		if err != nil {
+			return err
+		}
+		for k, v := range set {
+			f, err := strconv.ParseFloat(v, 64)
+			switch {
+			case err != nil:
+				return fmt.Errorf("%s=%s: must be a number", k, v)
+			case k == "azimuth" && (f < 0 || f > 360):
+				return fmt.Errorf("azimuth=%s: must be 0-360", v)
+			case k == "azimuth":
+				p.Azimuth = f
+			case k == "elevation" && (f < -90 || f > 90):
+				return fmt.Errorf("elevation=%s: must be -90 to 90", v)
+			case k == "elevation":
+				p.Elevation = f
+			case k == "zoom" && f < 1:
+				return fmt.Errorf("zoom=%s: must be a zoom factor of 1 or more", v)
+			case k == "zoom":
+				p.Zoom = f
+			default:
+				return fmt.Errorf("unknown ptz goto setting %q — must be azimuth, elevation or zoom", k)
+			}
+		}
+		if err := cam.SetPTZPosition(ctx, channel, p); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "ptz: going to %s\n", formatPTZPosition(p))
+		return nil
+
+	case "relative":
+		var r hikvision.PTZRelative
+		for k, v := range set {
+			switch k {
+			case "x":
+				r.X, err = ptzSpeed(k, v)
+			case "y":
+				r.Y, err = ptzSpeed(k, v)
+			case "zoom":
+				r.Zoom, err = ptzSpeed(k, v)
+			default:
+				return fmt.Errorf("unknown ptz relative setting %q — must be x, y or zoom", k)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if r == (hikvision.PTZRelative{}) {
+			return fmt.Errorf("ptz relative needs x, y or zoom, e.g. ptz relative x=20")
+		}
+		if err := cam.MovePTZRelative(ctx, channel, r); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "ptz: moved")
+		return nil
+	}
+	return fmt.Errorf("unknown ptz command %q — must be status, move, zoom, stop, goto, relative, preset, patrol, park, aux, wiper, light, heater, fan or tracking", cmd)
+}
+
+// ptzPreset lists the stored presets of channel, stores the current position
+// as one, deletes one or goes to one. Presets are given by id or name.
+func ptzPreset(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	presets, err := cam.GetPTZPresets(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		n := 0
+		for _, p := range presets {
+			if p.Enabled {
+				fmt.Fprintf(w, "preset %d: %s\n", p.ID, dash(p.Name))
+				n++
+			}
+		}
+		if n == 0 {
+			fmt.Fprintln(w, "presets: none")
+		}
+		return nil
+	}
+
+	switch cmd := positional[0]; {
+	case cmd == "set" && (len(positional) == 2 || len(positional) == 3):
+		id, err := strconv.Atoi(positional[1])
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid preset id %q", positional[1])
+		}
+		p := &hikvision.PTZPreset{ID: id, Name: fmt.Sprintf("Preset %d", id)}
+		for _, q := range presets {
+			if q.ID == id {
+				*p = q
+			}
+		}
+		if len(positional) == 3 {
+			p.Name = positional[2]
+		}
+		if err := cam.SetPTZPreset(ctx, channel, p); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "preset %d: %s stored\n", p.ID, p.Name)
+	case cmd == "delete" && len(positional) == 2:
+		p, err := findPreset(presets, positional[1])
+		if err != nil {
+			return err
+		}
+		if err := cam.DeletePTZPreset(ctx, channel, p.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "preset %d: deleted\n", p.ID)
+	case cmd == "goto" && len(positional) == 2:
+		p, err := findPreset(presets, positional[1])
+		if err != nil {
+			return err
+		}
+		if err := cam.GotoPTZPreset(ctx, channel, p.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "ptz: going to preset %d (%s)\n", p.ID, dash(p.Name))
+	case cmd == "set":
+		return fmt.Errorf("ptz preset set needs <id> [<name>], e.g. ptz preset set 3 gate")
+	case cmd == "delete" || cmd == "goto":
+		return fmt.Errorf("ptz preset %s needs one preset id or name", cmd)
+	default:
+		return fmt.Errorf("unknown ptz preset command %q — must be set, delete or goto", cmd)
+	}
+	return nil
+}
+
+// findPreset returns the stored preset with the id or name s.
+func findPreset(presets []hikvision.PTZPreset, s string) (hikvision.PTZPreset, error) {
+	id, _ := strconv.Atoi(s)
+	for _, p := range presets {
+		if p.Enabled && (p.ID == id || strings.EqualFold(p.Name, s)) {
+			return p, nil
+		}
+	}
+	return hikvision.PTZPreset{}, fmt.Errorf("no preset %q — run ptz preset for the list", s)
+}
+
+// tracking prints whether the PTZ of channel follows what its smart events
+// pick up, or switches it on or off.
+func tracking(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) > 1 {
+		return fmt.Errorf("ptz tracking takes on or off")
+	}
+	s, err := cam.GetSmartTracking(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		if s.Enabled, err = onOff("tracking", positional[0]); err != nil {
+			return fmt.Errorf("ptz tracking takes on or off, not %q", positional[0])
+		}
+		if err := cam.SetSmartTracking(ctx, channel, s); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "tracking: %s\n", onOffName(s.Enabled))
+	return nil
+}
+
+// ptzMove moves the PTZ at the speeds of m, for d or, if d is 0, until ptz
+// stop.
+func ptzMove(ctx context.Context, cam *hikvision.Camera, channel int, m hikvision.PTZMove, d time.Duration, w io.Writer) error {
+	if d == 0 {
+		if err := cam.MovePTZ(ctx, channel, m); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "ptz: moving until ptz stop")
+		return nil
+	}
+	if err := cam.MovePTZFor(ctx, channel, m, d); err != nil {
+		return err
+	}
+	return printPTZPosition(ctx, cam, channel, w)
+}
+
+// ptzDuration parses the positive duration of a move.
+func ptzDuration(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s=%s: want a duration such as 2s", key, value)
+	}
+	return d, nil
+}
+
+// ptzSpeed parses a -100 to 100 speed or shift.
+func ptzSpeed(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < -100 || n > 100 {
+		return 0, fmt.Errorf("%s=%s: want -100 to 100", key, value)
+	}
+	return n, nil
+}
+
+func printPTZPosition(ctx context.Context, cam *hikvision.Camera, channel int, w io.Writer) error {
+	p, err := cam.GetPTZPosition(ctx, channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "ptz: %s\n", formatPTZPosition(p))
+	return nil
+}
+
+func formatPTZPosition(p hikvision.PTZPosition) string {
+	return fmt.Sprintf("azimuth %.1f°, elevation %.1f°, zoom %.1fx", p.Azimuth, p.Elevation, p.Zoom)
+}
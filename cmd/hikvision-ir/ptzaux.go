@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// auxTypes maps the CLI names of auxiliary devices to their types.
+var auxTypes = map[string]string{
+	"wiper":  hikvision.AuxWiper,
+	"light":  hikvision.AuxLight,
+	"heater": hikvision.AuxHeater,
+	"fan":    hikvision.AuxFan,
+}
+
+// ptzAux lists the auxiliary devices of channel ("ptz aux"), or switches
+// one by name ("ptz wiper on") or id ("ptz aux 2 off"). name is the device
+// name the command was given as, or "aux".
+func ptzAux(ctx context.Context, cam *hikvision.Camera, channel int, name string, positional []string, w io.Writer) error {
+	devices, err := cam.GetPTZAux(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if name == "aux" && len(positional) == 0 {
+		if len(devices) == 0 {
+			fmt.Fprintln(w, "aux: none")
+		}
+		for _, a := range devices {
+			fmt.Fprintf(w, "aux %d: %s %s\n", a.ID, auxName(a.Type), onOffName(a.On()))
+		}
+		return nil
+	}
+
+	var a *hikvision.PTZAux
+	if name == "aux" {
+		id, err := strconv.Atoi(positional[0])
+		if err != nil {
+			return fmt.Errorf("ptz aux needs <id> on|off, e.g. ptz aux 1 on")
+		}
+		for i := range devices {
+			if devices[i].ID == id {
+				a = &devices[i]
+			}
+		}
+		if a == nil {
+			return fmt.Errorf("no aux %d — run ptz aux for the list", id)
+		}
+		positional = positional[1:]
+	} else {
+		for i := range devices {
+			if strings.EqualFold(devices[i].Type, auxTypes[name]) {
+				a = &devices[i]
+				break
+			}
+		}
+		if a == nil {
+			return fmt.Errorf("the camera has no %s", name)
+		}
+	}
+	if len(positional) == 0 {
+		fmt.Fprintf(w, "%s: %s\n", auxName(a.Type), onOffName(a.On()))
+		return nil
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("ptz %s takes on or off", name)
+	}
+	on, err := onOff(auxName(a.Type), positional[0])
+	if err != nil {
+		return fmt.Errorf("ptz %s takes on or off, not %q", name, positional[0])
+	}
+	a.Status = onOffName(on)
+	if err := cam.SetPTZAux(ctx, channel, a); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s: %s\n", auxName(a.Type), a.Status)
+	return nil
+}
+
+// auxName is the CLI name of an auxiliary device type.
+func auxName(t string) string {
+	for name, x := range auxTypes {
+		if strings.EqualFold(x, t) {
+			return name
+		}
+	}
+	return strings.ToLower(t)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// recordModes maps CLI names to recording modes.
+var recordModes = map[string]hikvision.RecordMode{
+	"continuous":       hikvision.RecordContinuous,
+	"motion":           hikvision.RecordMotion,
+	"alarm":            hikvision.RecordAlarm,
+	"motion-or-alarm":  hikvision.RecordMotionOrAlarm,
+	"motion-and-alarm": hikvision.RecordMotionAndAlarm,
+	"event":            hikvision.RecordEvent,
+}
+
+// recordModeName is the CLI name of a recording mode.
+func recordModeName(m hikvision.RecordMode) string {
+	for name, mode := range recordModes {
+		if strings.EqualFold(string(mode), string(m)) {
+			return name
+		}
+	}
+	return strings.ToLower(string(m))
+}
+
+// weekdays are the schedule's day names, Monday first as cameras show them.
+var weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// weekday returns the time.Weekday of a weekdays index.
+func weekday(i int) time.Weekday {
+	return time.Weekday((i + 1) % 7)
+}
+
+// recordSlot is a stretch of one day recorded in one mode. Start and end are
+// minutes after midnight.
+type recordSlot struct {
+	day        int // index into weekdays
+	start, end int
+	mode       hikvision.RecordMode
+}
+
+// record prints the recording schedule of channel, or changes the settings
+// given as key=value arguments: enabled=on|off, schedule=<schedule>,
+// pre=<seconds>, post=<seconds> and audio=on|off. A schedule is a list of
+// "[days] [HH:MM-HH:MM] mode" entries separated by semicolons, such as
+// "mon-fri 07:00-19:00 motion; mon-fri 19:00-07:00 continuous; sat,sun
+// continuous", or off. Days default to every day and times to the whole day.
+// A window past midnight such as 19:00-07:00 covers both ends of each day
+// listed, as the weekly grid of the camera does.
+func record(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	set, err := parseSettings(positional)
+	if err != nil {
+		return err
+	}
+	s, err := cam.GetRecordSchedule(ctx, hikvision.RecordingTrackID(channel))
+	if err != nil {
+		return err
+	}
+	ext := s.Settings()
+	for k, v := range set {
+		switch k {
+		case "enabled":
+			ext.EnableSchedule, err = onOff(k, v)
+			if ext.EnableSchedule {
+				s.Enable = true
+			}
+		case "schedule":
+			var slots []recordSlot
+			if slots, err = parseRecordSchedule(v); err == nil {
+				s.SetActions(scheduleActions(slots))
+			}
+		case "pre":
+			ext.PreRecord, err = seconds(k, v)
+		case "post":
+			ext.PostRecord, err = seconds(k, v)
+		case "audio":
+			ext.SaveAudio, err = onOff(k, v)
+		default:
+			return fmt.Errorf("unknown record setting %q — must be enabled, schedule, pre, post or audio", k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetRecordSchedule(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "enabled: %s\n", onOffName(s.Enable && ext.EnableSchedule))
+	fmt.Fprintf(w, "schedule: %s\n", formatRecordSchedule(actionSlots(s.Actions())))
+	fmt.Fprintf(w, "pre: %d\n", ext.PreRecord)
+	fmt.Fprintf(w, "post: %d\n", ext.PostRecord)
+	fmt.Fprintf(w, "audio: %s\n", onOffName(ext.SaveAudio))
+	return nil
+}
+
+// seconds parses a non-negative number of seconds.
+func seconds(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s=%s: must be a number of seconds", key, value)
+	}
+	return n, nil
+}
+
+// parseRecordSchedule parses a schedule, see record.
+func parseRecordSchedule(s string) ([]recordSlot, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") || s == "" {
+		return nil, nil
+	}
+	var slots []recordSlot
+	for _, entry := range strings.Split(s, ";") {
+		fields := strings.Fields(strings.ToLower(entry))
+		if len(fields) == 0 {
+			continue
+		}
+		mode, ok := recordModes[fields[len(fields)-1]]
+		if !ok {
+			return nil, fmt.Errorf("schedule %q: unknown mode %q — must be one of %s", entry, fields[len(fields)-1], strings.Join(sortedKeys(recordModes), ", "))
+		}
+		days := []int{0, 1, 2, 3, 4, 5, 6}
+		start, end := 0, 24*60
+		for _, f := range fields[:len(fields)-1] {
+			var err error
+			if strings.Contains(f, ":") {
+				start, end, err = parseWindow(f)
+			} else {
+				days, err = parseDays(f)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("schedule %q: %w", entry, err)
+			}
+		}
+		for _, d := range days {
+			if start < end {
+				slots = append(slots, recordSlot{d, start, end, mode})
+				continue
+			}
+			slots = append(slots, recordSlot{d, start, 24 * 60, mode})
+			if end > 0 {
+				slots = append(slots, recordSlot{d, 0, end, mode})
+			}
+		}
+	}
+	slots = mergeSlots(slots)
+	for i := 1; i < len(slots); i++ {
+		if p, c := slots[i-1], slots[i]; p.day == c.day && c.start < p.end {
+			return nil, fmt.Errorf("schedule: %s %s and %s overlap", weekdays[c.day], clockRange(p.start, p.end), clockRange(c.start, c.end))
+		}
+	}
+	return slots, nil
+}
+
+// parseDays parses "mon", "mon-fri", "sat,sun" or "daily".
+func parseDays(s string) ([]int, error) {
+	if s == "daily" {
+		return []int{0, 1, 2, 3, 4, 5, 6}, nil
+	}
+	var days []int
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		a := slices.Index(weekdays, from)
+		b := a
+		if isRange {
+			b = slices.Index(weekdays, to)
+		}
+		if a < 0 || b < 0 {
+			return nil, fmt.Errorf("unknown days %q — want e.g. mon, mon-fri or sat,sun", part)
+		}
+		for d := a; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == b {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseWindow parses "HH:MM-HH:MM" into minutes after midnight. The end may
+// be 24:00.
+func parseWindow(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if ok {
+		start, err = parseClock(from)
+	}
+	if ok && err == nil {
+		if to == "24:00" {
+			end = 24 * 60
+		} else {
+			end, err = parseClock(to)
+		}
+	}
+	if !ok || err != nil || start == end {
+		return 0, 0, fmt.Errorf("invalid window %q — want HH:MM-HH:MM", s)
+	}
+	return start, end, nil
+}
+
+// mergeSlots sorts slots by day and start and joins those that run on into
+// each other in the same mode.
+func mergeSlots(slots []recordSlot) []recordSlot {
+	slices.SortFunc(slots, func(a, b recordSlot) int {
+		if a.day != b.day {
+			return a.day - b.day
+		}
+		return a.start - b.start
+	})
+	var merged []recordSlot
+	for _, s := range slots {
+		if n := len(merged); n > 0 && merged[n-1].day == s.day && merged[n-1].end == s.start && merged[n-1].mode == s.mode {
+			merged[n-1].end = s.end
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// formatRecordSchedule is the inverse of parseRecordSchedule, in a canonical
+// form: runs of days with the same plan are joined, and days and times that
+// cover everything are left out.
+func formatRecordSchedule(slots []recordSlot) string {
+	if len(slots) == 0 {
+		return "off"
+	}
+	plans := make([][]recordSlot, 7)
+	for _, s := range slots {
+		plans[s.day] = append(plans[s.day], recordSlot{start: s.start, end: s.end, mode: s.mode})
+	}
+	var entries []string
+	for a := 0; a < 7; {
+		b := a
+		for b+1 < 7 && slices.Equal(plans[b+1], plans[a]) {
+			b++
+		}
+		days := weekdays[a]
+		switch {
+		case a == 0 && b == 6:
+			days = ""
+		case b > a:
+			days += "-" + weekdays[b]
+		}
+		for _, s := range plans[a] {
+			entry := days
+			if s.start != 0 || s.end != 24*60 {
+				entry = strings.TrimSpace(entry + " " + clockRange(s.start, s.end))
+			}
+			entries = append(entries, strings.TrimSpace(entry+" "+recordModeName(s.mode)))
+		}
+		a = b + 1
+	}
+	return strings.Join(entries, "; ")
+}
+
+// clockRange formats a window as HH:MM-HH:MM.
+func clockRange(start, end int) string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", start/60, start%60, end/60, end%60)
+}
+
+// actionSlots turns the recording actions of a schedule into slots.
+func actionSlots(actions []hikvision.ScheduleAction) []recordSlot {
+	var slots []recordSlot
+	for _, a := range actions {
+		day := -1
+		for i := range weekdays {
+			if strings.EqualFold(weekday(i).String(), a.StartDay) {
+				day = i
+			}
+		}
+		start, err1 := actionClock(a.StartTime)
+		end, err2 := actionClock(a.EndTime)
+		if !a.Record || day < 0 || err1 != nil || err2 != nil {
+			continue
+		}
+		if !strings.EqualFold(a.EndDay, a.StartDay) || end <= start {
+			end = 24 * 60
+		}
+		slots = append(slots, recordSlot{day, start, end, a.Mode})
+	}
+	return mergeSlots(slots)
+}
+
+// actionClock parses the HH:MM:SS of a schedule action, which may be
+// 24:00:00.
+func actionClock(s string) (int, error) {
+	if strings.HasPrefix(s, "24:00") {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04:05", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// scheduleActions turns slots into recording actions.
+func scheduleActions(slots []recordSlot) []hikvision.ScheduleAction {
+	actions := make([]hikvision.ScheduleAction, 0, len(slots))
+	for _, s := range slots {
+		day := weekday(s.day).String()
+		actions = append(actions, hikvision.ScheduleAction{
+			StartDay:  day,
+			StartTime: fmt.Sprintf("%02d:%02d:00", s.start/60, s.start%60),
+			EndDay:    day,
+			EndTime:   fmt.Sprintf("%02d:%02d:00", s.end/60, s.end%60),
+			Record:    true,
+			Mode:      s.mode,
+		})
+	}
+	return actions
+}
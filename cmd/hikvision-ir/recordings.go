@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// progressEvery is how often a download reports its progress.
+const progressEvery = 2 * time.Second
+
+// recordings runs "recordings [search]", which lists the recordings of
+// channel between --from and --to, and "recordings download [<uri> ...]",
+// which saves the given playback URIs, or every recording found, to the --out
+// directory.
+func recordings(ctx context.Context, cam *hikvision.Camera, channel int, name string, a actionArgs, w io.Writer) error {
+	cmd, uris := "search", []string(nil)
+	if len(a.positional) > 0 {
+		cmd, uris = a.positional[0], a.positional[1:]
+	}
+	var recs []hikvision.Recording
+	if cmd == "search" || (cmd == "download" && len(uris) == 0) {
+		if len(uris) > 0 {
+			return fmt.Errorf("recordings search takes no arguments")
+		}
+		now := time.Now()
+		from, err := parseWhen(a.from, now.Add(-24*time.Hour), now)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		to, err := parseWhen(a.to, now, now)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+		if !to.After(from) {
+			return fmt.Errorf("--to must be after --from")
+		}
+		recs, err = cam.SearchRecordings(ctx, hikvision.RecordingSearch{
+			TrackID: hikvision.RecordingTrackID(channel), From: from, To: to,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	switch cmd {
+	case "search":
+		if len(recs) == 0 {
+			fmt.Fprintln(w, "recordings: none found")
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "START\tEND\tLENGTH\tTYPE\tSIZE\tPLAYBACK URI")
+		for _, r := range recs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Start.Format(time.DateTime), r.End.Format(time.DateTime),
+				r.End.Sub(r.Start), dash(r.RecordType), mebibytes(r.Size), r.PlaybackURI)
+		}
+		return tw.Flush()
+	case "download":
+		for _, r := range recs {
+			uris = append(uris, r.PlaybackURI)
+		}
+		if len(uris) == 0 {
+			fmt.Fprintln(w, "recordings: none found")
+			return nil
+		}
+		dir := a.out
+		if dir == "-" {
+			dir = "."
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		for _, uri := range uris {
+			if err := download(ctx, cam, uri, filepath.Join(dir, recordingName(name, uri)), w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown recordings command %q — must be search or download", cmd)
+}
+
+// download saves the recording at a playback URI to path, reporting its
+// progress every progressEvery. A failed download leaves no file behind.
+func download(ctx context.Context, cam *hikvision.Camera, uri, path string, w io.Writer) error {
+	r, size, err := cam.DownloadRecording(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	p := &progress{w: w, name: filepath.Base(path), size: size, last: time.Now()}
+	_, err = io.Copy(io.MultiWriter(f, p), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("download %s: %w", filepath.Base(path), err)
+	}
+	fmt.Fprintf(w, "recordings: saved %s (%s)\n", path, mebibytes(p.n))
+	return nil
+}
+
+// progress counts the bytes of a download written through it and prints how
+// far it got every progressEvery.
+type progress struct {
+	w    io.Writer
+	name string
+	size int64
+	n    int64
+	last time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.n += int64(len(b))
+	if now := time.Now(); now.Sub(p.last) >= progressEvery {
+		p.last = now
+		if p.size > 0 {
+			fmt.Fprintf(p.w, "recordings: %s %d%% (%s of %s)\n", p.name, p.n*100/p.size, mebibytes(p.n), mebibytes(p.size))
+		} else {
+			fmt.Fprintf(p.w, "recordings: %s %s\n", p.name, mebibytes(p.n))
+		}
+	}
+	return len(b), nil
+}
+
+// recordingName is the file a recording is saved to: the camera name and the
+// times of the playback URI, or the whole URI made safe if it has none.
+func recordingName(camera, uri string) string {
+	var start, end string
+	if u, err := url.Parse(uri); err == nil {
+		start, end = u.Query().Get("starttime"), u.Query().Get("endtime")
+	}
+	if start == "" || end == "" {
+		return fileSafe(camera+"-"+uri) + ".mp4"
+	}
+	return fileSafe(camera+"-"+start+"-"+end) + ".mp4"
+}
+
+// mebibytes formats a size for people, or "-" if unknown.
+func mebibytes(n int64) string {
+	if n <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
+
+// parseWhen parses a --from or --to time: RFC 3339, a local date and time
+// such as "2026-10-13 18:00", a date, a time of day today, or a duration
+// before now such as "2h" or "7d". Empty means def.
+func parseWhen(s string, def, now time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d.Abs()), nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Local(), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want e.g. 2026-10-13 18:00, 18:00, 2h or 7d", s)
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// ruleConfig is one event-driven IR rule from a camera's rules list:
+//
+//	rules:
+//	  - events: [VMD, linedetection]
+//	    from: "19:30"    # only between from and to (local time)
+//	    to: "06:30"      # or sunset-15m, sunrise+30m: see ruleTime
+//	    ir: on           # mode while the rule is active
+//	    for: 5m          # extended by every further event
+//	    then: auto       # mode restored afterwards
+//	    output: 1        # also hold alarm output 1 active, e.g. for a floodlight
+//	    preset: 3        # also turn a PTZ camera to preset 3
+//	    snapshot: {}     # save a snapshot when the rule fires, see captureConfig
+//
+// Sunset and sunrise are worked out each day at the latitude and longitude
+// of the schedule section, or --lat and --lon. A rule with an output, a
+// preset or a snapshot but neither ir nor then leaves the IR mode alone. The preset is not left when the rule expires;
+// the camera's park action brings it back.
+type ruleConfig struct {
+	Events   []string       `yaml:"events"`
+	From     string         `yaml:"from"`
+	To       string         `yaml:"to"`
+	IR       string         `yaml:"ir"`
+	For      time.Duration  `yaml:"for"`
+	Then     string         `yaml:"then"`
+	Output   int            `yaml:"output"`
+	Preset   int            `yaml:"preset"`
+	Snapshot *captureConfig `yaml:"snapshot"`
+}
+
+// rule is a validated ruleConfig.
+type rule struct {
+	events   map[string]bool  // lower-cased event types
+	window   bool             // false means always
+	from, to ruleTime         // the daily window when window is set
+	lat, lon float64          // where sunset and sunrise are worked out
+	ir, then hikvision.IRMode // empty when the rule leaves IR alone
+	output   int              // alarm output held while active, or 0
+	preset   int              // PTZ preset gone to when fired, or 0
+	capture  *capture         // snapshots taken when the rule fires, or nil
+	hold     time.Duration
+
+	// applying serializes applying the rule and restoring it, so that a
+	// restore still under way cannot undo the rule firing again.
+	applying sync.Mutex
+}
+
+// compile validates a rule and fills in defaults: motion events, at any time,
+// IR on for five minutes, then back to auto. lat and lon, which may be nil,
+// are the coordinates of windows that start or end at sunset or sunrise.
+func (rc ruleConfig) compile(lat, lon *float64) (*rule, error) {
+	r := &rule{events: make(map[string]bool), hold: rc.For, output: rc.Output, preset: rc.Preset}
+	events := rc.Events
+	if len(events) == 0 {
+		events = []string{string(hikvision.EventMotion)}
+	}
+	for _, e := range events {
+		r.events[strings.ToLower(e)] = true
+	}
+	if r.hold <= 0 {
+		r.hold = 5 * time.Minute
+	}
+
+	if rc.Output < 0 {
+		return nil, fmt.Errorf("invalid alarm output %d", rc.Output)
+	}
+	if rc.Preset < 0 {
+		return nil, fmt.Errorf("invalid preset %d", rc.Preset)
+	}
+	var err error
+	if rc.Snapshot != nil {
+		if r.capture, err = rc.Snapshot.compile(); err != nil {
+			return nil, err
+		}
+	}
+	if (rc.Output == 0 && rc.Preset == 0 && rc.Snapshot == nil) || rc.IR != "" || rc.Then != "" {
+		if r.ir, err = ruleMode(rc.IR, hikvision.IRModeOpen); err != nil {
+			return nil, err
+		}
+		if r.then, err = ruleMode(rc.Then, hikvision.IRModeAuto); err != nil {
+			return nil, err
+		}
+	}
+	if (rc.From == "") != (rc.To == "") {
+		return nil, fmt.Errorf("rule needs both from and to, or neither")
+	}
+	if rc.From != "" {
+		r.window = true
+		if r.from, err = parseRuleTime(rc.From); err != nil {
+			return nil, err
+		}
+		if r.to, err = parseRuleTime(rc.To); err != nil {
+			return nil, err
+		}
+		if r.from.sun != "" || r.to.sun != "" {
+			if lat == nil || lon == nil {
+				return nil, fmt.Errorf("rule from or to at sunset or sunrise needs latitude and longitude in the schedule section (or --lat and --lon)")
+			}
+			r.lat, r.lon = *lat, *lon
+		}
+	}
+	return r, nil
+}
+
+// ruleTime is one end of the daily window of a rule: a clock time "HH:MM",
+// or "sunset" or "sunrise" shifted by an optional offset such as
+// "sunset-15m" or "sunrise+1h".
+type ruleTime struct {
+	sun    string        // "sunset" or "sunrise", or empty for clock
+	clock  int           // minutes after midnight, when sun is empty
+	offset time.Duration // added to sun
+}
+
+func parseRuleTime(s string) (ruleTime, error) {
+	for _, sun := range []string{"sunset", "sunrise"} {
+		rest, ok := strings.CutPrefix(strings.ToLower(s), sun)
+		if !ok {
+			continue
+		}
+		t := ruleTime{sun: sun}
+		if rest != "" {
+			var err error
+			if t.offset, err = time.ParseDuration(rest); err != nil || (rest[0] != '+' && rest[0] != '-') {
+				return ruleTime{}, fmt.Errorf("invalid time %q: want HH:MM, or %s with an offset such as %s-15m", s, sun, sun)
+			}
+		}
+		return t, nil
+	}
+	m, err := parseClock(s)
+	if err != nil {
+		return ruleTime{}, fmt.Errorf("invalid time %q: want HH:MM, sunset or sunrise", s)
+	}
+	return ruleTime{clock: m}, nil
+}
+
+func ruleMode(name string, def hikvision.IRMode) (hikvision.IRMode, error) {
+	if name == "" {
+		return def, nil
+	}
+	mode, ok := irModes[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown IR mode %q — must be on, off, or auto", name)
+	}
+	return mode, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// inWindow reports whether now falls in the rule's daily window, which may
+// wrap past midnight.
+func (r *rule) inWindow(now time.Time) bool {
+	if !r.window {
+		return true
+	}
+	from, to := r.minutes(r.from, now), r.minutes(r.to, now)
+	if from == to {
+		return true
+	}
+	m := now.Hour()*60 + now.Minute()
+	if from < to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
+
+// minutes resolves t into minutes after midnight on the date of now. On a
+// day without sunset the sun rises at 0 and sets at 1440, and on one without
+// sunrise the other way round, so that a window from sunset to sunrise is
+// never open in polar day and always open in polar night.
+func (r *rule) minutes(t ruleTime, now time.Time) int {
+	if t.sun == "" {
+		return t.clock
+	}
+	rise, set, polar := sunTimes(now, r.lat, r.lon)
+	if polar != 0 {
+		if (t.sun == "sunrise") == (polar > 0) {
+			return 0
+		}
+		return 24 * 60
+	}
+	at := rise
+	if t.sun == "sunset" {
+		at = set
+	}
+	at = at.Add(t.offset)
+	return at.Hour()*60 + at.Minute()
+}
+
+func (r *rule) matches(e hikvision.Event, now time.Time) bool {
+	return e.Active() && r.events[strings.ToLower(string(e.Type))] && r.inWindow(now)
+}
+
+// ruleEngine applies event rules to cameras. Each rule that fires holds its
+// IR mode and output until hold has passed without another matching event.
+type ruleEngine struct {
+	rules   map[string][]*rule      // by camera name
+	buffers map[string]*frameBuffer // by camera name, for rules with snapshots before
+	store   snapshotStore
+	notify  *notifier
+	ctx     context.Context
+
+	mu     sync.Mutex
+	timers map[*rule]*time.Timer
+}
+
+// runRules watches the alert streams of every target with rules and applies
+// them until ctx is cancelled, then restores each active rule. Rules that
+// fire are notified, with their snapshot if they take one.
+func runRules(ctx context.Context, targets []target, lat, lon *float64, store snapshotStore, notify *notifier) error {
+	e := &ruleEngine{
+		rules:   make(map[string][]*rule),
+		buffers: make(map[string]*frameBuffer),
+		store:   store,
+		notify:  notify,
+		ctx:     ctx,
+		timers:  make(map[*rule]*time.Timer),
+	}
+	var watched []target
+	snapshots := false
+	for _, t := range targets {
+		var every time.Duration
+		before := 0
+		for i, rc := range t.rules {
+			r, err := rc.compile(lat, lon)
+			if err != nil {
+				return fmt.Errorf("%s: rule %d: %w", t.name, i+1, err)
+			}
+			e.rules[t.name] = append(e.rules[t.name], r)
+			if c := r.capture; c != nil {
+				snapshots = true
+				if c.before > 0 {
+					before = max(before, c.before)
+					if every == 0 || c.every < every {
+						every = c.every
+					}
+				}
+			}
+		}
+		if len(e.rules[t.name]) > 0 {
+			watched = append(watched, t)
+		}
+		if before > 0 {
+			b := &frameBuffer{size: before}
+			e.buffers[t.name] = b
+			go b.run(ctx, t, every)
+		}
+	}
+	if len(watched) == 0 {
+		return fmt.Errorf("no camera has rules configured")
+	}
+	if snapshots {
+		if err := e.store.validate(); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("rules: watching", "cameras", len(watched))
+	watchEvents(ctx, watched, e.handle)
+	e.restoreAll(watched)
+	return nil
+}
+
+// handle fires every rule of t matching the event.
+func (e *ruleEngine) handle(t target, ev hikvision.Event) {
+	now := time.Now()
+	for _, r := range e.rules[t.name] {
+		if r.matches(ev, now) {
+			e.fire(t, r, ev)
+		}
+	}
+}
+
+// snap takes the snapshots of a rule that fired: the buffered frames from
+// before the event, one now and the rest of the burst after it. It saves
+// them to the store and notifies the event with the first snapshot taken
+// after it, without waiting for the rest of the burst.
+func (e *ruleEngine) snap(t target, r *rule, ev hikvision.Event) {
+	ctx, c := e.ctx, r.capture
+	channel := ev.ChannelID
+	if channel == 0 {
+		channel = t.channel
+	}
+	var files []string
+	save := func(f frame) {
+		path, err := e.store.save(t.name, channel, ev.Type, f)
+		if err != nil {
+			slog.Error("rules: save snapshot", "camera", t.name, "err", err)
+			return
+		}
+		files = append(files, path)
+	}
+	for _, f := range e.buffers[t.name].last(c.before) {
+		save(f)
+	}
+	for i := 0; i <= c.after; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.every):
+			}
+		}
+		data, err := grab(ctx, t.cam, channel)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("rules: snapshot failed", "camera", t.name, "err", err)
+			}
+			if i > 0 {
+				continue
+			}
+		} else {
+			save(frame{at: time.Now(), data: data})
+		}
+		if i == 0 {
+			if data != nil {
+				ev.Pictures = append(ev.Pictures, hikvision.EventPicture{Name: "snapshot.jpg", ContentType: "image/jpeg", Data: data})
+			}
+			e.notify.event(ctx, t.name, ev, files)
+		}
+	}
+	if len(files) > 0 {
+		slog.Info("rules: saved snapshots", "camera", t.name, "count", len(files), "last", files[len(files)-1])
+	}
+}
+
+// describe summarises what the rule does while active, or afterwards.
+func (r *rule) describe(active bool) string {
+	var parts []string
+	if mode := r.then; mode != "" {
+		if active {
+			mode = r.ir
+		}
+		parts = append(parts, "IR "+irModeName(mode))
+	}
+	if r.output > 0 {
+		parts = append(parts, fmt.Sprintf("output %d %s", r.output, onOffName(active)))
+	}
+	if active && r.preset > 0 {
+		parts = append(parts, fmt.Sprintf("preset %d", r.preset))
+	}
+	if active && r.capture != nil {
+		parts = append(parts, "snapshot")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// apply sets the rule's IR mode and alarm output for the active or the
+// restored state, and goes to its preset when active.
+func (r *rule) apply(t target, active bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if mode := r.then; mode != "" {
+		if active {
+			mode = r.ir
+		}
+		if err := t.cam.SetIRModeContext(ctx, mode); err != nil {
+			slog.Error("rules: set IR", "camera", t.name, "mode", irModeName(mode), "err", err)
+		}
+	}
+	if r.output > 0 {
+		if err := t.cam.SetAlarmOutput(ctx, r.output, active); err != nil {
+			slog.Error("rules: set output", "camera", t.name, "output", r.output, "state", onOffName(active), "err", err)
+		}
+	}
+	if active && r.preset > 0 {
+		if err := t.cam.GotoPTZPreset(ctx, t.channel, r.preset); err != nil {
+			slog.Error("rules: go to preset", "camera", t.name, "preset", r.preset, "err", err)
+		}
+	}
+}
+
+// fire applies a rule, or extends it if the rule is already active.
+func (e *ruleEngine) fire(t target, r *rule, ev hikvision.Event) {
+	e.mu.Lock()
+	if timer, ok := e.timers[r]; ok && timer.Stop() {
+		timer.Reset(r.hold)
+		e.mu.Unlock()
+		return
+	}
+	// The rule is not active, or its timer has fired and the restore is
+	// under way or about to be: fire it anew, which makes a restore that
+	// has not started yet skip.
+	delete(e.timers, r)
+	e.mu.Unlock()
+
+	slog.Info("rules: event", "camera", t.name, "type", ev.Type, "rule", r.describe(true), "hold", r.hold)
+	if r.capture != nil {
+		go e.snap(t, r, ev)
+	} else {
+		e.notify.event(e.ctx, t.name, ev, nil)
+	}
+
+	r.applying.Lock()
+	defer r.applying.Unlock()
+	r.apply(t, true)
+	e.mu.Lock()
+	var timer *time.Timer
+	timer = time.AfterFunc(r.hold, func() {
+		// Taking applying first also waits for timer to be set.
+		r.applying.Lock()
+		defer r.applying.Unlock()
+		e.expire(t, r, timer)
+	})
+	e.timers[r] = timer
+	e.mu.Unlock()
+}
+
+// expire restores a rule whose timer has fired, unless timer is no longer
+// the rule's because it fired again or was restored since. The caller holds
+// r.applying.
+func (e *ruleEngine) expire(t target, r *rule, timer *time.Timer) {
+	e.mu.Lock()
+	current := e.timers[r] == timer
+	if current {
+		delete(e.timers, r)
+	}
+	e.mu.Unlock()
+	if current {
+		slog.Info("rules: rule expired", "camera", t.name, "rule", r.describe(false))
+		r.apply(t, false)
+	}
+}
+
+// restoreAll stops every active rule and restores it. The rules are
+// restored after e.mu is released, as each may take a minute.
+func (e *ruleEngine) restoreAll(targets []target) {
+	type active struct {
+		t target
+		r *rule
+	}
+	var restore []active
+	e.mu.Lock()
+	for _, t := range targets {
+		for _, r := range e.rules[t.name] {
+			if timer, ok := e.timers[r]; ok {
+				// A timer that has already fired skips its restore
+				// once it is no longer the rule's.
+				timer.Stop()
+				delete(e.timers, r)
+				restore = append(restore, active{t, r})
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for _, a := range restore {
+		a.r.applying.Lock()
+		a.r.apply(a.t, false)
+		a.r.applying.Unlock()
+	}
+}
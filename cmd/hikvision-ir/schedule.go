@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// scheduleConfig is the schedule section of a --config file. Day runs from
+// sunrise to sunset at the given coordinates, shifted by the offsets, or from
+// day_start to night_start when fixed times are given instead:
+//
+//	schedule:
+//	  latitude: 51.5
+//	  longitude: -0.12
+//	  sunset_offset: -20m   # switch to night 20 minutes before sunset
+//	  day:   {ir: off, daynight: day}
+//	  night: {ir: on, daynight: night, image: {shutter: 1/50}, tracking: on}
+type scheduleConfig struct {
+	Latitude      *float64      `yaml:"latitude"`
+	Longitude     *float64      `yaml:"longitude"`
+	SunriseOffset time.Duration `yaml:"sunrise_offset"`
+	SunsetOffset  time.Duration `yaml:"sunset_offset"`
+	DayStart      string        `yaml:"day_start"`
+	NightStart    string        `yaml:"night_start"`
+	Day           phaseConfig   `yaml:"day"`
+	Night         phaseConfig   `yaml:"night"`
+}
+
+// phaseConfig is what schedule and auto-lux apply at the start of day or
+// night. Empty fields are left alone.
+type phaseConfig struct {
+	IR       string `yaml:"ir"`       // on | off | auto
+	DayNight string `yaml:"daynight"` // day | night | auto
+	// Image holds image action settings, e.g. a shorter shutter at night
+	// to limit motion blur under IR.
+	Image map[string]string `yaml:"image"`
+	// Tracking switches the auto-tracking of smart PTZ cameras, e.g. off
+	// by day while the site is staffed.
+	Tracking string `yaml:"tracking"` // on | off
+}
+
+// validate checks the schedule and fills in the default phases.
+func (s *scheduleConfig) validate() error {
+	fixed := s.DayStart != "" || s.NightStart != ""
+	switch {
+	case fixed && (s.DayStart == "" || s.NightStart == ""):
+		return fmt.Errorf("schedule needs both day_start and night_start")
+	case fixed:
+		day, err := parseClock(s.DayStart)
+		if err != nil {
+			return err
+		}
+		night, err := parseClock(s.NightStart)
+		if err != nil {
+			return err
+		}
+		if day >= night {
+			return fmt.Errorf("schedule day_start must be before night_start")
+		}
+	case s.Latitude == nil || s.Longitude == nil:
+		return fmt.Errorf("schedule needs latitude and longitude (or day_start and night_start)")
+	}
+
+	return validatePhases("schedule", &s.Day, &s.Night)
+}
+
+// validatePhases checks the day and night phases of section and fills in
+// the defaults: IR off and day mode by day, IR on and night mode by night.
+func validatePhases(section string, day, night *phaseConfig) error {
+	if day.empty() && night.empty() {
+		*day = phaseConfig{IR: "off", DayNight: "day"}
+		*night = phaseConfig{IR: "on", DayNight: "night"}
+	}
+	for _, p := range []*phaseConfig{day, night} {
+		if _, err := ruleMode(p.IR, ""); err != nil {
+			return err
+		}
+		switch p.DayNight {
+		case "", "day", "night", "auto":
+		default:
+			return fmt.Errorf("unknown %s daynight %q — must be day, night, or auto", section, p.DayNight)
+		}
+		if err := checkImageSettings(p.Image); err != nil {
+			return err
+		}
+		if p.Tracking != "" {
+			if _, err := onOff("tracking", p.Tracking); err != nil {
+				return fmt.Errorf("%s %w", section, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p phaseConfig) empty() bool {
+	return p.IR == "" && p.DayNight == "" && len(p.Image) == 0 && p.Tracking == ""
+}
+
+// dayWindow returns when day starts and ends on the calendar date of date.
+// polar is +1 for a day without sunset and -1 for one without sunrise.
+func (s *scheduleConfig) dayWindow(date time.Time) (start, end time.Time, polar int) {
+	if s.DayStart != "" {
+		day, _ := parseClock(s.DayStart)
+		night, _ := parseClock(s.NightStart)
+		midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		return midnight.Add(time.Duration(day) * time.Minute), midnight.Add(time.Duration(night) * time.Minute), 0
+	}
+	rise, set, polar := sunTimes(date, *s.Latitude, *s.Longitude)
+	return rise.Add(s.SunriseOffset), set.Add(s.SunsetOffset), polar
+}
+
+// phase reports whether it is night at now and when the phase next changes.
+// During polar day or night the phase is re-evaluated at midnight.
+func (s *scheduleConfig) phase(now time.Time) (night bool, next time.Time) {
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	start, end, polar := s.dayWindow(now)
+	switch {
+	case polar != 0:
+		return polar < 0, tomorrow
+	case now.Before(start):
+		return true, start
+	case now.Before(end):
+		return false, end
+	}
+	if start, _, polar := s.dayWindow(tomorrow); polar == 0 {
+		return true, start
+	}
+	return true, tomorrow
+}
+
+// scheduleRetry is how soon a phase that failed to apply to a camera, such
+// as one that was offline, is tried again.
+const scheduleRetry = time.Minute
+
+// runSchedule applies the current phase to every target, then applies each
+// new phase as it begins, until ctx is cancelled. Cameras the phase failed
+// to apply to are retried every scheduleRetry until it does.
+func runSchedule(ctx context.Context, s scheduleConfig, targets []target, parallel int) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	announced := -1                 // 0 day, 1 night
+	applied := make(map[string]int) // by camera name
+	for {
+		isNight, next := s.phase(time.Now())
+		phase := map[bool]int{false: 0, true: 1}[isNight]
+		p, name := s.Day, "day"
+		if isNight {
+			p, name = s.Night, "night"
+		}
+		if phase != announced {
+			slog.Info("schedule: phase", "phase", name, "until", next.Format("2006-01-02 15:04"))
+			announced = phase
+		}
+
+		var pending []target
+		for _, t := range targets {
+			if last, ok := applied[t.name]; !ok || last != phase {
+				pending = append(pending, t)
+			}
+		}
+		wait := time.Until(next) + time.Second
+		if len(pending) > 0 {
+			failed := applyPhase(ctx, "schedule", p, pending, parallel)
+			for _, t := range pending {
+				if !failed[t.name] {
+					applied[t.name] = phase
+				}
+			}
+			if len(failed) > 0 {
+				slog.Warn("schedule: retrying", "phase", name, "cameras", len(failed), "in", scheduleRetry)
+				wait = min(wait, scheduleRetry)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// applyPhase sets the IR mode, day/night mode, image settings and tracking of
+// a phase on every target, logging failures as by who (schedule or
+// auto-lux). It returns the names of the targets it failed on.
+func applyPhase(ctx context.Context, who string, p phaseConfig, targets []target, parallel int) map[string]bool {
+	var steps []actionArgs
+	if mode, _ := ruleMode(p.IR, ""); mode != "" {
+		steps = append(steps, actionArgs{action: irModeName(mode), brightness: -1})
+	}
+	if p.DayNight != "" {
+		steps = append(steps, actionArgs{action: "daynight", mode: p.DayNight})
+	}
+	if len(p.Image) > 0 {
+		a := actionArgs{action: "image"}
+		for k, v := range p.Image {
+			a.positional = append(a.positional, k+"="+v)
+		}
+		steps = append(steps, a)
+	}
+	if p.Tracking != "" {
+		steps = append(steps, actionArgs{action: "ptz", positional: []string{"tracking", p.Tracking}})
+	}
+	failed := make(map[string]bool)
+	for _, a := range steps {
+		for _, r := range runAll(ctx, targets, a, parallel) {
+			if r.err != nil {
+				slog.Error(who+": apply", "camera", r.name, "action", a.action, "err", r.err)
+				failed[r.name] = true
+			}
+		}
+	}
+	return failed
+}
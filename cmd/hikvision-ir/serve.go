@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// server exposes the configured cameras over a small JSON HTTP API:
+//
+//	GET /cameras                  list camera names
+//	GET /cameras/{name}/status    IR mode, brightness and day/night mode
+//	GET /cameras/{name}/ir        IR mode
+//	PUT /cameras/{name}/ir        set IR mode and/or brightness: {"mode": "on|off|auto", "brightness": 40}
+//	GET /cameras/{name}/snapshot  JPEG from the main stream
+//	GET /metrics                  Prometheus metrics
+//
+// Each camera keeps its client, and with it the digest auth session, for the
+// lifetime of the server.
+type server struct {
+	targets map[string]target
+	names   []string
+}
+
+func newServer(targets []target) *server {
+	s := &server{targets: make(map[string]target, len(targets))}
+	for _, t := range targets {
+		s.targets[t.name] = t
+		s.names = append(s.names, t.name)
+	}
+	return s
+}
+
+// serve runs the HTTP API on addr until ctx is cancelled. Prometheus metrics
+// are served on /metrics, with camera state polled every interval.
+func serve(ctx context.Context, addr string, targets []target, interval time.Duration, notify *notifier) error {
+	m := newMetrics()
+	m.notify = notify
+	for _, t := range targets {
+		m.instrument(t)
+	}
+	go m.poll(ctx, targets, interval)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	mux.Handle("/", newServer(targets))
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	slog.Info("serving", "cameras", len(targets), "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "cameras" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	if len(parts) == 1 {
+		if allowMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, s.names)
+		}
+		return
+	}
+	if len(parts) != 3 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	t, ok := s.targets[parts[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown camera %q", parts[1]))
+		return
+	}
+
+	switch parts[2] {
+	case "status":
+		if allowMethod(w, r, http.MethodGet) {
+			s.status(w, r, t)
+		}
+	case "ir":
+		if !allowMethod(w, r, http.MethodGet, http.MethodPut) {
+			return
+		}
+		if r.Method == http.MethodPut {
+			s.putIR(w, r, t)
+		} else {
+			s.getIR(w, r, t)
+		}
+	case "snapshot":
+		if allowMethod(w, r, http.MethodGet) {
+			s.snapshot(w, r, t)
+		}
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found"))
+	}
+}
+
+// allowMethod reports whether r uses one of methods, replying 405 otherwise.
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// irState is the JSON body of the ir resource. Mode is on, off or auto, as
+// on the command line.
+type irState struct {
+	Mode       string `json:"mode,omitempty"`
+	Brightness *int   `json:"brightness,omitempty"`
+}
+
+// statusState is the JSON body of the status resource.
+type statusState struct {
+	irState
+	DayNight hikvision.IRCutFilterMode `json:"daynight,omitempty"`
+}
+
+func (s *server) getIR(w http.ResponseWriter, r *http.Request, t target) {
+	st, err := readIR(r.Context(), t.cam)
+	if err != nil {
+		writeCameraError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+func (s *server) putIR(w http.ResponseWriter, r *http.Request, t target) {
+	var req irState
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return
+	}
+	if req.Mode == "" && req.Brightness == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request must set mode, brightness, or both"))
+		return
+	}
+	mode, ok := irModes[req.Mode]
+	if req.Mode != "" && !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown mode %q — must be on, off, or auto", req.Mode))
+		return
+	}
+
+	ctx := r.Context()
+	if req.Mode != "" {
+		if err := t.cam.SetIRModeContext(ctx, mode); err != nil {
+			writeCameraError(w, err)
+			return
+		}
+	}
+	if req.Brightness != nil {
+		if err := t.cam.SetIRBrightness(ctx, *req.Brightness); err != nil {
+			writeCameraError(w, err)
+			return
+		}
+	}
+	s.getIR(w, r, t)
+}
+
+func (s *server) status(w http.ResponseWriter, r *http.Request, t target) {
+	ctx := r.Context()
+	ir, err := readIR(ctx, t.cam)
+	if err != nil {
+		writeCameraError(w, err)
+		return
+	}
+	st := statusState{irState: ir}
+	if f, err := t.cam.GetIRCutFilter(ctx, t.channel); err == nil {
+		st.DayNight = f.Type
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+func (s *server) snapshot(w http.ResponseWriter, r *http.Request, t target) {
+	img, err := t.cam.Snapshot(r.Context(), hikvision.StreamingChannelID(t.channel, 1))
+	if err != nil {
+		writeCameraError(w, err)
+		return
+	}
+	defer img.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	io.Copy(w, img)
+}
+
+// readIR fetches the IR mode and, where the camera reports it, brightness.
+func readIR(ctx context.Context, cam hikvision.Controller) (irState, error) {
+	mode, err := cam.GetIRModeContext(ctx)
+	if err != nil {
+		return irState{}, err
+	}
+	st := irState{Mode: irModeName(mode)}
+	if b, ok, err := cam.GetIRBrightness(ctx); err == nil && ok {
+		st.Brightness = &b
+	}
+	return st, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeCameraError reports a failed camera request as 502 Bad Gateway, or
+// 501 when the camera does not support the operation.
+func writeCameraError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if errors.Is(err, hikvision.ErrNotSupported) {
+		status = http.StatusNotImplemented
+	}
+	writeError(w, status, err)
+}
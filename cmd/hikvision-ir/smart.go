@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// smart runs "smart export <kind>", which prints a smart event configuration
+// as YAML, and "smart push <kind> <file>", which applies an edited export.
+// kind is line (line crossing) or field (intrusion).
+func smart(ctx context.Context, cam *hikvision.Camera, channel int, positional []string, w io.Writer) error {
+	if len(positional) < 2 {
+		return fmt.Errorf("smart needs export <line|field> or push <line|field> <file>")
+	}
+	cmd, kind := positional[0], positional[1]
+
+	var file []byte
+	switch {
+	case cmd == "push" && len(positional) == 3:
+		var err error
+		if file, err = os.ReadFile(positional[2]); err != nil {
+			return err
+		}
+	case cmd == "push":
+		return fmt.Errorf("smart push needs <line|field> <file>")
+	case cmd != "export":
+		return fmt.Errorf("unknown smart subcommand %q — must be export or push", cmd)
+	}
+
+	switch kind {
+	case "line":
+		ld, err := cam.GetLineDetection(ctx, channel)
+		if err != nil {
+			return err
+		}
+		if cmd == "export" {
+			return encodeYAML(w, ld)
+		}
+		var edit hikvision.LineDetection
+		if err := yaml.Unmarshal(file, &edit); err != nil {
+			return fmt.Errorf("parse %s: %w", positional[2], err)
+		}
+		for i, l := range edit.Lines {
+			for _, old := range ld.Lines {
+				if old.ID == l.ID {
+					edit.Lines[i].Extra = old.Extra
+				}
+			}
+		}
+		ld.Enabled, ld.Lines = edit.Enabled, edit.Lines
+		if err := cam.SetLineDetection(ctx, channel, ld); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "line detection: %d line(s) pushed\n", len(ld.Lines))
+
+	case "field":
+		fd, err := cam.GetFieldDetection(ctx, channel)
+		if err != nil {
+			return err
+		}
+		if cmd == "export" {
+			return encodeYAML(w, fd)
+		}
+		var edit hikvision.FieldDetection
+		if err := yaml.Unmarshal(file, &edit); err != nil {
+			return fmt.Errorf("parse %s: %w", positional[2], err)
+		}
+		for i, r := range edit.Regions {
+			for _, old := range fd.Regions {
+				if old.ID == r.ID {
+					edit.Regions[i].Extra = old.Extra
+				}
+			}
+		}
+		fd.Enabled, fd.Regions = edit.Enabled, edit.Regions
+		if err := cam.SetFieldDetection(ctx, channel, fd); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "intrusion detection: %d region(s) pushed\n", len(fd.Regions))
+
+	default:
+		return fmt.Errorf("unknown smart event %q — must be line or field", kind)
+	}
+	return nil
+}
+
+// encodeYAML writes v as YAML indented like the README examples.
+func encodeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
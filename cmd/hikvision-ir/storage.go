@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// formatTimeout bounds formatting a disk.
+const formatTimeout = 30 * time.Minute
+
+// storage shows the SD cards, disks and network storage of the camera
+// ("storage"), formats one ("storage format [<id>]"; the id may be left
+// out when there is a single card) or sets up network storage ("storage
+// nas", see nas).
+func storage(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	s, err := cam.GetStorage(ctx)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		printStorage(ctx, cam, s, w)
+		return nil
+	}
+	if positional[0] == "nas" {
+		return nas(ctx, cam, s, positional[1:], w)
+	}
+	if positional[0] != "format" {
+		return fmt.Errorf("unknown storage command %q — must be format or nas", positional[0])
+	}
+	var id int
+	switch {
+	case len(positional) == 2:
+		if id, err = strconv.Atoi(positional[1]); err != nil {
+			return fmt.Errorf("invalid storage id %q", positional[1])
+		}
+	case len(positional) == 1 && len(s.HDDs) == 1:
+		id = s.HDDs[0].ID
+	case len(positional) == 1:
+		return fmt.Errorf("storage format needs the id of one of the %d disks", len(s.HDDs))
+	default:
+		return fmt.Errorf("storage format takes one id")
+	}
+	found := false
+	for _, h := range s.HDDs {
+		found = found || h.ID == id
+	}
+	if !found {
+		return fmt.Errorf("no storage %d", id)
+	}
+
+	fmt.Fprintf(w, "storage %d: formatting\n", id)
+	if err := formatHDD(ctx, cam, id, w); err != nil {
+		return err
+	}
+	if s, err = cam.GetStorage(ctx); err != nil {
+		return err
+	}
+	for _, h := range s.HDDs {
+		if h.ID == id {
+			printHDD(ctx, cam, h, w)
+		}
+	}
+	return nil
+}
+
+// printStorage writes a line per disk and network mount, and a warning when
+// nothing can be recorded to.
+func printStorage(ctx context.Context, cam *hikvision.Camera, s *hikvision.Storage, w io.Writer) {
+	healthy := false
+	for _, h := range s.HDDs {
+		printHDD(ctx, cam, h, w)
+		healthy = healthy || h.Healthy()
+	}
+	for _, n := range s.NAS {
+		fmt.Fprintf(w, "nas %d: %s %s, %s\n", n.ID, n.Address, n.Path, n.Status)
+		printSpace(w, n.Capacity, n.FreeSpace)
+		healthy = healthy || n.Status == hikvision.HDDStatusOK
+	}
+	switch {
+	case len(s.HDDs) == 0 && len(s.NAS) == 0:
+		fmt.Fprintln(w, "storage: none — nothing is recorded")
+	case !healthy:
+		fmt.Fprintln(w, "storage: no usable storage — nothing is recorded")
+	}
+}
+
+// printHDD writes the status, space and, where the disk reports them, health
+// of a disk.
+func printHDD(ctx context.Context, cam *hikvision.Camera, h hikvision.HDD, w io.Writer) {
+	kind := h.Type
+	if kind == "" {
+		kind = "disk"
+	}
+	fmt.Fprintf(w, "storage %d: %s %s", h.ID, kind, h.Status)
+	if h.Property != "" && h.Property != "RW" {
+		fmt.Fprintf(w, " (%s)", h.Property)
+	}
+	fmt.Fprintln(w)
+	printSpace(w, h.Capacity, h.FreeSpace)
+	switch h.Status {
+	case hikvision.HDDStatusUnformatted:
+		fmt.Fprintf(w, "  unformatted: run storage format %d to record to it\n", h.ID)
+	case hikvision.HDDStatusError:
+		fmt.Fprintln(w, "  error: reseat or replace the card")
+	}
+	if smart, err := cam.GetSMARTStatus(ctx, h.ID); err == nil {
+		fmt.Fprintf(w, "  health: %s, %d°C, %d days powered on\n", dash(smart.Evaluation), smart.Temperature, smart.PowerOnDays)
+	}
+}
+
+// printSpace writes the capacity and use of storage sized in MiB.
+func printSpace(w io.Writer, capacity, free int64) {
+	if capacity <= 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %.1f GiB, %.1f GiB free (%d%% used)\n", float64(capacity)/1024, float64(free)/1024, (capacity-free)*100/capacity)
+}
+
+// formatHDD formats disk id, printing the progress meanwhile. The
+// per-request timeout is lifted for the format request, which may take as
+// long as formatting; each progress poll gets its own.
+func formatHDD(ctx context.Context, cam *hikvision.Camera, id int, w io.Writer) error {
+	timeout := cam.Timeout
+	cam.Timeout = 0
+	defer func() { cam.Timeout = timeout }()
+	if timeout == 0 {
+		timeout = pollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- cam.FormatHDD(ctx, id) }()
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	answered, last := false, -1
+	for {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+			answered, errc = true, nil
+		case <-t.C:
+		case <-ctx.Done():
+			return fmt.Errorf("format: %w", ctx.Err())
+		}
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		s, err := cam.GetFormatStatus(pctx, id)
+		cancel()
+		if err == nil && s.Formatting && s.Percent != last {
+			fmt.Fprintf(w, "format: %d%%\n", s.Percent)
+			last = s.Percent
+		}
+		// Firmware that answer at once format in the background.
+		if answered && (err != nil || !s.Formatting) {
+			return nil
+		}
+	}
+}
+
+// nasTypes maps CLI names to NAS mount types.
+var nasTypes = map[string]string{
+	"nfs": hikvision.NASMountNFS,
+	"smb": hikvision.NASMountSMB,
+}
+
+// nas shows network storage mount id, 1 unless given, or changes it with
+// server=<ip>, path=<share>, type=nfs|smb, user and pass settings; "storage
+// nas test [<id>]" has the camera try to mount it.
+func nas(ctx context.Context, cam *hikvision.Camera, s *hikvision.Storage, args []string, w io.Writer) error {
+	test := len(args) > 0 && args[0] == "test"
+	if test {
+		args = args[1:]
+		if len(args) > 1 {
+			return fmt.Errorf("storage nas test takes at most an id")
+		}
+	}
+	id := 1
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		var err error
+		if id, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid nas id %q", args[0])
+		}
+		args = args[1:]
+	}
+	i := slices.IndexFunc(s.NAS, func(n hikvision.NAS) bool { return n.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no nas %d", id)
+	}
+	n := &s.NAS[i]
+	if test {
+		if err := cam.TestNAS(ctx, n); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "nas %d: mounted\n", id)
+		return nil
+	}
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	if len(set) > 0 {
+		if err := applyNASSettings(n, set); err != nil {
+			return err
+		}
+		if err := cam.SetNAS(ctx, n); err != nil {
+			return err
+		}
+	}
+	have := map[string]string{"path": n.Path, "type": nameOf(nasTypes, n.MountType), "user": n.User, "status": n.Status}
+	if n.Address != "0.0.0.0" {
+		have["server"] = n.Address
+	}
+	printSettings(w, have, "server", "path", "type", "user", "status")
+	return nil
+}
+
+// applyNASSettings applies the settings nas takes.
+func applyNASSettings(n *hikvision.NAS, set map[string]string) error {
+	for k, v := range set {
+		switch k {
+		case "server":
+			if net.ParseIP(v) == nil {
+				return fmt.Errorf("server=%s: want an IP address", v)
+			}
+			n.Address = v
+		case "path":
+			if !strings.HasPrefix(v, "/") {
+				return fmt.Errorf("path=%s: must start with /", v)
+			}
+			n.Path = v
+		case "type":
+			t, ok := nasTypes[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("type=%s: must be nfs or smb", v)
+			}
+			n.MountType = t
+		case "user":
+			n.User = v
+		case "pass":
+			n.Password = v
+		default:
+			return fmt.Errorf("unknown nas setting %q — must be server, path, type, user or pass", k)
+		}
+	}
+	return nil
+}
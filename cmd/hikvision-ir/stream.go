@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// streamNumbers maps the --stream names to ISAPI stream numbers.
+var streamNumbers = map[string]int{"main": 1, "sub": 2, "third": 3}
+
+// streamID returns the streaming channel ID for --stream on channel. name is
+// main, sub, third, or a stream number.
+func streamID(channel int, name string) (int, error) {
+	n, ok := streamNumbers[strings.ToLower(name)]
+	if !ok {
+		var err error
+		if n, err = strconv.Atoi(name); err != nil || n < 1 || n > 99 {
+			return 0, fmt.Errorf("unknown stream %q — must be main, sub, third, or a stream number", name)
+		}
+	}
+	return hikvision.StreamingChannelID(channel, n), nil
+}
+
+// stream runs a stream subcommand against --stream of channel.
+func stream(ctx context.Context, cam *hikvision.Camera, channel int, a actionArgs, w io.Writer) error {
+	if len(a.positional) == 0 {
+		return fmt.Errorf("stream needs a subcommand: config or url")
+	}
+	id, err := streamID(channel, a.stream)
+	if err != nil {
+		return err
+	}
+	switch a.positional[0] {
+	case "config":
+		return streamConfig(ctx, cam, id, a.positional[1:], w)
+	case "url":
+		fmt.Fprintln(w, cam.RTSPURL(id, a.rtspPort))
+		return nil
+	}
+	return fmt.Errorf("unknown stream subcommand %q — must be config or url", a.positional[0])
+}
+
+// probe checks with RTSP DESCRIBE that --stream of channel is live and prints
+// its codec and resolution. Cameras that do not announce the resolution over
+// RTSP report the configured one.
+func probe(ctx context.Context, cam *hikvision.Camera, channel int, a actionArgs, w io.Writer) error {
+	id, err := streamID(channel, a.stream)
+	if err != nil {
+		return err
+	}
+	info, err := cam.ProbeStream(ctx, id, a.rtspPort)
+	if err != nil {
+		return err
+	}
+	if u, err := url.Parse(cam.RTSPURL(id, a.rtspPort)); err == nil {
+		fmt.Fprintf(w, "url: %s\n", u.Redacted())
+	}
+	fmt.Fprintf(w, "codec: %s\n", info.Codec)
+	if info.Width > 0 {
+		fmt.Fprintf(w, "resolution: %dx%d\n", info.Width, info.Height)
+	} else if s, err := cam.GetStreamConfig(ctx, id); err == nil {
+		fmt.Fprintf(w, "resolution: %dx%d (configured)\n", s.Video.Width, s.Video.Height)
+	}
+	return nil
+}
+
+// videoCodecs maps the codec= values of stream config to codecs.
+var videoCodecs = map[string]hikvision.VideoCodec{
+	"h264":  hikvision.CodecH264,
+	"h265":  hikvision.CodecH265,
+	"mjpeg": hikvision.CodecMJPEG,
+}
+
+// bitrateTypes maps the bitrate= values of stream config to bitrate types.
+var bitrateTypes = map[string]hikvision.BitrateType{
+	"cbr": hikvision.BitrateConstant,
+	"vbr": hikvision.BitrateVariable,
+}
+
+// streamConfig prints the encoding of streaming channel id, or changes the
+// settings given as key=value arguments.
+func streamConfig(ctx context.Context, cam *hikvision.Camera, id int, args []string, w io.Writer) error {
+	set, err := parseSettings(args)
+	if err != nil {
+		return err
+	}
+	s, err := cam.GetStreamConfig(ctx, id)
+	if err != nil {
+		return err
+	}
+	v := &s.Video
+
+	// Apply the bitrate type first so max-bitrate lands in the right field.
+	if val, ok := set["bitrate"]; ok {
+		if v.BitrateType, ok = bitrateTypes[strings.ToLower(val)]; !ok {
+			return fmt.Errorf("bitrate=%s: must be cbr or vbr", val)
+		}
+	}
+	for k, val := range set {
+		var ok bool
+		switch k {
+		case "bitrate":
+		case "codec":
+			if v.Codec, ok = videoCodecs[strings.ToLower(strings.ReplaceAll(val, ".", ""))]; !ok {
+				return fmt.Errorf("codec=%s: must be h264, h265, or mjpeg", val)
+			}
+		case "resolution":
+			width, height, ok := strings.Cut(strings.ToLower(val), "x")
+			v.Width, err = strconv.Atoi(width)
+			if err == nil {
+				v.Height, err = strconv.Atoi(height)
+			}
+			if !ok || err != nil {
+				return fmt.Errorf("resolution=%s: want WIDTHxHEIGHT, e.g. 1920x1080", val)
+			}
+		case "fps":
+			fps, err := strconv.ParseFloat(val, 64)
+			if err != nil || fps <= 0 {
+				return fmt.Errorf("fps=%s: want a frame rate such as 25", val)
+			}
+			v.MaxFrameRate = int(fps * 100)
+		case "max-bitrate":
+			kbps, err := strconv.Atoi(val)
+			if err != nil || kbps <= 0 {
+				return fmt.Errorf("max-bitrate=%s: want kbps, e.g. 4096", val)
+			}
+			if v.BitrateType == hikvision.BitrateConstant {
+				v.ConstantBitrate = kbps
+			} else {
+				v.MaxBitrate = kbps
+			}
+		case "gop":
+			if v.GOPLength, err = strconv.Atoi(val); err != nil || v.GOPLength <= 0 {
+				return fmt.Errorf("gop=%s: want a frame count, e.g. 50", val)
+			}
+		case "smart":
+			on, err := onOff(k, val)
+			if err != nil {
+				return err
+			}
+			v.SmartCodec = &hikvision.SmartCodec{Enabled: on}
+		default:
+			return fmt.Errorf("unknown stream setting %q", k)
+		}
+	}
+	if len(set) > 0 {
+		if err := cam.SetStreamConfig(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "stream: %d\n", s.ID)
+	fmt.Fprintf(w, "codec: %s\n", nameOf(videoCodecs, v.Codec))
+	fmt.Fprintf(w, "resolution: %dx%d\n", v.Width, v.Height)
+	fmt.Fprintf(w, "fps: %s\n", strconv.FormatFloat(float64(v.MaxFrameRate)/100, 'f', -1, 64))
+	fmt.Fprintf(w, "bitrate: %s\n", nameOf(bitrateTypes, v.BitrateType))
+	kbps := v.MaxBitrate
+	if v.BitrateType == hikvision.BitrateConstant {
+		kbps = v.ConstantBitrate
+	}
+	fmt.Fprintf(w, "max-bitrate: %d\n", kbps)
+	if v.GOPLength > 0 {
+		fmt.Fprintf(w, "gop: %d\n", v.GOPLength)
+	}
+	if v.SmartCodec != nil {
+		fmt.Fprintf(w, "smart: %s\n", onOffName(v.SmartCodec.Enabled))
+	}
+	return nil
+}
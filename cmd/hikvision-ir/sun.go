@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// sunTimes returns sunrise and sunset on the local calendar date of day at
+// the given latitude and longitude (degrees, east positive), using the
+// sunrise equation with standard refraction. polar is +1 if the sun never
+// sets that day, -1 if it never rises, and 0 otherwise.
+func sunTimes(day time.Time, lat, lon float64) (rise, set time.Time, polar int) {
+	const rad = math.Pi / 180
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, day.Location())
+	jd := float64(noon.Unix())/86400 + 2440587.5
+	n := math.Round(jd - 2451545.0 + 0.0008)
+
+	jStar := n - lon/360
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	c := 1.9148*math.Sin(m*rad) + 0.02*math.Sin(2*m*rad) + 0.0003*math.Sin(3*m*rad)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := 2451545.0 + jStar + 0.0053*math.Sin(m*rad) - 0.0069*math.Sin(2*lambda*rad)
+
+	sinDecl := math.Sin(lambda*rad) * math.Sin(23.4397*rad)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHour := (math.Sin(-0.833*rad) - math.Sin(lat*rad)*sinDecl) / (math.Cos(lat*rad) * cosDecl)
+	switch {
+	case cosHour < -1:
+		return time.Time{}, time.Time{}, 1
+	case cosHour > 1:
+		return time.Time{}, time.Time{}, -1
+	}
+	hour := math.Acos(cosHour) / rad
+
+	return julianTime(transit-hour/360, day.Location()), julianTime(transit+hour/360, day.Location()), 0
+}
+
+// julianTime converts a Julian date to a time in loc.
+func julianTime(jd float64, loc *time.Location) time.Time {
+	secs := (jd - 2440587.5) * 86400
+	return time.Unix(int64(secs), 0).In(loc)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestSunTimes(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		date      string
+		lat, lon  float64
+		rise, set string // local clock, empty when polar
+		polar     int
+	}{
+		{name: "london summer", zone: "Europe/London", date: "2024-06-21", lat: 51.5074, lon: -0.1278, rise: "04:43", set: "21:21"},
+		{name: "london winter", zone: "Europe/London", date: "2024-12-21", lat: 51.5074, lon: -0.1278, rise: "08:04", set: "15:54"},
+		{name: "sydney summer", zone: "Australia/Sydney", date: "2024-12-21", lat: -33.8688, lon: 151.2093, rise: "05:41", set: "20:05"},
+		{name: "sydney winter", zone: "Australia/Sydney", date: "2024-06-21", lat: -33.8688, lon: 151.2093, rise: "07:00", set: "16:54"},
+		{name: "tromso polar day", zone: "Europe/Oslo", date: "2024-06-21", lat: 69.6496, lon: 18.9560, polar: 1},
+		{name: "tromso polar night", zone: "Europe/Oslo", date: "2024-12-21", lat: 69.6496, lon: 18.9560, polar: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			day, err := time.ParseInLocation("2006-01-02", tt.date, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rise, set, polar := sunTimes(day, tt.lat, tt.lon)
+			if polar != tt.polar {
+				t.Fatalf("sunTimes(%s) polar = %d, want %d", tt.date, polar, tt.polar)
+			}
+			if polar != 0 {
+				return
+			}
+			check := func(what string, got time.Time, want string) {
+				w, err := time.ParseInLocation("2006-01-02 15:04", tt.date+" "+want, loc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := got.Sub(w); d < -3*time.Minute || d > 3*time.Minute {
+					t.Errorf("sunTimes(%s) %s = %s, want %s ± 3m", tt.date, what, got.Format("15:04 MST"), w.Format("15:04 MST"))
+				}
+			}
+			check("sunrise", rise, tt.rise)
+			check("sunset", set, tt.set)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// userLevels maps CLI names to user levels.
+var userLevels = map[string]string{
+	"administrator": hikvision.UserAdministrator,
+	"operator":      hikvision.UserOperator,
+	"viewer":        hikvision.UserViewer,
+}
+
+// permissions maps CLI permission names to fields of a RemotePermission.
+var permissions = map[string]func(*hikvision.RemotePermission) *bool{
+	"preview":  func(p *hikvision.RemotePermission) *bool { return &p.Preview },
+	"playback": func(p *hikvision.RemotePermission) *bool { return &p.PlayBack },
+	"record":   func(p *hikvision.RemotePermission) *bool { return &p.Record },
+	"ptz":      func(p *hikvision.RemotePermission) *bool { return &p.PTZControl },
+	"config":   func(p *hikvision.RemotePermission) *bool { return &p.ParameterConfig },
+	"log":      func(p *hikvision.RemotePermission) *bool { return &p.LogOrStateCheck },
+	"upgrade":  func(p *hikvision.RemotePermission) *bool { return &p.Upgrade },
+	"talk":     func(p *hikvision.RemotePermission) *bool { return &p.VoiceTalk },
+	"reboot":   func(p *hikvision.RemotePermission) *bool { return &p.RestartOrShutdown },
+	"alarm":    func(p *hikvision.RemotePermission) *bool { return &p.AlarmOutOrUpload },
+}
+
+// users lists the camera's accounts or adds, changes or deletes one:
+//
+//	user add <name> password=<pw> level=operator|viewer [perm=preview,playback,...]
+//	user set <name> [password=<pw>] [level=...] [perm=...]
+//	user delete <name>
+func users(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	list, err := cam.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		for _, u := range list {
+			printUser(ctx, cam, w, u)
+		}
+		return nil
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("user %s needs a user name", positional[0])
+	}
+	cmd, name := positional[0], positional[1]
+	var u *hikvision.User
+	for i := range list {
+		if list[i].Name == name {
+			u = &list[i]
+		}
+	}
+
+	switch cmd {
+	case "add", "set":
+		set, err := parseSettings(positional[2:])
+		if err != nil {
+			return err
+		}
+		if cmd == "add" {
+			if u != nil {
+				return fmt.Errorf("user %q already exists", name)
+			}
+			if set["password"] == "" || set["level"] == "" {
+				return fmt.Errorf("user add needs password= and level=")
+			}
+			u = &hikvision.User{Name: name}
+		} else if u == nil {
+			return fmt.Errorf("no user %q", name)
+		}
+		return saveUser(ctx, cam, u, cmd == "add", set, w)
+	case "delete":
+		if u == nil {
+			return fmt.Errorf("no user %q", name)
+		}
+		if err := cam.DeleteUser(ctx, u.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "user %s: deleted\n", name)
+		return nil
+	}
+	return fmt.Errorf("unknown user command %q — must be add, set or delete", cmd)
+}
+
+func saveUser(ctx context.Context, cam *hikvision.Camera, u *hikvision.User, create bool, set map[string]string, w io.Writer) error {
+	var perms []string
+	for k, v := range set {
+		switch k {
+		case "password":
+			u.Password = v
+		case "level":
+			level, ok := userLevels[strings.ToLower(v)]
+			if !ok {
+				return fmt.Errorf("level=%s: must be administrator, operator or viewer", v)
+			}
+			u.Level = level
+		case "perm":
+			perms = strings.Split(v, ",")
+			for _, p := range perms {
+				if _, ok := permissions[p]; !ok {
+					return fmt.Errorf("unknown permission %q", p)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown user setting %q", k)
+		}
+	}
+
+	if create {
+		if err := cam.CreateUser(ctx, u); err != nil {
+			return err
+		}
+	} else if set["password"] != "" || set["level"] != "" {
+		if err := cam.SetUser(ctx, u); err != nil {
+			return err
+		}
+	}
+	if perms != nil {
+		if err := setPermissions(ctx, cam, u.Name, perms); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "user %s: saved\n", u.Name)
+	return nil
+}
+
+// setPermissions grants exactly the named permissions to a user. The user is
+// looked up again by name, since CreateUser does not return the new ID.
+func setPermissions(ctx context.Context, cam *hikvision.Camera, name string, perms []string) error {
+	list, err := cam.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+	id := 0
+	for _, u := range list {
+		if u.Name == name {
+			id = u.ID
+		}
+	}
+	if id == 0 {
+		return fmt.Errorf("user %q not found after saving", name)
+	}
+	p, err := cam.GetUserPermission(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, field := range permissions {
+		*field(&p.Remote) = false
+	}
+	for _, name := range perms {
+		*permissions[name](&p.Remote) = true
+	}
+	return cam.SetUserPermission(ctx, p)
+}
+
+func printUser(ctx context.Context, cam *hikvision.Camera, w io.Writer, u hikvision.User) {
+	fmt.Fprintf(w, "user %d: %s (%s)", u.ID, u.Name, strings.ToLower(u.Level))
+	if u.Level != hikvision.UserAdministrator {
+		p, err := cam.GetUserPermission(ctx, u.ID)
+		if err == nil {
+			var granted []string
+			for name, field := range permissions {
+				if *field(&p.Remote) {
+					granted = append(granted, name)
+				}
+			}
+			sort.Strings(granted)
+			fmt.Fprintf(w, " perm=%s", strings.Join(granted, ","))
+		} else if !errors.Is(err, hikvision.ErrNotSupported) {
+			fmt.Fprintf(w, " (permissions: %v)", err)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchStatus polls the IR state of every target each interval, with
+// withDayNight the day/night mode too, and prints it with a timestamp
+// whenever it changes, until ctx is cancelled.
+func watchStatus(ctx context.Context, targets []target, interval time.Duration, withDayNight bool, w io.Writer) error {
+	if interval <= 0 {
+		return fmt.Errorf("status --watch needs a positive --interval")
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		prefix := ""
+		if len(targets) > 1 {
+			prefix = t.name + ": "
+		}
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			var last string
+			for {
+				state := pollStatus(ctx, t, withDayNight)
+				if ctx.Err() != nil {
+					return
+				}
+				if state != last {
+					mu.Lock()
+					fmt.Fprintf(w, "%s %s%s\n", time.Now().Format("2006-01-02 15:04:05"), prefix, state)
+					mu.Unlock()
+					last = state
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(t)
+	}
+	wg.Wait()
+	return nil
+}
+
+// pollStatus returns the status output of t on one line, or the error that
+// prevented reading it.
+func pollStatus(ctx context.Context, t target, withDayNight bool) string {
+	var buf bytes.Buffer
+	if err := run(ctx, t, actionArgs{action: "status"}, &buf); err != nil {
+		return "error: " + err.Error()
+	}
+	if withDayNight {
+		if err := dayNight(ctx, t.cam, t.channel, "", "", &buf); err != nil {
+			fmt.Fprintf(&buf, "day/night: error: %v\n", err)
+		}
+	}
+	return strings.Join(strings.Split(strings.TrimSpace(buf.String()), "\n"), ", ")
+}
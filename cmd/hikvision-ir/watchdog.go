@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// watchdogConfig is the watchdog section of a --config file. The watchdog
+// takes a snapshot of each camera every interval and, when the image stays
+// dark while the camera is in auto IR mode with the IR off, recovers it:
+//
+//	watchdog:
+//	  interval: 1m
+//	  dark: 20       # average luminance (0-255) below which the image is dark
+//	  bright: 40     # ...and above which it is no longer
+//	  samples: 3     # dark snapshots in a row before recovering
+//	  recover: ir    # ir: force IR on for hold, then back to auto; or reboot
+//	  hold: 30m      # also the least time between two reboots
+type watchdogConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Dark     float64       `yaml:"dark"`
+	Bright   float64       `yaml:"bright"`
+	Samples  int           `yaml:"samples"`
+	Recover  string        `yaml:"recover"`
+	Hold     time.Duration `yaml:"hold"`
+}
+
+// validate checks the watchdog settings and fills in the defaults.
+func (c *watchdogConfig) validate() error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("watchdog needs a positive interval")
+	}
+	if c.Dark == 0 {
+		c.Dark = 20
+	}
+	if c.Bright == 0 {
+		c.Bright = max(40, c.Dark)
+	}
+	if c.Samples == 0 {
+		c.Samples = 3
+	}
+	if c.Recover == "" {
+		c.Recover = "ir"
+	}
+	if c.Hold == 0 {
+		c.Hold = 30 * time.Minute
+	}
+	switch {
+	case c.Dark < 0 || c.Bright > 255 || c.Bright < c.Dark:
+		return fmt.Errorf("watchdog needs 0 <= dark <= bright <= 255")
+	case c.Samples < 1:
+		return fmt.Errorf("watchdog samples must be at least 1")
+	case c.Recover != "ir" && c.Recover != "reboot":
+		return fmt.Errorf("unknown watchdog recover %q — must be ir or reboot", c.Recover)
+	}
+	return nil
+}
+
+// runWatchdog watches every target until ctx is cancelled, then hands the
+// cameras whose IR it forced on back to auto.
+func runWatchdog(ctx context.Context, c watchdogConfig, targets []target, notify *notifier) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	slog.Info("watchdog: watching", "cameras", len(targets), "interval", c.Interval, "recover", c.Recover)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			w := &watchdog{watchdogConfig: c, t: t, notify: notify}
+			w.run(ctx)
+		}(t)
+	}
+	wg.Wait()
+	return nil
+}
+
+// watchdog is the state of the watchdog of one camera.
+type watchdog struct {
+	watchdogConfig
+	t      target
+	notify *notifier
+
+	// dark counts the dark snapshots in a row. It is only reset by a
+	// bright one, so that an image hovering between dark and bright
+	// neither recovers nor clears.
+	dark int
+	// forced is when the watchdog forced the IR on, and rebooted when it
+	// last rebooted the camera; zero if it did not.
+	forced, rebooted time.Time
+}
+
+func (w *watchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		w.check(ctx)
+		select {
+		case <-ctx.Done():
+			if !w.forced.IsZero() {
+				// ctx is done: give the camera back with a fresh one.
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				w.restore(ctx)
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check takes one sample and recovers the camera if it is stuck.
+func (w *watchdog) check(ctx context.Context) {
+	name, cam, channel := w.t.name, w.t.cam, w.t.channel
+	if !w.forced.IsZero() {
+		if time.Since(w.forced) >= w.Hold {
+			w.restore(ctx)
+		}
+		return
+	}
+	// Give a rebooted camera hold to come back and settle.
+	if !w.rebooted.IsZero() && time.Since(w.rebooted) < w.Hold {
+		return
+	}
+
+	mode, err := cam.GetChannelIRMode(ctx, channel)
+	w.notify.observe(ctx, name, mode, err)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("watchdog: IR mode", "camera", name, "err", err)
+		}
+		return
+	}
+	data, err := grab(ctx, cam, channel)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("watchdog: snapshot", "camera", name, "err", err)
+		}
+		return
+	}
+	stats, err := snapshotLuma(data)
+	if err != nil {
+		slog.Warn("watchdog: snapshot", "camera", name, "err", err)
+		return
+	}
+
+	switch luma := stats.mean; {
+	case luma > w.Bright:
+		w.dark = 0
+	case luma < w.Dark:
+		w.dark++
+	}
+	// Only auto mode gets stuck: IR forced off was asked for, and a dark
+	// image with IR forced on is not the watchdog's to fix.
+	if mode != hikvision.IRModeAuto || w.dark < w.Samples {
+		return
+	}
+	slog.Warn("watchdog: dark image in auto IR mode", "camera", name, "luma", int(stats.mean), "samples", w.dark)
+	w.dark = 0
+	switch w.Recover {
+	case "ir":
+		if err := cam.SetChannelIRMode(ctx, channel, hikvision.IRModeOpen); err != nil {
+			slog.Error("watchdog: force IR on", "camera", name, "err", err)
+			return
+		}
+		slog.Info("watchdog: IR forced on", "camera", name, "until", time.Now().Add(w.Hold).Format("15:04"))
+		w.forced = time.Now()
+		w.notify.observe(ctx, name, hikvision.IRModeOpen, nil)
+	case "reboot":
+		if err := cam.Reboot(ctx); err != nil {
+			slog.Error("watchdog: reboot", "camera", name, "err", err)
+			return
+		}
+		slog.Info("watchdog: rebooted", "camera", name)
+		w.rebooted = time.Now()
+	}
+}
+
+// restore hands the IR of a camera the watchdog forced on back to auto.
+func (w *watchdog) restore(ctx context.Context) {
+	if err := w.t.cam.SetChannelIRMode(ctx, w.t.channel, hikvision.IRModeAuto); err != nil {
+		slog.Error("watchdog: restore IR auto", "camera", w.t.name, "err", err)
+		return
+	}
+	slog.Info("watchdog: IR back to auto", "camera", w.t.name)
+	w.forced = time.Time{}
+	w.notify.observe(ctx, w.t.name, hikvision.IRModeAuto, nil)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+)
+
+// webhookConfig is one entry of the webhooks section of a --config file:
+//
+//	webhooks:
+//	  - url: https://hooks.example.com/cameras
+//	    on: [offline, online, event]
+//	    headers: {Authorization: Bearer secret}
+//	    body: '{"text": {{printf "%s is %s" .Camera .Kind | json}}}'
+type webhookConfig struct {
+	URL string `yaml:"url"`
+	// On lists the notification kinds sent: ir, offline, online and
+	// event. Empty means all of them.
+	On      []string          `yaml:"on"`
+	Headers map[string]string `yaml:"headers"`
+	// Body is a text/template of the request body, executed on a
+	// notification, with json to quote a value. Empty means the
+	// notification as JSON.
+	Body string `yaml:"body"`
+	// Retries is how often a failed delivery is retried, with a doubling
+	// backoff from 1s; defaults to 3.
+	Retries *int `yaml:"retries"`
+}
+
+// notifyClient sends webhook and chat notifications.
+var notifyClient = &http.Client{Timeout: 30 * time.Second}
+
+// webhookSink validates c and returns its sink, which POSTs to every camera's
+// notifications.
+func webhookSink(c webhookConfig) (*sink, error) {
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", c.URL)
+	}
+	if err := checkKinds(c.On); err != nil {
+		return nil, err
+	}
+	var body *template.Template
+	if c.Body != "" {
+		var err error
+		if body, err = template.New("body").Funcs(template.FuncMap{"json": toJSON}).Parse(c.Body); err != nil {
+			return nil, err
+		}
+	}
+	s := &sink{name: c.URL, on: c.On, retries: retries(c.Retries)}
+	s.send = func(ctx context.Context, note notification, _ []byte) error {
+		var buf bytes.Buffer
+		if body == nil {
+			if err := json.NewEncoder(&buf).Encode(note); err != nil {
+				return err
+			}
+		} else if err := body.Execute(&buf, note); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, &buf)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range c.Headers {
+			req.Header.Set(k, v)
+		}
+		return postNotification(req)
+	}
+	return s, nil
+}
+
+// retries returns a configured retry count, 3 if unset.
+func retries(n *int) int {
+	if n == nil {
+		return 3
+	}
+	return max(*n, 0)
+}
+
+// toJSON is the json template function.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// postNotification sends req and fails unless it is answered with a 2xx
+// status.
+func postNotification(req *http.Request) error {
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// wifiSecurities maps camera Wi-Fi security modes to the names wifi prints.
+var wifiSecurities = map[string]string{
+	"open": hikvision.WiFiSecurityNone,
+	"wep":  hikvision.WiFiSecurityWEP,
+	"wpa":  hikvision.WiFiSecurityWPA,
+	"wpa2": hikvision.WiFiSecurityWPA2,
+}
+
+// wifi shows the Wi-Fi connection of the camera with its signal strength,
+// lists the networks in range ("wifi scan"), joins one ("wifi join <ssid>
+// [<key>]") or turns Wi-Fi off ("wifi off").
+func wifi(ctx context.Context, cam *hikvision.Camera, positional []string, w io.Writer) error {
+	iface, wl, err := wifiInterface(ctx, cam)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "scan":
+			if len(positional) > 1 {
+				return fmt.Errorf("wifi scan takes no arguments")
+			}
+			return wifiScan(ctx, cam, iface, w)
+		case "join":
+			if len(positional) < 2 || len(positional) > 3 {
+				return fmt.Errorf("wifi join needs an SSID and, unless the network is open, its key")
+			}
+			key := ""
+			if len(positional) == 3 {
+				key = positional[2]
+			}
+			if err := wifiJoin(ctx, cam, iface, wl, positional[1], key); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "wifi: joining %s — the camera leaves its current network\n", positional[1])
+			return nil
+		case "off":
+			if len(positional) > 1 {
+				return fmt.Errorf("wifi off takes no arguments")
+			}
+			wl.Enabled = false
+			if err := cam.SetWireless(ctx, iface, wl); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown wifi command %q — must be scan, join or off", positional[0])
+		}
+	}
+
+	fmt.Fprintf(w, "interface: %d\n", iface)
+	fmt.Fprintf(w, "enabled: %s\n", onOffName(wl.Enabled))
+	fmt.Fprintf(w, "ssid: %s\n", dash(wl.SSID))
+	fmt.Fprintf(w, "security: %s\n", nameOf(wifiSecurities, wl.Security.Mode))
+	if !wl.Enabled {
+		return nil
+	}
+	aps, err := cam.GetAccessPoints(ctx, iface)
+	if err != nil {
+		return err
+	}
+	for _, ap := range aps {
+		if ap.Connected {
+			fmt.Fprintf(w, "signal: %d%%\n", ap.Signal)
+			return nil
+		}
+	}
+	fmt.Fprintln(w, "signal: not connected")
+	return nil
+}
+
+// wifiInterface returns the first network interface of cam with Wi-Fi and
+// its configuration.
+func wifiInterface(ctx context.Context, cam *hikvision.Camera) (int, *hikvision.Wireless, error) {
+	interfaces, err := cam.GetNetworkInterfaces(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, n := range interfaces {
+		wl, err := cam.GetWireless(ctx, n.ID)
+		switch {
+		case err == nil:
+			return n.ID, wl, nil
+		case !errors.Is(err, hikvision.ErrNotSupported):
+			return 0, nil, err
+		}
+	}
+	return 0, nil, fmt.Errorf("camera has no Wi-Fi interface")
+}
+
+// wifiScan prints the networks interface iface sees, strongest first.
+func wifiScan(ctx context.Context, cam *hikvision.Camera, iface int, w io.Writer) error {
+	aps, err := cam.GetAccessPoints(ctx, iface)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(aps, func(i, j int) bool { return aps[i].Signal > aps[j].Signal })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SSID\tSECURITY\tCHANNEL\tSIGNAL\tSPEED")
+	for _, ap := range aps {
+		ssid := dash(ap.SSID)
+		if ap.Connected {
+			ssid += " (connected)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d%%\t%d Mbit/s\n", ssid, nameOf(wifiSecurities, ap.Security), dash(ap.Channel), ap.Signal, ap.Speed)
+	}
+	return tw.Flush()
+}
+
+// wifiJoin points interface iface at network ssid. The security mode is
+// taken from a scan, falling back to WPA2 for networks out of range so a
+// camera can be set up before it is mounted.
+func wifiJoin(ctx context.Context, cam *hikvision.Camera, iface int, wl *hikvision.Wireless, ssid, key string) error {
+	mode := hikvision.WiFiSecurityWPA2
+	if key == "" {
+		mode = hikvision.WiFiSecurityNone
+	}
+	aps, err := cam.GetAccessPoints(ctx, iface)
+	if err != nil {
+		return err
+	}
+	for _, ap := range aps {
+		if ap.SSID == ssid {
+			mode = ap.Security
+			break
+		}
+	}
+	switch mode {
+	case hikvision.WiFiSecurityNone:
+		if key != "" {
+			return fmt.Errorf("%s is an open network and takes no key", ssid)
+		}
+		wl.Security.WPA = nil
+	case hikvision.WiFiSecurityWPA, hikvision.WiFiSecurityWPA2:
+		if n := len(key); n < 8 || n > 63 {
+			return fmt.Errorf("%s needs a WPA key of 8 to 63 characters", ssid)
+		}
+		if wl.Security.WPA == nil {
+			wl.Security.WPA = &hikvision.WPASettings{Algorithm: "AES"}
+		}
+		wl.Security.WPA.Key, wl.Security.WPA.KeyLength = key, len(key)
+	default:
+		return fmt.Errorf("%s uses %s security, which wifi join does not support — use WPA or WPA2", ssid, strings.ToUpper(nameOf(wifiSecurities, mode)))
+	}
+	wl.Enabled, wl.SSID, wl.Security.Mode = true, ssid, mode
+	return cam.SetWireless(ctx, iface, wl)
+}
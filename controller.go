@@ -0,0 +1,27 @@
+package hikvision
+
+import (
+	"context"
+	"io"
+)
+
+// Controller is the camera control surface most callers need: IR, day/night,
+// snapshots and events. *Camera implements it over ISAPI, routing IR to the
+// backend DetectIREndpoint picks; *PSIAController and *ONVIFController
+// implement it over the legacy PSIA API and ONVIF. Code that takes a
+// Controller can be handed a mock in tests or another backend instead.
+//
+// Snapshot takes a streaming ID, channel*100 + stream as returned by
+// StreamingChannelID, e.g. 101 for the main stream of channel 1.
+type Controller interface {
+	GetIRModeContext(ctx context.Context) (IRMode, error)
+	SetIRModeContext(ctx context.Context, mode IRMode) error
+	GetIRBrightness(ctx context.Context) (brightness int, ok bool, err error)
+	SetIRBrightness(ctx context.Context, brightness int) error
+	GetIRCutFilter(ctx context.Context, channel int) (*IRCutFilter, error)
+	SetIRCutFilterMode(ctx context.Context, channel int, mode IRCutFilterMode) error
+	Snapshot(ctx context.Context, id int) (io.ReadCloser, error)
+	SubscribeEvents(ctx context.Context) (*EventStream, error)
+}
+
+var _ Controller = (*Camera)(nil)
@@ -0,0 +1,44 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeviceInfo identifies a camera, as served by /ISAPI/System/deviceInfo.
+type DeviceInfo struct {
+	XMLName              xml.Name `xml:"DeviceInfo"`
+	DeviceName           string   `xml:"deviceName"`
+	DeviceID             string   `xml:"deviceID"`
+	Model                string   `xml:"model"`
+	SerialNumber         string   `xml:"serialNumber"`
+	MACAddress           string   `xml:"macAddress"`
+	FirmwareVersion      string   `xml:"firmwareVersion"`
+	FirmwareReleasedDate string   `xml:"firmwareReleasedDate"`
+	EncoderVersion       string   `xml:"encoderVersion"`
+	HardwareVersion      string   `xml:"hardwareVersion"`
+	DeviceType           string   `xml:"deviceType"`
+}
+
+// GetDeviceInfo returns the model, serial number, firmware and MAC address of
+// the camera.
+func (c *Camera) GetDeviceInfo(ctx context.Context) (*DeviceInfo, error) {
+	var info DeviceInfo
+	if err := c.getXML(ctx, "/ISAPI/System/deviceInfo", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// FirmwareDate parses FirmwareReleasedDate, such as "build 210628".
+func (d *DeviceInfo) FirmwareDate() (time.Time, error) {
+	s := strings.TrimSpace(strings.TrimPrefix(d.FirmwareReleasedDate, "build"))
+	t, err := time.Parse("060102", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid firmware date %q", d.FirmwareReleasedDate)
+	}
+	return t, nil
+}
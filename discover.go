@@ -0,0 +1,275 @@
+package hikvision
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiscoveredDevice is a camera found on the local network by Discover.
+type DiscoveredDevice struct {
+	// IP is the device's IPv4 address.
+	IP string
+	// HTTPPort is the ISAPI port, or zero if the device did not say.
+	HTTPPort     int
+	MACAddress   string
+	Model        string
+	SerialNumber string
+	// FirmwareVersion is only known from SADP, e.g. "V5.5.0build 170725".
+	FirmwareVersion string
+	// Activated is false for a factory-new device that has no admin
+	// password yet. It is only known from SADP and otherwise true.
+	Activated bool
+	// Source names the protocols that found the device, "sadp", "ws" or
+	// "sadp,ws".
+	Source string
+}
+
+// Host returns the address to reach the device's ISAPI on, with the port if
+// it is not 80.
+func (d DiscoveredDevice) Host() string {
+	if d.HTTPPort == 0 || d.HTTPPort == 80 {
+		return d.IP
+	}
+	return net.JoinHostPort(d.IP, strconv.Itoa(d.HTTPPort))
+}
+
+// The multicast groups of SADP, Hikvision's own discovery protocol, and of
+// ONVIF WS-Discovery.
+const (
+	sadpAddr = "239.255.255.250:37020"
+	wsdAddr  = "239.255.255.250:3702"
+)
+
+// Discover finds cameras on the local network with SADP and WS-Discovery at
+// once, listening until ctx is done, and merges the answers by IP address.
+// It fails only if neither protocol could be used.
+func Discover(ctx context.Context) ([]DiscoveredDevice, error) {
+	var (
+		wg      sync.WaitGroup
+		results [2][]DiscoveredDevice
+		errs    [2]error
+	)
+	for i, probe := range []func(context.Context) ([]DiscoveredDevice, error){DiscoverSADP, DiscoverWS} {
+		wg.Add(1)
+		go func(i int, probe func(context.Context) ([]DiscoveredDevice, error)) {
+			defer wg.Done()
+			results[i], errs[i] = probe(ctx)
+		}(i, probe)
+	}
+	wg.Wait()
+	if errs[0] != nil && errs[1] != nil {
+		return nil, errors.Join(errs[0], errs[1])
+	}
+
+	devices := results[0]
+	byIP := map[string]int{}
+	for i, d := range devices {
+		byIP[d.IP] = i
+	}
+	for _, d := range results[1] {
+		i, ok := byIP[d.IP]
+		if !ok {
+			devices = append(devices, d)
+			continue
+		}
+		devices[i].Source += "," + d.Source
+		if devices[i].HTTPPort == 0 {
+			devices[i].HTTPPort = d.HTTPPort
+		}
+	}
+	sortDevices(devices)
+	return devices, nil
+}
+
+type sadpProbe struct {
+	XMLName xml.Name `xml:"Probe"`
+	UUID    string   `xml:"Uuid"`
+	Types   string   `xml:"Types"`
+}
+
+type sadpMatch struct {
+	XMLName   xml.Name `xml:"ProbeMatch"`
+	Model     string   `xml:"DeviceDescription"`
+	Serial    string   `xml:"DeviceSN"`
+	HTTPPort  int      `xml:"HttpPort"`
+	MAC       string   `xml:"MAC"`
+	IP        string   `xml:"IPv4Address"`
+	Software  string   `xml:"SoftwareVersion"`
+	Activated string   `xml:"Activated"`
+}
+
+// DiscoverSADP finds Hikvision devices with an SADP inquiry, listening for
+// answers until ctx is done. Devices answer on the SADP multicast group,
+// which the host's firewall must let through.
+func DiscoverSADP(ctx context.Context) ([]DiscoveredDevice, error) {
+	group, err := net.ResolveUDPAddr("udp4", sadpAddr)
+	if err != nil {
+		return nil, err
+	}
+	listen, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return nil, fmt.Errorf("sadp: %w", err)
+	}
+	defer listen.Close()
+	send, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("sadp: %w", err)
+	}
+	defer send.Close()
+
+	probe, err := xml.Marshal(sadpProbe{UUID: strings.ToUpper(newUUID()), Types: "inquiry"})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := send.WriteTo(append([]byte(xml.Header), probe...), group); err != nil {
+		return nil, fmt.Errorf("sadp: %w", err)
+	}
+
+	found := map[string]DiscoveredDevice{}
+	var mu sync.Mutex
+	collect := func(data []byte) {
+		var m sadpMatch
+		if xml.Unmarshal(data, &m) != nil || m.IP == "" {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		found[m.IP] = DiscoveredDevice{
+			IP:              m.IP,
+			HTTPPort:        m.HTTPPort,
+			MACAddress:      strings.ReplaceAll(m.MAC, "-", ":"),
+			Model:           m.Model,
+			SerialNumber:    m.Serial,
+			FirmwareVersion: m.Software,
+			Activated:       m.Activated != "false",
+			Source:          "sadp",
+		}
+	}
+	// Some firmwares answer the sender directly instead of the group.
+	var wg sync.WaitGroup
+	for _, conn := range []*net.UDPConn{listen, send} {
+		wg.Add(1)
+		go func(conn *net.UDPConn) {
+			defer wg.Done()
+			readUntil(ctx, conn, collect)
+		}(conn)
+	}
+	wg.Wait()
+	return deviceList(found), nil
+}
+
+// wsdProbe is a WS-Discovery Probe for ONVIF video transmitters.
+const wsdProbe = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:dn="http://www.onvif.org/ver10/network/wsdl">
+<s:Header><a:Action>http://schemas.xmlsoap.org/ws/2005/04/discovery/Probe</a:Action><a:MessageID>uuid:%s</a:MessageID><a:To>urn:schemas-xmlsoap-org:ws:2005:04:discovery</a:To></s:Header>
+<s:Body><d:Probe><d:Types>dn:NetworkVideoTransmitter</d:Types></d:Probe></s:Body>
+</s:Envelope>`
+
+type wsdEnvelope struct {
+	Matches []struct {
+		Scopes string `xml:"Scopes"`
+		XAddrs string `xml:"XAddrs"`
+	} `xml:"Body>ProbeMatches>ProbeMatch"`
+}
+
+// DiscoverWS finds ONVIF cameras with a WS-Discovery probe, listening for
+// answers until ctx is done. Only devices whose scopes name Hikvision are
+// returned; WS-Discovery gives their model but not serial or firmware.
+func DiscoverWS(ctx context.Context) ([]DiscoveredDevice, error) {
+	group, err := net.ResolveUDPAddr("udp4", wsdAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ws-discovery: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.WriteTo([]byte(fmt.Sprintf(wsdProbe, newUUID())), group); err != nil {
+		return nil, fmt.Errorf("ws-discovery: %w", err)
+	}
+
+	found := map[string]DiscoveredDevice{}
+	readUntil(ctx, conn, func(data []byte) {
+		var env wsdEnvelope
+		if xml.Unmarshal(data, &env) != nil {
+			return
+		}
+		for _, m := range env.Matches {
+			if !strings.Contains(strings.ToLower(m.Scopes), "hikvision") {
+				continue
+			}
+			d := DiscoveredDevice{Activated: true, Source: "ws"}
+			for _, scope := range strings.Fields(m.Scopes) {
+				if model, ok := strings.CutPrefix(scope, "onvif://www.onvif.org/hardware/"); ok {
+					d.Model, _ = url.PathUnescape(model)
+				}
+			}
+			for _, addr := range strings.Fields(m.XAddrs) {
+				u, err := url.Parse(addr)
+				if err != nil || net.ParseIP(u.Hostname()).To4() == nil {
+					continue
+				}
+				d.IP = u.Hostname()
+				if p, err := strconv.Atoi(u.Port()); err == nil {
+					d.HTTPPort = p
+				}
+				break
+			}
+			if d.IP != "" {
+				found[d.IP] = d
+			}
+		}
+	})
+	return deviceList(found), nil
+}
+
+// readUntil passes every datagram read from conn to handle until ctx is done.
+func readUntil(ctx context.Context, conn *net.UDPConn, handle func([]byte)) {
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		handle(append([]byte(nil), buf[:n]...))
+	}
+}
+
+func deviceList(found map[string]DiscoveredDevice) []DiscoveredDevice {
+	devices := make([]DiscoveredDevice, 0, len(found))
+	for _, d := range found {
+		devices = append(devices, d)
+	}
+	sortDevices(devices)
+	return devices
+}
+
+// sortDevices orders devices by IP address.
+func sortDevices(devices []DiscoveredDevice) {
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := net.ParseIP(devices[i].IP).To4(), net.ParseIP(devices[j].IP).To4()
+		return string(a) < string(b)
+	})
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,56 @@
+package hikvision
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// dryRunReply is the body a dry run answers changes with: the ResponseStatus
+// of a camera that accepted them.
+const dryRunReply = `<?xml version="1.0" encoding="UTF-8"?>
+<ResponseStatus version="2.0"><statusCode>1</statusCode><statusString>OK</statusString></ResponseStatus>`
+
+// readKey marks the context of a POST that only reads, such as an ONVIF Get
+// operation, so that a dry run sends it.
+type readKey struct{}
+
+// withRead returns ctx marked as carrying a read-only request.
+func withRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readKey{}, true)
+}
+
+// dryRun writes a request that would change the camera to c.DryRun and
+// returns the reply standing in for the camera's, or nil if the request is
+// to be sent.
+func (c *Camera) dryRun(ctx context.Context, method, url string, body []byte) *http.Response {
+	if c.DryRun == nil || method == http.MethodGet || method == http.MethodHead || ctx.Value(readKey{}) != nil {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", method, url)
+	switch ct := contentType(body); {
+	case len(body) == 0:
+	case ct == "application/octet-stream":
+		fmt.Fprintf(&b, "[%d bytes %s]\n", len(body), ct)
+	default:
+		b.Write(body)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	io.WriteString(c.DryRun, b.String())
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/xml"}, "Content-Length": {strconv.Itoa(len(dryRunReply))}},
+		Body:          io.NopCloser(strings.NewReader(dryRunReply)),
+		ContentLength: int64(len(dryRunReply)),
+	}
+}
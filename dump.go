@@ -0,0 +1,234 @@
+package hikvision
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxDumpBody caps how much of a body WithDump writes, which matters for
+// long-lived streams such as the alert stream.
+const maxDumpBody = 1 << 20
+
+// DumpFunc opens the destination of the dump of the nth HTTP exchange,
+// counted from 1, for WithDump.
+type DumpFunc func(n int, req *http.Request) (io.WriteCloser, error)
+
+// DumpWriter returns a DumpFunc that writes every dump to w, one after the
+// other with a blank line between them.
+func DumpWriter(w io.Writer) DumpFunc {
+	var mu sync.Mutex
+	return func(int, *http.Request) (io.WriteCloser, error) {
+		mu.Lock()
+		return &lockedWriter{w: w, unlock: mu.Unlock}, nil
+	}
+}
+
+// lockedWriter holds the lock of a DumpWriter until closed, so that
+// concurrent dumps do not interleave.
+type lockedWriter struct {
+	w      io.Writer
+	unlock func()
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) { return l.w.Write(p) }
+
+func (l *lockedWriter) Close() error {
+	_, err := io.WriteString(l.w, "\n")
+	l.unlock()
+	return err
+}
+
+// dumpTransport writes every exchange of base, once its response body has
+// been read, through open.
+type dumpTransport struct {
+	base http.RoundTripper
+	open DumpFunc
+	n    atomic.Int64
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.GetBody != nil && req.ContentLength != 0 {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, maxDumpBody))
+			body.Close()
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	n := int(t.n.Add(1))
+
+	write := func(respBody []byte, size int64) {
+		w, oerr := t.open(n, req)
+		if oerr != nil {
+			return
+		}
+		defer w.Close()
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "%s %s\n", req.Method, req.URL.Redacted())
+		writeDumpHeader(&b, req.Header)
+		writeDumpBody(&b, req.Header.Get("Content-Type"), reqBody, req.ContentLength)
+		if err != nil {
+			fmt.Fprintf(&b, "\nerror: %v\n", err)
+		} else {
+			fmt.Fprintf(&b, "\n%s %s\n", resp.Proto, resp.Status)
+			writeDumpHeader(&b, resp.Header)
+			writeDumpBody(&b, resp.Header.Get("Content-Type"), respBody, size)
+		}
+		w.Write(b.Bytes())
+	}
+	if err != nil {
+		write(nil, 0)
+		return nil, err
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, limit: maxDumpBody, log: write}
+	return resp, nil
+}
+
+// writeDumpHeader writes h sorted by name, with credentials redacted.
+func writeDumpHeader(b *bytes.Buffer, h http.Header) {
+	h = redactHeader(h)
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			fmt.Fprintf(b, "%s: %s\n", name, v)
+		}
+	}
+}
+
+// writeDumpBody writes a body of size bytes starting with data: XML and JSON
+// indented, other text as is and binary content by size only. Passwords and
+// keys are redacted.
+func writeDumpBody(b *bytes.Buffer, contentType string, data []byte, size int64) {
+	if len(data) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	if !isText(contentType) {
+		fmt.Fprintf(b, "[%d bytes %s]\n", max(size, int64(len(data))), contentType)
+		return
+	}
+	text := redactBody(string(data))
+	ct := strings.ToLower(contentType)
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.Contains(ct, "json") || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		var out bytes.Buffer
+		if json.Indent(&out, []byte(trimmed), "", "  ") == nil {
+			text = out.String()
+		}
+	case strings.HasPrefix(trimmed, "<") && !strings.HasPrefix(ct, "multipart/"):
+		if pretty, err := indentXML(trimmed); err == nil {
+			text = pretty
+		}
+	}
+	b.WriteString(strings.TrimRight(text, "\n"))
+	b.WriteString("\n")
+	if size > int64(len(data)) {
+		fmt.Fprintf(b, "[truncated: %d of %d bytes]\n", len(data), size)
+	}
+}
+
+// indentXML re-indents an XML document by two spaces per level, keeping
+// prefixes, namespaces and attributes exactly as written. Elements holding
+// only text stay on one line.
+func indentXML(s string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader(s))
+	d.Strict = false
+	var toks []xml.Token
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+
+	var b strings.Builder
+	depth := 0
+	indent := func() { b.WriteString(strings.Repeat("  ", depth)) }
+	for i := 0; i < len(toks); i++ {
+		switch tok := toks[i].(type) {
+		case xml.StartElement:
+			indent()
+			writeStart(&b, tok)
+			// <a>text</a> and <a></a> stay on one line.
+			if i+2 < len(toks) {
+				if text, ok := toks[i+1].(xml.CharData); ok {
+					if _, ok := toks[i+2].(xml.EndElement); ok {
+						xml.EscapeText(&b, text)
+						writeEnd(&b, toks[i+2].(xml.EndElement))
+						b.WriteString("\n")
+						i += 2
+						continue
+					}
+				}
+			}
+			if i+1 < len(toks) {
+				if end, ok := toks[i+1].(xml.EndElement); ok {
+					writeEnd(&b, end)
+					b.WriteString("\n")
+					i++
+					continue
+				}
+			}
+			b.WriteString("\n")
+			depth++
+		case xml.EndElement:
+			depth--
+			indent()
+			writeEnd(&b, tok)
+			b.WriteString("\n")
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				indent()
+				xml.EscapeText(&b, []byte(text))
+				b.WriteString("\n")
+			}
+		case xml.ProcInst:
+			fmt.Fprintf(&b, "<?%s %s?>\n", tok.Target, tok.Inst)
+		case xml.Comment:
+			indent()
+			fmt.Fprintf(&b, "<!--%s-->\n", tok)
+		case xml.Directive:
+			fmt.Fprintf(&b, "<!%s>\n", tok)
+		}
+	}
+	return b.String(), nil
+}
+
+func writeStart(b *strings.Builder, e xml.StartElement) {
+	b.WriteString("<" + rawName(e.Name))
+	for _, a := range e.Attr {
+		b.WriteString(" " + rawName(a.Name) + `="`)
+		xml.EscapeText(b, []byte(a.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+}
+
+func writeEnd(b *strings.Builder, e xml.EndElement) {
+	b.WriteString("</" + rawName(e.Name) + ">")
+}
+
+// rawName returns a name from RawToken, whose Space is the prefix.
+func rawName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
@@ -0,0 +1,115 @@
+package hikvision
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for common camera failures. They match an *ISAPIError via
+// errors.Is, so callers can branch on the kind of failure:
+//
+//	if errors.Is(err, hikvision.ErrNotSupported) { ... }
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotSupported = errors.New("not supported by camera")
+	ErrDeviceBusy   = errors.New("device busy")
+)
+
+// ISAPI ResponseStatus statusCode values.
+const (
+	StatusOK             = 1
+	StatusDeviceBusy     = 2
+	StatusDeviceError    = 3
+	StatusInvalidOp      = 4
+	StatusInvalidFormat  = 5
+	StatusInvalidContent = 6
+	StatusRebootRequired = 7
+)
+
+// ISAPIError is a failed ISAPI request. When the camera sent a ResponseStatus
+// XML body its fields are filled in; otherwise Body holds the raw response.
+type ISAPIError struct {
+	// HTTPStatus is the HTTP status code of the response.
+	HTTPStatus int
+	// RequestURL, StatusCode, StatusString and SubStatusCode come from the
+	// ResponseStatus body, e.g. 4, "Invalid Operation", "notSupport".
+	RequestURL    string
+	StatusCode    int
+	StatusString  string
+	SubStatusCode string
+	// Body is the raw response body when it was not a ResponseStatus.
+	Body string
+}
+
+func (e *ISAPIError) Error() string {
+	if e.StatusString != "" {
+		msg := fmt.Sprintf("camera returned %d: %s", e.HTTPStatus, e.StatusString)
+		if e.SubStatusCode != "" {
+			msg += " (" + e.SubStatusCode + ")"
+		}
+		return msg
+	}
+	return fmt.Sprintf("camera returned %d: %s", e.HTTPStatus, strings.TrimSpace(e.Body))
+}
+
+// Is reports whether e is an instance of one of the package sentinel errors.
+func (e *ISAPIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.HTTPStatus == http.StatusUnauthorized ||
+			e.SubStatusCode == "badAuthorization" || e.SubStatusCode == "userNotExist"
+	case ErrNotSupported:
+		return e.HTTPStatus == http.StatusNotFound || e.HTTPStatus == http.StatusNotImplemented ||
+			e.SubStatusCode == "notSupport" || e.SubStatusCode == "methodNotAllowed"
+	case ErrDeviceBusy:
+		return e.StatusCode == StatusDeviceBusy || e.SubStatusCode == "deviceBusy"
+	}
+	return false
+}
+
+// responseStatus is the ResponseStatus body ISAPI returns for errors and for
+// most PUT/POST requests. JSON endpoints send the same fields unwrapped.
+type responseStatus struct {
+	XMLName       xml.Name `xml:"ResponseStatus" json:"-"`
+	RequestURL    string   `xml:"requestURL" json:"requestURL"`
+	StatusCode    int      `xml:"statusCode" json:"statusCode"`
+	StatusString  string   `xml:"statusString" json:"statusString"`
+	SubStatusCode string   `xml:"subStatusCode" json:"subStatusCode"`
+}
+
+// parseResponseStatus decodes a ResponseStatus body in XML or JSON.
+func parseResponseStatus(body []byte) (responseStatus, bool) {
+	var rs responseStatus
+	if isJSON(body) {
+		return rs, json.Unmarshal(body, &rs) == nil
+	}
+	return rs, xml.Unmarshal(body, &rs) == nil
+}
+
+// newISAPIError builds an ISAPIError from a response status and body.
+func newISAPIError(httpStatus int, body []byte) *ISAPIError {
+	e := &ISAPIError{HTTPStatus: httpStatus}
+	if rs, ok := parseResponseStatus(body); ok && rs.StatusString != "" {
+		e.RequestURL, e.StatusCode, e.StatusString, e.SubStatusCode = rs.RequestURL, rs.StatusCode, rs.StatusString, rs.SubStatusCode
+	} else {
+		e.Body = string(body)
+	}
+	return e
+}
+
+// checkResponseStatus returns an error if a 200 OK body is a ResponseStatus
+// reporting failure. Some firmwares signal rejected writes this way.
+func checkResponseStatus(body []byte) error {
+	rs, ok := parseResponseStatus(body)
+	if !ok {
+		return nil
+	}
+	if rs.StatusCode == 0 || rs.StatusCode == StatusOK || rs.StatusCode == StatusRebootRequired {
+		return nil
+	}
+	return newISAPIError(http.StatusOK, body)
+}
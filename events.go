@@ -0,0 +1,259 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType is the eventType of an EventNotificationAlert.
+type EventType string
+
+const (
+	EventMotion        EventType = "VMD"
+	EventLineCrossing  EventType = "linedetection"
+	EventIntrusion     EventType = "fielddetection"
+	EventRegionEntry   EventType = "regionEntrance"
+	EventRegionExit    EventType = "regionExiting"
+	EventTamper        EventType = "tamperdetection"
+	EventShelter       EventType = "shelteralarm"
+	EventVideoLoss     EventType = "videoloss"
+	EventAlarmInput    EventType = "IO"
+	EventIllegalAccess EventType = "illegalAccess"
+	EventDiskFull      EventType = "diskfull"
+	EventDiskError     EventType = "diskerror"
+	// EventThermometry is a temperature alarm of a thermal camera, and
+	// EventThermometryPreAlarm the pre-alarm before it.
+	EventThermometry         EventType = "TMA"
+	EventThermometryPreAlarm EventType = "TMPA"
+	// EventANPR is a plate read by an ANPR camera.
+	EventANPR EventType = "ANPR"
+	// EventFaceDetection is a face appearing in the picture, and
+	// EventFaceCapture a face picture taken by a face capture camera.
+	EventFaceDetection EventType = "facedetection"
+	EventFaceCapture   EventType = "faceSnap"
+	// EventAudioException is an audio input failing or its sound intensity
+	// rising or dropping sharply, and EventSceneChange the picture changing
+	// as a whole.
+	EventAudioException EventType = "audioexception"
+	EventSceneChange    EventType = "scenechangedetection"
+)
+
+// Event is an EventNotificationAlert, as delivered on the alert stream and
+// pushed to HTTP notification hosts.
+type Event struct {
+	XMLName    xml.Name  `xml:"EventNotificationAlert" json:"-"`
+	IPAddress  string    `xml:"ipAddress" json:"ipAddress,omitempty"`
+	MACAddress string    `xml:"macAddress" json:"macAddress,omitempty"`
+	ChannelID  int       `xml:"channelID" json:"channelID,omitempty"`
+	DateTime   string    `xml:"dateTime" json:"dateTime"`
+	Type       EventType `xml:"eventType" json:"eventType"`
+	// State is "active" while the condition lasts and "inactive" after.
+	State       string `xml:"eventState" json:"eventState"`
+	Description string `xml:"eventDescription" json:"eventDescription,omitempty"`
+	// ActivePostCount counts notifications sent for this occurrence.
+	ActivePostCount int `xml:"activePostCount" json:"activePostCount,omitempty"`
+	// InputPort is the alarm input that fired, for EventAlarmInput.
+	InputPort int `xml:"inputIOPortID" json:"inputIOPortID,omitempty"`
+	// Regions lists the smart-event regions or lines that were triggered.
+	Regions []EventRegion `xml:"DetectionRegionList>DetectionRegionEntry" json:"DetectionRegionList,omitempty"`
+	// Thermometry is the rule and temperature of EventThermometry and
+	// EventThermometryPreAlarm.
+	Thermometry *ThermometryEvent `xml:"ThermometryAlarm" json:"ThermometryAlarm,omitempty"`
+	// AudioException is the check that raised EventAudioException:
+	// audioInputException, audioSteepRise or audioSteepDrop.
+	AudioException string `xml:"audioExceptionType" json:"audioExceptionType,omitempty"`
+	// ANPR is the plate of EventANPR.
+	ANPR *ANPREvent `xml:"ANPR" json:"ANPR,omitempty"`
+	// Pictures are the images pushed with the notification to an
+	// EventReceiver, such as the capture of a line crossing.
+	Pictures []EventPicture `xml:"-" json:"-"`
+}
+
+// EventRegion identifies a triggered smart-event region or line.
+type EventRegion struct {
+	ID          int    `xml:"regionID" json:"regionID,omitempty"`
+	Sensitivity int    `xml:"sensitivityLevel" json:"sensitivityLevel,omitempty"`
+	Target      string `xml:"detectionTarget" json:"detectionTarget,omitempty"`
+}
+
+// ThermometryEvent is the measurement rule that raised a temperature alarm
+// and what it read, in degrees Celsius.
+type ThermometryEvent struct {
+	RuleID      int     `xml:"ruleID" json:"ruleID"`
+	RuleName    string  `xml:"ruleName" json:"ruleName,omitempty"`
+	Temperature float64 `xml:"currTemperature" json:"currTemperature"`
+	Threshold   float64 `xml:"ruleTemperature" json:"ruleTemperature,omitempty"`
+}
+
+// Active reports whether the event marks the start or continuation of a
+// condition rather than its end.
+func (e *Event) Active() bool {
+	return e.State == "active"
+}
+
+// Heartbeat reports whether e is the periodic keep-alive the camera sends
+// on an idle alert stream (an inactive videoloss event).
+func (e *Event) Heartbeat() bool {
+	return e.Type == EventVideoLoss && e.State == "inactive"
+}
+
+// Time parses DateTime. Cameras report local time with an offset, e.g.
+// 2017-05-04T11:20:02+08:00; some omit the offset.
+func (e *Event) Time() (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, e.DateTime); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid event time %q", e.DateTime)
+}
+
+// EventStream is an open subscription to a camera's alert stream.
+type EventStream struct {
+	// C delivers events in the order the camera sends them, heartbeats
+	// included. It is closed when the stream ends.
+	C <-chan Event
+
+	body   io.Closer
+	once   sync.Once
+	mu     sync.Mutex
+	err    error
+	closed bool
+}
+
+// SubscribeEvents opens /ISAPI/Event/notification/alertStream and decodes it
+// into events. The stream lasts until ctx is cancelled, Close is called, or
+// the connection drops; callers that want a permanent subscription should
+// resubscribe when C is closed.
+func (c *Camera) SubscribeEvents(ctx context.Context) (*EventStream, error) {
+	return c.subscribeEvents(ctx, "/ISAPI/Event/notification/alertStream")
+}
+
+// subscribeEvents opens the alert stream at path.
+func (c *Camera) subscribeEvents(ctx context.Context, path string) (*EventStream, error) {
+	resp, err := c.stream(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Event, 16)
+	s := &EventStream{C: ch, body: resp.Body}
+	go func() {
+		defer close(ch)
+		err := decodeEvents(resp.Header.Get("Content-Type"), resp.Body, func(e Event) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		s.finish(err)
+	}()
+	return s, nil
+}
+
+// NewEventStream wraps events from another source, such as a mock or a
+// non-ISAPI Controller, as an EventStream. The source closes ch when it is
+// done; closer, if not nil, is called by Close to stop it early.
+func NewEventStream(ch <-chan Event, closer io.Closer) *EventStream {
+	if closer == nil {
+		closer = io.NopCloser(nil)
+	}
+	return &EventStream{C: ch, body: closer}
+}
+
+// Err returns the error that ended the stream, or nil if it was closed by
+// the caller or its context. It is only meaningful once C is closed.
+func (s *EventStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *EventStream) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	var err error
+	s.once.Do(func() { err = s.body.Close() })
+	return err
+}
+
+func (s *EventStream) finish(err error) {
+	s.mu.Lock()
+	if !s.closed && err != nil && !errors.Is(err, context.Canceled) {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.once.Do(func() { s.body.Close() })
+}
+
+// decodeEvents reads EventNotificationAlert documents from r and passes them
+// to emit until r ends or emit returns false. Multipart bodies are split on
+// their boundary, skipping picture parts, and their parts may be XML or
+// JSON; other bodies are read as a plain sequence of XML documents.
+func decodeEvents(contentType string, r io.Reader, emit func(Event) bool) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return decodeEventXML(r, emit)
+	}
+
+	mr := multipart.NewReader(r, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read alert stream: %w", err)
+		}
+		ct := part.Header.Get("Content-Type")
+		if ct != "" && !strings.Contains(ct, "xml") && !strings.Contains(ct, "json") {
+			continue
+		}
+		// Read only Content-Length bytes when it is given: reading to the
+		// end of the part would hold the event until the next one arrives.
+		var body io.Reader = part
+		if n, err := strconv.ParseInt(part.Header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+			body = io.LimitReader(part, n)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("read alert stream: %w", err)
+		}
+		e, err := decodeEvent(data)
+		if err != nil {
+			continue
+		}
+		if !emit(e) {
+			return nil
+		}
+	}
+}
+
+func decodeEventXML(r io.Reader, emit func(Event) bool) error {
+	dec := xml.NewDecoder(r)
+	for {
+		var e Event
+		if err := dec.Decode(&e); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decode alert stream: %w", err)
+		}
+		if !emit(e) {
+			return nil
+		}
+	}
+}
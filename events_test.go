@@ -0,0 +1,154 @@
+package hikvision
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Alert stream documents in the form cameras send them: an idle-stream
+// heartbeat and a motion alarm in XML, and a line crossing in JSON.
+const (
+	heartbeatXML = `<EventNotificationAlert version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">
+<ipAddress>192.168.1.64</ipAddress>
+<portNo>80</portNo>
+<protocol>HTTP</protocol>
+<macAddress>c0:56:e3:00:11:22</macAddress>
+<channelID>1</channelID>
+<dateTime>2024-05-04T11:20:02+08:00</dateTime>
+<activePostCount>0</activePostCount>
+<eventType>videoloss</eventType>
+<eventState>inactive</eventState>
+<eventDescription>videoloss alarm</eventDescription>
+</EventNotificationAlert>`
+	motionXML = `<EventNotificationAlert version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">
+<ipAddress>192.168.1.64</ipAddress>
+<portNo>80</portNo>
+<protocol>HTTP</protocol>
+<macAddress>c0:56:e3:00:11:22</macAddress>
+<channelID>1</channelID>
+<dateTime>2024-05-04T11:20:07+08:00</dateTime>
+<activePostCount>1</activePostCount>
+<eventType>VMD</eventType>
+<eventState>active</eventState>
+<eventDescription>Motion alarm</eventDescription>
+</EventNotificationAlert>`
+	lineCrossingJSON = `{
+	"ipAddress": "192.168.1.65",
+	"portNo": 80,
+	"protocol": "HTTP",
+	"macAddress": "c0:56:e3:00:33:44",
+	"channelID": 1,
+	"dateTime": "2024-05-04T11:21:30+08:00",
+	"activePostCount": 1,
+	"eventType": "linedetection",
+	"eventState": "active",
+	"eventDescription": "linedetection alarm",
+	"DetectionRegionList": [{"regionID": 1, "sensitivityLevel": 50, "detectionTarget": "human"}]
+}`
+)
+
+// alertPart is one part of a multipart alert stream, with its headers as
+// the camera sends them.
+func alertPart(contentType, body string, withLength bool) string {
+	s := "--boundary\r\nContent-Type: " + contentType + "\r\n"
+	if withLength {
+		s += fmt.Sprintf("Content-Length: %d\r\n", len(body))
+	}
+	return s + "\r\n" + body + "\r\n"
+}
+
+func TestDecodeEvents(t *testing.T) {
+	const multipart = "multipart/mixed; boundary=boundary"
+	heartbeat := Event{ChannelID: 1, DateTime: "2024-05-04T11:20:02+08:00", Type: EventVideoLoss, State: "inactive"}
+	motion := Event{ChannelID: 1, DateTime: "2024-05-04T11:20:07+08:00", Type: EventMotion, State: "active"}
+	lineCrossing := Event{ChannelID: 1, DateTime: "2024-05-04T11:21:30+08:00", Type: EventLineCrossing, State: "active"}
+
+	tests := []struct {
+		name, contentType, body string
+		want                    []Event
+	}{
+		{
+			name:        "multipart xml",
+			contentType: multipart,
+			body: alertPart(`application/xml; charset="UTF-8"`, heartbeatXML, true) +
+				alertPart(`application/xml; charset="UTF-8"`, motionXML, true),
+			want: []Event{heartbeat, motion},
+		},
+		{
+			name:        "multipart without content length",
+			contentType: multipart,
+			body:        alertPart("application/xml", motionXML, false) + "--boundary--\r\n",
+			want:        []Event{motion},
+		},
+		{
+			name:        "multipart json",
+			contentType: multipart,
+			body:        alertPart("application/json", lineCrossingJSON, true),
+			want:        []Event{lineCrossing},
+		},
+		{
+			name:        "multipart json wrapped in its root",
+			contentType: multipart,
+			body:        alertPart("application/json", `{"EventNotificationAlert": `+lineCrossingJSON+`}`, true),
+			want:        []Event{lineCrossing},
+		},
+		{
+			name:        "picture part skipped",
+			contentType: multipart,
+			body: alertPart("application/json", lineCrossingJSON, true) +
+				alertPart("image/jpeg", "\xff\xd8\xff\xe0 not a real picture \xff\xd9", true) +
+				alertPart("application/xml", heartbeatXML, true),
+			want: []Event{lineCrossing, heartbeat},
+		},
+		{
+			name:        "plain xml sequence",
+			contentType: "application/xml",
+			body:        heartbeatXML + "\r\n" + motionXML + "\r\n",
+			want:        []Event{heartbeat, motion},
+		},
+		{
+			name:        "no content type",
+			contentType: "",
+			body:        motionXML,
+			want:        []Event{motion},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Event
+			err := decodeEvents(tt.contentType, strings.NewReader(tt.body), func(e Event) bool {
+				got = append(got, e)
+				return true
+			})
+			if err != nil {
+				t.Fatalf("decodeEvents: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("decodeEvents emitted %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, e := range got {
+				w := tt.want[i]
+				if e.ChannelID != w.ChannelID || e.DateTime != w.DateTime || e.Type != w.Type || e.State != w.State {
+					t.Errorf("event %d = %s %s on channel %d at %s, want %s %s on channel %d at %s",
+						i, e.Type, e.State, e.ChannelID, e.DateTime, w.Type, w.State, w.ChannelID, w.DateTime)
+				}
+				if e.Heartbeat() != w.Heartbeat() {
+					t.Errorf("event %d Heartbeat() = %t, want %t", i, e.Heartbeat(), w.Heartbeat())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeEventsStops(t *testing.T) {
+	body := alertPart("application/xml", heartbeatXML, true) + alertPart("application/xml", motionXML, true)
+	n := 0
+	err := decodeEvents("multipart/mixed; boundary=boundary", strings.NewReader(body), func(Event) bool {
+		n++
+		return false
+	})
+	if err != nil || n != 1 {
+		t.Errorf("decodeEvents with emit returning false = %v after %d events, want nil after 1", err, n)
+	}
+}
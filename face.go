@@ -0,0 +1,115 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// FaceDetection is the face detection configuration of a video channel, as
+// served by /ISAPI/Smart/FaceDetect/N. While enabled, the camera raises
+// EventFaceDetection when a face appears and, on face capture models,
+// EventFaceCapture with the captured face.
+type FaceDetection struct {
+	XMLName xml.Name     `xml:"FaceDetect"`
+	ID      int          `xml:"id"`
+	Enabled bool         `xml:"enabled"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetFaceDetection returns the face detection configuration of a video
+// channel.
+func (c *Camera) GetFaceDetection(ctx context.Context, channel int) (*FaceDetection, error) {
+	var fd FaceDetection
+	if err := c.getXML(ctx, smartPath("FaceDetect", channel), &fd); err != nil {
+		return nil, err
+	}
+	return &fd, nil
+}
+
+// SetFaceDetection replaces the face detection configuration of a video
+// channel.
+func (c *Camera) SetFaceDetection(ctx context.Context, channel int, fd *FaceDetection) error {
+	fd.ID = channel
+	return c.putXML(ctx, smartPath("FaceDetect", channel), fd)
+}
+
+// FaceLibrary is a face picture library of a face capture camera, into
+// which captured faces are filed and against which they are compared. ID
+// is the camera's FDID of the library.
+type FaceLibrary struct {
+	ID   string `xml:"FDID"`
+	Name string `xml:"name"`
+	// Type is blackFD for a library faces are alarmed on, staticFD for a
+	// list of known faces and infraredFD on some models.
+	Type string `xml:"faceLibType"`
+	Info string `xml:"customInfo"`
+}
+
+type faceLibraryList struct {
+	XMLName   xml.Name      `xml:"FDLibBaseCfgList"`
+	Libraries []FaceLibrary `xml:"FDLibBaseCfg"`
+}
+
+// GetFaceLibraries returns the face picture libraries of the camera.
+// Calls GET /ISAPI/Intelligent/FDLib.
+func (c *Camera) GetFaceLibraries(ctx context.Context) ([]FaceLibrary, error) {
+	var list faceLibraryList
+	if err := c.getXML(ctx, "/ISAPI/Intelligent/FDLib", &list); err != nil {
+		return nil, err
+	}
+	return list.Libraries, nil
+}
+
+type createFaceLibraryList struct {
+	XMLName   xml.Name            `xml:"CreateFDLibList"`
+	Libraries []createFaceLibrary `xml:"CreateFDLib"`
+}
+
+type createFaceLibrary struct {
+	ID   int    `xml:"id"`
+	Name string `xml:"name"`
+	Info string `xml:"customInfo,omitempty"`
+}
+
+type faceLibraryInfoList struct {
+	XMLName   xml.Name `xml:"FDLibInfoList"`
+	Libraries []struct {
+		ID string `xml:"FDID"`
+	} `xml:"FDLibInfo"`
+}
+
+// AddFaceLibrary creates a face picture library and returns its ID, or ""
+// on a dry run.
+// Calls POST /ISAPI/Intelligent/FDLib.
+func (c *Camera) AddFaceLibrary(ctx context.Context, name, info string) (string, error) {
+	req := createFaceLibraryList{Libraries: []createFaceLibrary{{ID: 1, Name: name, Info: info}}}
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("encode face library: %w", err)
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/ISAPI/Intelligent/FDLib", append([]byte(xml.Header), body...))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// A dry run answers with a ResponseStatus and has no ID to return.
+	if c.DryRun != nil {
+		return "", nil
+	}
+	var res faceLibraryInfoList
+	if err := xml.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("decode face library: %w", err)
+	}
+	if len(res.Libraries) == 0 {
+		return "", fmt.Errorf("camera returned no face library ID")
+	}
+	return res.Libraries[0].ID, nil
+}
+
+// DeleteFaceLibrary deletes a face picture library and the faces in it.
+// Calls DELETE /ISAPI/Intelligent/FDLib/<FDID>.
+func (c *Camera) DeleteFaceLibrary(ctx context.Context, id string) error {
+	return c.deletePath(ctx, "/ISAPI/Intelligent/FDLib/"+id)
+}
@@ -0,0 +1,46 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UpgradeStatus is the progress of a firmware upgrade, as served by
+// /ISAPI/System/upgradeStatus.
+type UpgradeStatus struct {
+	XMLName   xml.Name `xml:"upgradeStatus"`
+	Upgrading bool     `xml:"upgrading"`
+	// Percent is how much of the image has been written to flash.
+	Percent int `xml:"percent"`
+}
+
+// GetUpgradeStatus returns the progress of a running firmware upgrade.
+func (c *Camera) GetUpgradeStatus(ctx context.Context) (*UpgradeStatus, error) {
+	var s UpgradeStatus
+	if err := c.getXML(ctx, "/ISAPI/System/upgradeStatus", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpgradeFirmware uploads a firmware image (a .dav file) and returns once the
+// camera has written it; poll GetUpgradeStatus meanwhile for progress. The
+// new firmware runs after Reboot. Flashing takes minutes, so c.Timeout must
+// allow for it. The request is never retried: a second upload while the
+// first is being written fails with ErrDeviceBusy.
+func (c *Camera) UpgradeFirmware(ctx context.Context, image []byte) error {
+	resp, err := c.sendRetry(ctx, http.MethodPut, "/ISAPI/System/updateFirmware", image, RetryPolicy{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	return checkResponseStatus(reply)
+}
@@ -0,0 +1,54 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+)
+
+// Fisheye mounting positions for Fisheye.Mount. The camera corrects the
+// image for the mounting, so it must match how the camera is installed.
+const (
+	FisheyeMountCeiling = "ceiling"
+	FisheyeMountWall    = "wall"
+	FisheyeMountDesktop = "desktop"
+)
+
+// Fisheye display (dewarp) modes for Fisheye.Display.
+const (
+	// FisheyeDisplay360 streams the whole circular fisheye image.
+	FisheyeDisplay360 = "fisheye"
+	// FisheyeDisplayPanorama dewarps the image into a panorama: 180° on
+	// a wall, 360° (in two halves) on a ceiling or desk.
+	FisheyeDisplayPanorama = "panorama"
+	// FisheyeDisplayPTZ streams dewarped electronic PTZ views of parts of
+	// the image.
+	FisheyeDisplayPTZ = "PTZ"
+)
+
+// Fisheye is the mounting and dewarp configuration of a fisheye camera, as
+// served by /ISAPI/Image/channels/<id>/fisheye. Changing the display mode
+// restarts the streams of the channel.
+type Fisheye struct {
+	XMLName xml.Name `xml:"Fisheye"`
+	Mount   string   `xml:"mountType"`
+	Display string   `xml:"displayMode"`
+	// PTZViews is the number of electronic PTZ views streamed with
+	// FisheyeDisplayPTZ, on models that offer a choice.
+	PTZViews int          `xml:"PTZChannelNum,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// GetFisheye returns the fisheye configuration of a video channel. Cameras
+// without a fisheye lens return ErrNotSupported.
+func (c *Camera) GetFisheye(ctx context.Context, channel int) (*Fisheye, error) {
+	var f Fisheye
+	if err := c.getXML(ctx, imagePath(channel, "fisheye"), &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetFisheye replaces the fisheye configuration of a video channel.
+func (c *Camera) SetFisheye(ctx context.Context, channel int, f *Fisheye) error {
+	return c.putXML(ctx, imagePath(channel, "fisheye"), f)
+}
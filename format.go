@@ -0,0 +1,201 @@
+package hikvision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Format is the encoding of ISAPI request and response bodies.
+type Format int
+
+const (
+	// FormatAuto uses XML, and JSON on endpoints that turn XML down; the
+	// choice is made on first use of each endpoint and cached.
+	FormatAuto Format = iota
+	// FormatXML always uses XML, which every firmware accepts on its
+	// classic endpoints.
+	FormatXML
+	// FormatJSON always uses JSON, requested with format=json as newer
+	// firmware expects for some resources such as supplementLight and
+	// smart events.
+	FormatJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatXML:
+		return "xml"
+	case FormatJSON:
+		return "json"
+	}
+	return "auto"
+}
+
+// jsonPath returns path asking for the JSON variant of its resource.
+func jsonPath(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&format=json"
+	}
+	return path + "?format=json"
+}
+
+// endpointFormat returns the format to use for path: c.Format if set,
+// otherwise the one cached for its endpoint, or FormatAuto if none is yet.
+func (c *Camera) endpointFormat(path string) Format {
+	if c.Format != FormatAuto {
+		return c.Format
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.formats[endpointName(path)]
+}
+
+// setEndpointFormat caches the format an endpoint was found to take.
+func (c *Camera) setEndpointFormat(path string, f Format) {
+	if c.Format != FormatAuto {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.formats == nil {
+		c.formats = make(map[string]Format)
+	}
+	c.formats[endpointName(path)] = f
+}
+
+// rejectsXML reports whether err means an endpoint does not take XML, so
+// that its JSON variant is worth trying.
+func rejectsXML(err error) bool {
+	var ie *ISAPIError
+	if !errors.As(err, &ie) {
+		return false
+	}
+	return errors.Is(err, ErrNotSupported) || ie.HTTPStatus == http.StatusBadRequest ||
+		ie.StatusCode == StatusInvalidFormat
+}
+
+// getDoc GETs an ISAPI path and decodes the response into out, in XML or
+// JSON according to the endpoint's format. out must be a pointer to a
+// struct with both xml and json tags.
+func (c *Camera) getDoc(ctx context.Context, path string, out any) error {
+	f := c.endpointFormat(path)
+	if f != FormatJSON {
+		data, err := c.GetRaw(ctx, path)
+		if err == nil {
+			// Some firmwares answer in JSON whatever is asked for.
+			if f == FormatAuto && isJSON(data) {
+				f = FormatJSON
+				err = decodeJSON(data, out)
+			} else {
+				f = FormatXML
+				err = decodeXML(data, out)
+			}
+			if err == nil {
+				c.setEndpointFormat(path, f)
+			}
+			return err
+		}
+		if f == FormatXML || !rejectsXML(err) {
+			return err
+		}
+	}
+	data, err := c.GetRaw(ctx, jsonPath(path))
+	if err != nil {
+		return err
+	}
+	if err := decodeJSON(data, out); err != nil {
+		return err
+	}
+	c.setEndpointFormat(path, FormatJSON)
+	return nil
+}
+
+// putDoc PUTs in to an ISAPI path in XML or JSON according to the endpoint's
+// format, like getDoc.
+func (c *Camera) putDoc(ctx context.Context, path string, in any) error {
+	f := c.endpointFormat(path)
+	if f != FormatJSON {
+		err := c.putXML(ctx, path, in)
+		if err == nil {
+			c.setEndpointFormat(path, FormatXML)
+		}
+		if err == nil || f == FormatXML || !rejectsXML(err) {
+			return err
+		}
+	}
+	payload, err := encodeJSON(in)
+	if err != nil {
+		return err
+	}
+	if err := c.command(ctx, http.MethodPut, jsonPath(path), payload); err != nil {
+		return err
+	}
+	c.setEndpointFormat(path, FormatJSON)
+	return nil
+}
+
+func isJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+func decodeXML(data []byte, out any) error {
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// decodeJSON decodes an ISAPI JSON document, which wraps the resource in an
+// object keyed by its name, as in {"SupplementLight": {...}}.
+func decodeJSON(data []byte, out any) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	body, ok := doc[rootName(out)]
+	if !ok {
+		return fmt.Errorf("decode response: no %s object in JSON reply", rootName(out))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// encodeJSON wraps in in an object keyed by its name, the JSON counterpart
+// of its XML root element.
+func encodeJSON(in any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(map[string]any{rootName(in): in}); err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// rootName returns the XML root element name of v, a struct or a pointer to
+// one, from its XMLName tag or else its type name.
+func rootName(v any) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if f, ok := t.FieldByName("XMLName"); ok {
+		if name, _, _ := strings.Cut(f.Tag.Get("xml"), ","); name != "" {
+			if _, local, ok := strings.Cut(name, " "); ok {
+				return local
+			}
+			return name
+		}
+	}
+	return t.Name()
+}
@@ -0,0 +1,73 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// FTPServer is an FTP server the camera uploads pictures to, as served by
+// /ISAPI/System/Network/ftp/<id>. Which events upload is set per event by
+// its linkage ("upload to FTP"), and scheduled snapshots by the capture
+// schedule.
+type FTPServer struct {
+	XMLName xml.Name `xml:"FTPNotification"`
+	ID      int      `xml:"id"`
+	Enabled bool     `xml:"enabled"`
+	// AddressingFormat is "hostname" or "ipaddress" and selects which of
+	// HostName and IPAddress is used.
+	AddressingFormat string `xml:"addressingFormatType"`
+	HostName         string `xml:"hostName,omitempty"`
+	IPAddress        string `xml:"ipAddress,omitempty"`
+	Port             int    `xml:"portNo"`
+	User             string `xml:"userName,omitempty"`
+	Password         string `xml:"password,omitempty"`
+	// Anonymous logs in without User; the element is misspelt by the
+	// firmware.
+	Anonymous bool `xml:"annoyftp"`
+	// Pictures uploads pictures; models that can also upload clips have
+	// their own settings for it.
+	Pictures bool         `xml:"uploadPicture"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// Address returns the configured host name or IP address.
+func (f *FTPServer) Address() string {
+	if f.AddressingFormat == "ipaddress" {
+		return f.IPAddress
+	}
+	return f.HostName
+}
+
+type ftpServerList struct {
+	XMLName xml.Name    `xml:"FTPNotificationList"`
+	Servers []FTPServer `xml:"FTPNotification"`
+}
+
+func ftpPath(op string) string {
+	return "/ISAPI/System/Network/ftp/" + op
+}
+
+// GetFTPServers returns the FTP servers of the camera. Most models have a
+// fixed list of one or two.
+// Calls GET /ISAPI/System/Network/ftp.
+func (c *Camera) GetFTPServers(ctx context.Context) ([]FTPServer, error) {
+	var list ftpServerList
+	if err := c.getXML(ctx, "/ISAPI/System/Network/ftp", &list); err != nil {
+		return nil, err
+	}
+	return list.Servers, nil
+}
+
+// SetFTPServer replaces FTP server f.ID.
+// Calls PUT /ISAPI/System/Network/ftp/<id>.
+func (c *Camera) SetFTPServer(ctx context.Context, f *FTPServer) error {
+	return c.putXML(ctx, ftpPath(fmt.Sprint(f.ID)), f)
+}
+
+// TestFTPServer makes the camera log in to f, which need not be saved
+// first, and upload a test file, and fails if it could not.
+// Calls POST /ISAPI/System/Network/ftp/test.
+func (c *Camera) TestFTPServer(ctx context.Context, f *FTPServer) error {
+	return c.postXML(ctx, ftpPath("test"), f)
+}
@@ -1,150 +1,410 @@
-package main
+// Package hikvision controls HikVision IP cameras over the ISAPI HTTP API.
+package hikvision
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/xml"
-	"flag"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strings"
-
-	"github.com/icholy/digest"
+	"sync"
+	"time"
 )
 
-// Camera represents a HikVision IP camera accessible over HTTP.
+// Camera represents a HikVision IP camera accessible over HTTP or HTTPS.
 type Camera struct {
 	Host     string
 	Username string
 	Password string
-	client   *http.Client
+	// Scheme is "http" or "https". An empty Scheme means "http".
+	Scheme string
+	// Timeout bounds each individual HTTP attempt. Zero means no timeout
+	// beyond the caller's context.
+	Timeout time.Duration
+	// Retry controls retries of requests that fail with a network error or
+	// a 5xx response. The zero value disables retries.
+	Retry RetryPolicy
+	// IREndpoint forces IR operations onto one endpoint. The zero value,
+	// IREndpointAuto, detects the endpoint on first use.
+	IREndpoint IREndpoint
+	// Channel is the video channel IR operations act on when they go through
+	// a per-channel Image endpoint. Zero means channel 1.
+	Channel int
+	// NVR marks Host as an NVR rather than a camera. Per-channel Image,
+	// Streaming and PTZ requests are then sent through the NVR's proxy
+	// endpoints to the camera attached on that channel.
+	NVR bool
+	// Format is the encoding of bodies on endpoints that newer firmware
+	// serves as JSON, such as supplementLight and smart events. The zero
+	// value, FormatAuto, uses XML unless the endpoint turns it down.
+	Format Format
+	// OnRequest, if set, is called after every HTTP attempt, including
+	// retries. It may be called from several goroutines at once.
+	OnRequest func(RequestInfo)
+	// DryRun, if set, receives the method, URL and body of every request
+	// that would change the camera, which is then not sent and answered as
+	// if the camera had accepted it. Reads still reach the camera, so that
+	// get-modify-set changes show the exact body.
+	DryRun io.Writer
+	client *http.Client
+	// transport is the client's transport underneath authentication.
+	transport http.RoundTripper
+	auth      AuthMode
+	userAgent string
+	telemetry *telemetry
+
+	mu         sync.Mutex
+	caps       *Capabilities
+	irEndpoint IREndpoint
+	// formats caches the format each endpoint took under FormatAuto,
+	// keyed by endpointName.
+	formats map[string]Format
+}
+
+// TLSOptions controls how the server certificate of an HTTPS camera is verified.
+type TLSOptions struct {
+	// InsecureSkipVerify disables certificate verification entirely.
+	InsecureSkipVerify bool
+	// RootCAs is the pool used to verify the camera certificate.
+	// A nil pool means the host's system roots.
+	RootCAs *x509.CertPool
+	// PinnedSHA256 is the SHA-256 digest of the camera's leaf certificate (DER).
+	// When set, the chain is not verified; the leaf must match the pin instead.
+	// This is the usual way to trust a camera's self-signed certificate.
+	PinnedSHA256 []byte
+}
+
+// config returns the TLS client configuration that implements o.
+func (o TLSOptions) config() *tls.Config {
+	cfg := &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+		RootCAs:            o.RootCAs,
+	}
+	if len(o.PinnedSHA256) > 0 {
+		pin := o.PinnedSHA256
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("camera presented no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(sum[:], pin) {
+				return fmt.Errorf("certificate fingerprint %x does not match pin %x", sum, pin)
+			}
+			return nil
+		}
+	}
+	return cfg
+}
+
+// NewCameraTLS creates a Camera that talks HTTPS, verifying the camera
+// certificate according to opts.
+//
+// Deprecated: use NewCamera(host, WithCredentials(username, password),
+// WithTLS(opts)).
+func NewCameraTLS(host, username, password string, opts TLSOptions) *Camera {
+	return NewCamera(host, WithCredentials(username, password), WithTLS(opts))
+}
+
+// nvrProxies maps per-channel ISAPI path prefixes to the NVR endpoints that
+// forward them to the attached camera.
+var nvrProxies = []struct{ direct, proxy string }{
+	{"/ISAPI/Image/channels/", "/ISAPI/ContentMgmt/ImageProxy/channels/"},
+	{"/ISAPI/Streaming/channels/", "/ISAPI/ContentMgmt/StreamingProxy/channels/"},
+	{"/ISAPI/PTZCtrl/channels/", "/ISAPI/ContentMgmt/PTZCtrlProxy/channels/"},
 }
 
-// NewCamera creates a Camera with an HTTP client configured for digest auth.
-func NewCamera(host, username, password string) *Camera {
-	return &Camera{
-		Host:     host,
-		Username: username,
-		Password: password,
-		client: &http.Client{
-			Transport: &digest.Transport{
-				Username: username,
-				Password: password,
-			},
-		},
+// route rewrites a camera ISAPI path for the device the Camera points at:
+// unchanged for a camera, through the proxy endpoints for an NVR.
+func (c *Camera) route(path string) string {
+	if !c.NVR {
+		return path
+	}
+	for _, p := range nvrProxies {
+		if strings.HasPrefix(path, p.direct) {
+			return p.proxy + strings.TrimPrefix(path, p.direct)
+		}
 	}
+	return path
+}
+
+// url returns the absolute URL for an ISAPI path such as "/ISAPI/System/Hardware".
+func (c *Camera) url(path string) string {
+	return c.baseURL() + path
 }
 
 // hardwareService is the root XML envelope returned by GET /ISAPI/System/Hardware.
 type hardwareService struct {
-	XMLName      xml.Name      `xml:"HardwareService"`
+	XMLName       xml.Name      `xml:"HardwareService"`
 	IrLightSwitch irLightSwitch `xml:"IrLightSwitch"`
 }
 
 // irLightSwitch is the IR LED control element nested inside HardwareService.
 type irLightSwitch struct {
 	Mode string `xml:"mode"`
+	// BrightnessLimit is nil when the camera does not report one, so
+	// that a limit of 0 can be told apart and sent.
+	BrightnessLimit *int `xml:"brightnessLimit,omitempty"`
 }
 
+// IRMode is the IrLightSwitch mode reported and accepted by the camera.
+type IRMode string
+
+const (
+	// IRModeOpen forces the IR illuminator on.
+	IRModeOpen IRMode = "open"
+	// IRModeClose forces the IR illuminator off.
+	IRModeClose IRMode = "close"
+	// IRModeAuto lets the camera switch IR based on its light sensor.
+	IRModeAuto IRMode = "auto"
+)
+
 // SetIRLight turns the IR illuminator on (true) or off (false).
-// Calls PUT /ISAPI/System/Hardware with an IrLightSwitch XML body.
+// It is shorthand for SetIRMode(IRModeOpen) or SetIRMode(IRModeClose).
 func (c *Camera) SetIRLight(on bool) error {
-	mode := "close"
+	return c.SetIRLightContext(context.Background(), on)
+}
+
+// SetIRLightContext is like SetIRLight but aborts the request when ctx is done.
+func (c *Camera) SetIRLightContext(ctx context.Context, on bool) error {
+	mode := IRModeClose
 	if on {
-		mode = "open"
+		mode = IRModeOpen
 	}
+	return c.SetIRModeContext(ctx, mode)
+}
 
-	payload, err := xml.Marshal(hardwareService{IrLightSwitch: irLightSwitch{Mode: mode}})
-	if err != nil {
-		return fmt.Errorf("marshal xml: %w", err)
-	}
+// GetIRLight returns true if the IR illuminator is currently forced on.
+// A camera in IRModeAuto reports false.
+func (c *Camera) GetIRLight() (bool, error) {
+	return c.GetIRLightContext(context.Background())
+}
 
-	url := fmt.Sprintf("http://%s/ISAPI/System/Hardware", c.Host)
-	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(xml.Header+string(payload)))
+// GetIRLightContext is like GetIRLight but aborts the request when ctx is done.
+func (c *Camera) GetIRLightContext(ctx context.Context) (bool, error) {
+	mode, err := c.GetIRModeContext(ctx)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return false, err
 	}
-	req.Header.Set("Content-Type", "application/xml")
+	return mode == IRModeOpen, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("PUT %s: %w", url, err)
+// irChannel returns the video channel IR operations act on when they are
+// routed to a per-channel Image endpoint.
+func (c *Camera) irChannel() int {
+	if c.Channel > 0 {
+		return c.Channel
 	}
-	defer resp.Body.Close()
+	return 1
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("camera returned %d: %s", resp.StatusCode, string(body))
+// backend returns the Controller that serves the IR of channel on the
+// endpoints outside ISAPI, or nil on the ISAPI ones.
+func (c *Camera) backend(ep IREndpoint, channel int) Controller {
+	switch ep {
+	case IREndpointLegacy:
+		return c.psia(channel)
+	case IREndpointONVIF:
+		return c.onvif(channel)
 	}
 	return nil
 }
 
-// GetIRLight returns true if the IR illuminator is currently enabled.
-// Calls GET /ISAPI/System/Hardware and parses the IrLightSwitch mode.
-func (c *Camera) GetIRLight() (bool, error) {
-	url := fmt.Sprintf("http://%s/ISAPI/System/Hardware", c.Host)
-	resp, err := c.client.Get(url)
+// SetIRMode sets the IR illuminator mode.
+// The request goes to the endpoint reported by DetectIREndpoint: IrLightSwitch
+// under /ISAPI/System/Hardware on most models, supplementLight on ColorVu and
+// hybrid models, or the day/night mode of the IR-cut filter otherwise, over
+// PSIA on old firmware or ONVIF on units that reject ISAPI.
+func (c *Camera) SetIRMode(mode IRMode) error {
+	return c.SetIRModeContext(context.Background(), mode)
+}
+
+// SetIRModeContext is like SetIRMode but aborts the request when ctx is done.
+func (c *Camera) SetIRModeContext(ctx context.Context, mode IRMode) error {
+	return c.SetChannelIRMode(ctx, c.irChannel(), mode)
+}
+
+// SetChannelIRMode is like SetIRModeContext for the IR of one video channel,
+// such as one lens of a multi-sensor camera, rather than Camera.Channel. The
+// Hardware endpoint is device-wide and switches every lens.
+func (c *Camera) SetChannelIRMode(ctx context.Context, channel int, mode IRMode) error {
+	ep, err := c.DetectIREndpoint(ctx)
 	if err != nil {
-		return false, fmt.Errorf("GET %s: %w", url, err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("camera returned %d: %s", resp.StatusCode, string(body))
+	if b := c.backend(ep, channel); b != nil {
+		return b.SetIRModeContext(ctx, mode)
 	}
 
-	var result hardwareService
-	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, fmt.Errorf("decode response: %w", err)
+	switch ep {
+	case IREndpointSupplementLight:
+		sl, err := c.GetSupplementLight(ctx, channel)
+		if err != nil {
+			return err
+		}
+		switch mode {
+		case IRModeOpen:
+			sl.Mode, sl.BrightnessMode = SupplementLightIR, BrightnessManual
+		case IRModeAuto:
+			sl.Mode, sl.BrightnessMode = SupplementLightIR, BrightnessAuto
+		default:
+			sl.Mode = SupplementLightOff
+		}
+		return c.SetSupplementLight(ctx, channel, sl)
+
+	case IREndpointIRCutFilter:
+		filter, err := irCutForIR(mode)
+		if err != nil {
+			return err
+		}
+		return c.SetIRCutFilterMode(ctx, channel, filter)
 	}
+	return c.putXML(ctx, "/ISAPI/System/Hardware", hardwareService{IrLightSwitch: irLightSwitch{Mode: string(mode)}})
+}
 
-	return result.IrLightSwitch.Mode == "open", nil
+// GetIRMode returns the current IR illuminator mode.
+// Calls GET /ISAPI/System/Hardware and parses the IrLightSwitch mode.
+func (c *Camera) GetIRMode() (IRMode, error) {
+	return c.GetIRModeContext(context.Background())
 }
 
-func main() {
-	host := flag.String("host", "", "Camera IP address (required)")
-	user := flag.String("user", "admin", "Camera username")
-	pass := flag.String("pass", "", "Camera password (required)")
-	action := flag.String("action", "", "Action: on | off | status (required)")
-	flag.Parse()
+// GetIRModeContext is like GetIRMode but aborts the request when ctx is done.
+func (c *Camera) GetIRModeContext(ctx context.Context) (IRMode, error) {
+	return c.GetChannelIRMode(ctx, c.irChannel())
+}
 
-	if *host == "" || *pass == "" || *action == "" {
-		fmt.Fprintf(os.Stderr, "Usage: hikvision-ir --host <IP> --user <user> --pass <pass> --action on|off|status\n")
-		os.Exit(1)
+// GetChannelIRMode is like GetIRModeContext for the IR of one video channel
+// rather than Camera.Channel.
+func (c *Camera) GetChannelIRMode(ctx context.Context, channel int) (IRMode, error) {
+	ep, err := c.DetectIREndpoint(ctx)
+	if err != nil {
+		return "", err
+	}
+	if b := c.backend(ep, channel); b != nil {
+		return b.GetIRModeContext(ctx)
 	}
 
-	cam := NewCamera(*host, *user, *pass)
+	switch ep {
+	case IREndpointSupplementLight:
+		sl, err := c.GetSupplementLight(ctx, channel)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case sl.Mode != SupplementLightIR:
+			return IRModeClose, nil
+		case sl.BrightnessMode == BrightnessAuto:
+			return IRModeAuto, nil
+		}
+		return IRModeOpen, nil
 
-	switch *action {
-	case "on":
-		if err := cam.SetIRLight(true); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+	case IREndpointIRCutFilter:
+		f, err := c.GetIRCutFilter(ctx, channel)
+		if err != nil {
+			return "", err
 		}
-		fmt.Println("IR light: on")
+		return irForIRCut(f.Type), nil
+	}
+	var result hardwareService
+	if err := c.getXML(ctx, "/ISAPI/System/Hardware", &result); err != nil {
+		return "", err
+	}
+	return IRMode(result.IrLightSwitch.Mode), nil
+}
 
-	case "off":
-		if err := cam.SetIRLight(false); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+// SetIRBrightness sets the IR LED brightness, 0-100, without changing the IR
+// mode. On the Hardware endpoint this is the IrLightSwitch brightness limit;
+// on supplementLight models it is the manual IR brightness level, or the
+// limit of the level in auto regulation, which is kept. Cameras whose IR
+// follows the IR-cut filter have no brightness control.
+func (c *Camera) SetIRBrightness(ctx context.Context, brightness int) error {
+	return c.SetChannelIRBrightness(ctx, c.irChannel(), brightness)
+}
+
+// SetChannelIRBrightness is like SetIRBrightness for the IR of one video
+// channel rather than Camera.Channel.
+func (c *Camera) SetChannelIRBrightness(ctx context.Context, channel, brightness int) error {
+	if brightness < 0 || brightness > 100 {
+		return fmt.Errorf("IR brightness %d out of range 0-100", brightness)
+	}
+	ep, err := c.DetectIREndpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if b := c.backend(ep, channel); b != nil {
+		return b.SetIRBrightness(ctx, brightness)
+	}
+
+	switch ep {
+	case IREndpointHardware:
+		var hw hardwareService
+		if err := c.getXML(ctx, "/ISAPI/System/Hardware", &hw); err != nil {
+			return err
 		}
-		fmt.Println("IR light: off")
+		hw.IrLightSwitch.BrightnessLimit = &brightness
+		return c.putXML(ctx, "/ISAPI/System/Hardware", hw)
 
-	case "status":
-		on, err := cam.GetIRLight()
+	case IREndpointSupplementLight:
+		sl, err := c.GetSupplementLight(ctx, channel)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-		if on {
-			fmt.Println("IR light: on")
+		if sl.BrightnessMode == BrightnessAuto {
+			sl.IRBrightnessLimit = &brightness
 		} else {
-			fmt.Println("IR light: off")
+			sl.BrightnessMode, sl.IRBrightness = BrightnessManual, &brightness
 		}
+		return c.SetSupplementLight(ctx, channel, sl)
+	}
+	return fmt.Errorf("IR brightness is not adjustable through %s", ep)
+}
+
+// GetIRBrightness returns the IR LED brightness, 0-100. ok is false when the
+// camera does not report a brightness.
+func (c *Camera) GetIRBrightness(ctx context.Context) (brightness int, ok bool, err error) {
+	return c.GetChannelIRBrightness(ctx, c.irChannel())
+}
 
-	default:
-		fmt.Fprintf(os.Stderr, "unknown action %q — must be on, off, or status\n", *action)
-		os.Exit(1)
+// GetChannelIRBrightness is like GetIRBrightness for the IR of one video
+// channel rather than Camera.Channel.
+func (c *Camera) GetChannelIRBrightness(ctx context.Context, channel int) (brightness int, ok bool, err error) {
+	ep, err := c.DetectIREndpoint(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if b := c.backend(ep, channel); b != nil {
+		return b.GetIRBrightness(ctx)
+	}
+
+	switch ep {
+	case IREndpointHardware:
+		var hw hardwareService
+		if err := c.getXML(ctx, "/ISAPI/System/Hardware", &hw); err != nil {
+			return 0, false, err
+		}
+		if b := hw.IrLightSwitch.BrightnessLimit; b != nil {
+			return *b, true, nil
+		}
+		return 0, false, nil
+
+	case IREndpointSupplementLight:
+		sl, err := c.GetSupplementLight(ctx, channel)
+		if err != nil {
+			return 0, false, err
+		}
+		b := sl.IRBrightness
+		if sl.BrightnessMode == BrightnessAuto {
+			b = sl.IRBrightnessLimit
+		}
+		if b != nil {
+			return *b, true, nil
+		}
+		return 0, false, nil
 	}
+	return 0, false, nil
 }
@@ -0,0 +1,362 @@
+// Package hikvisiontest runs an in-process HTTP server that emulates the
+// ISAPI endpoints of a single-channel HikVision camera, for tests that
+// exercise the hikvision client end to end without hardware. It serves
+// digest authentication, deviceInfo, capabilities, the Hardware IR switch,
+// the channel 1 ircutFilter, snapshots and the alert stream; anything else
+// answers 404 notSupport.
+//
+//	srv := hikvisiontest.NewServer()
+//	defer srv.Close()
+//	cam := srv.Camera()
+//	cam.SetIRMode(hikvision.IRModeOpen)
+//	if srv.IRMode() != hikvision.IRModeOpen { ... }
+package hikvisiontest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/icholy/digest"
+
+	hikvision "github.com/exploded/hikvision-ir"
+)
+
+// Credentials the server accepts unless changed before the first request.
+const (
+	DefaultUsername = "admin"
+	DefaultPassword = "hik12345"
+)
+
+const realm = "hikvisiontest"
+
+// Server is a fake camera. Its exported fields may be changed before the first
+// request; the emulated state is read and changed through its methods, which
+// are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	Username string
+	Password string
+	// Info is served as /ISAPI/System/deviceInfo.
+	Info hikvision.DeviceInfo
+	// Snapshot is the JPEG served for every streaming channel picture.
+	Snapshot []byte
+
+	nonce string
+
+	mu          sync.Mutex
+	irMode      hikvision.IRMode
+	brightness  int
+	ircut       hikvision.IRCutFilter
+	requests    []string
+	subscribers map[chan hikvision.Event]bool
+}
+
+// NewServer starts a fake camera with IR in auto mode and the IR-cut filter
+// in auto day/night mode. The caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		Username: DefaultUsername,
+		Password: DefaultPassword,
+		Info: hikvision.DeviceInfo{
+			DeviceName:           "hikvisiontest",
+			Model:                "DS-2CD2143G0-I",
+			SerialNumber:         "DS-2CD2143G0-I20240101AAWRTEST0001",
+			MACAddress:           "44:19:b6:00:00:01",
+			FirmwareVersion:      "V5.7.3",
+			FirmwareReleasedDate: "build 220112",
+			DeviceType:           "IPCamera",
+		},
+		Snapshot:    grayJPEG(),
+		nonce:       randomHex(16),
+		irMode:      hikvision.IRModeAuto,
+		brightness:  100,
+		ircut:       hikvision.IRCutFilter{Type: hikvision.IRCutAuto, NightToDayFilterLevel: 4, NightToDayFilterTime: 5},
+		subscribers: map[chan hikvision.Event]bool{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Host returns the host:port of the server, as taken by hikvision.NewCamera.
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Camera returns a client for the server with its credentials.
+func (s *Server) Camera() *hikvision.Camera {
+	return hikvision.NewCamera(s.Host(), hikvision.WithCredentials(s.Username, s.Password))
+}
+
+// IRMode returns the emulated IrLightSwitch mode.
+func (s *Server) IRMode() hikvision.IRMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.irMode
+}
+
+// SetIRMode changes the emulated IrLightSwitch mode, as if from the web UI.
+func (s *Server) SetIRMode(mode hikvision.IRMode) {
+	s.mu.Lock()
+	s.irMode = mode
+	s.mu.Unlock()
+}
+
+// DayNight returns the emulated IR-cut filter mode.
+func (s *Server) DayNight() hikvision.IRCutFilterMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ircut.Type
+}
+
+// Requests returns every authenticated request served so far, as
+// "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Emit sends an event to every open alert stream. DateTime is filled in if
+// empty, and ChannelID defaults to 1. Streams whose client has fallen 16
+// events behind miss it.
+func (s *Server) Emit(e hikvision.Event) {
+	if e.ChannelID == 0 {
+		e.ChannelID = 1
+	}
+	if e.DateTime == "" {
+		e.DateTime = "2024-01-01T12:00:00+00:00"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default: // the client is not reading; drop rather than block
+		}
+	}
+}
+
+// Subscribers returns the number of open alert streams, so a test can wait
+// for SubscribeEvents to connect before calling Emit.
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers)
+}
+
+var pictureRE = regexp.MustCompile(`^/ISAPI/Streaming/channels/\d+/picture$`)
+var ircutRE = regexp.MustCompile(`^/ISAPI/Image/channels/1/ircutFilter$`)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		chal := digest.Challenge{Realm: realm, Nonce: s.nonce, Algorithm: "MD5", QOP: []string{"auth"}}
+		w.Header().Set("WWW-Authenticate", chal.String())
+		writeStatus(w, http.StatusUnauthorized, 4, "Invalid Operation", "badAuthorization")
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case path == "/ISAPI/System/deviceInfo" && r.Method == http.MethodGet:
+		writeXML(w, s.Info)
+	case path == "/ISAPI/System/capabilities" && r.Method == http.MethodGet:
+		writeXML(w, hikvision.Capabilities{VideoInputs: 1, Snapshot: true})
+	case path == "/ISAPI/System/Hardware":
+		s.hardware(w, r)
+	case ircutRE.MatchString(path):
+		s.ircutFilter(w, r)
+	case pictureRE.MatchString(path) && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(s.Snapshot)
+	case path == "/ISAPI/Event/notification/alertStream" && r.Method == http.MethodGet:
+		s.alertStream(w, r)
+	default:
+		writeStatus(w, http.StatusNotFound, 4, "Invalid Operation", "notSupport")
+	}
+}
+
+// hardwareXML is the HardwareService document of /ISAPI/System/Hardware.
+type hardwareXML struct {
+	XMLName         xml.Name `xml:"HardwareService"`
+	Mode            string   `xml:"IrLightSwitch>mode"`
+	BrightnessLimit *int     `xml:"IrLightSwitch>brightnessLimit,omitempty"`
+}
+
+func (s *Server) hardware(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		brightness := s.brightness
+		doc := hardwareXML{Mode: string(s.irMode), BrightnessLimit: &brightness}
+		s.mu.Unlock()
+		writeXML(w, doc)
+	case http.MethodPut:
+		var doc hardwareXML
+		if err := xml.NewDecoder(r.Body).Decode(&doc); err != nil {
+			writeStatus(w, http.StatusBadRequest, 5, "Invalid XML Format", "badXmlFormat")
+			return
+		}
+		mode := hikvision.IRMode(doc.Mode)
+		switch mode {
+		case hikvision.IRModeOpen, hikvision.IRModeClose, hikvision.IRModeAuto:
+		default:
+			writeStatus(w, http.StatusBadRequest, 6, "Invalid XML Content", "badParameters")
+			return
+		}
+		s.mu.Lock()
+		s.irMode = mode
+		if doc.BrightnessLimit != nil {
+			s.brightness = *doc.BrightnessLimit
+		}
+		s.mu.Unlock()
+		writeStatus(w, http.StatusOK, 1, "OK", "ok")
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, 4, "Invalid Operation", "methodNotAllowed")
+	}
+}
+
+func (s *Server) ircutFilter(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		doc := s.ircut
+		s.mu.Unlock()
+		writeXML(w, doc)
+	case http.MethodPut:
+		var doc hikvision.IRCutFilter
+		if err := xml.NewDecoder(r.Body).Decode(&doc); err != nil {
+			writeStatus(w, http.StatusBadRequest, 5, "Invalid XML Format", "badXmlFormat")
+			return
+		}
+		switch doc.Type {
+		case hikvision.IRCutDay, hikvision.IRCutNight, hikvision.IRCutAuto, hikvision.IRCutSchedule:
+		default:
+			writeStatus(w, http.StatusBadRequest, 6, "Invalid XML Content", "badParameters")
+			return
+		}
+		doc.XMLName = xml.Name{}
+		s.mu.Lock()
+		s.ircut = doc
+		s.mu.Unlock()
+		writeStatus(w, http.StatusOK, 1, "OK", "ok")
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, 4, "Invalid Operation", "methodNotAllowed")
+	}
+}
+
+// alertStream serves a multipart/mixed alert stream carrying the events
+// passed to Emit until the client disconnects or the server closes.
+func (s *Server) alertStream(w http.ResponseWriter, r *http.Request) {
+	ch := make(chan hikvision.Event, 16)
+	s.mu.Lock()
+	s.subscribers[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			body, err := xml.Marshal(e)
+			if err != nil {
+				return
+			}
+			part, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":   {`application/xml; charset="UTF-8"`},
+				"Content-Length": {strconv.Itoa(len(body))},
+			})
+			if err != nil {
+				return
+			}
+			part.Write(body)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// authorized checks the request's digest credentials against the server's
+// nonce and password.
+func (s *Server) authorized(r *http.Request) bool {
+	cred, err := digest.ParseCredentials(r.Header.Get("Authorization"))
+	if err != nil || cred.Username != s.Username || cred.Nonce != s.nonce {
+		return false
+	}
+	want, err := digest.Digest(&digest.Challenge{Realm: realm, Nonce: s.nonce, Algorithm: cred.Algorithm, QOP: []string{cred.QOP}}, digest.Options{
+		Method:   r.Method,
+		URI:      cred.URI,
+		Count:    cred.Nc,
+		Cnonce:   cred.Cnonce,
+		Username: s.Username,
+		Password: s.Password,
+	})
+	return err == nil && want.Response == cred.Response
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	w.Write(body)
+}
+
+// writeStatus writes an ISAPI ResponseStatus document.
+func writeStatus(w http.ResponseWriter, httpStatus, code int, status, sub string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(httpStatus)
+	fmt.Fprintf(w, `%s<ResponseStatus version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">`+
+		`<requestURL></requestURL><statusCode>%d</statusCode><statusString>%s</statusString>`+
+		`<subStatusCode>%s</subStatusCode></ResponseStatus>`, xml.Header, code, status, sub)
+}
+
+// grayJPEG returns a small valid JPEG to serve as the default snapshot.
+func grayJPEG() []byte {
+	img := image.NewGray(image.Rect(0, 0, 16, 9))
+	for i := range img.Pix {
+		img.Pix[i] = color.Gray{Y: 128}.Y
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	return buf.Bytes()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package hikvisiontest_test
+
+import (
+	"context"
+	"errors"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	hikvision "github.com/exploded/hikvision-ir"
+	"github.com/exploded/hikvision-ir/hikvisiontest"
+)
+
+func TestIRMode(t *testing.T) {
+	srv := hikvisiontest.NewServer()
+	defer srv.Close()
+	cam := srv.Camera()
+
+	if err := cam.SetIRMode(hikvision.IRModeOpen); err != nil {
+		t.Fatalf("SetIRMode: %v", err)
+	}
+	if got := srv.IRMode(); got != hikvision.IRModeOpen {
+		t.Errorf("server IR mode = %q, want %q", got, hikvision.IRModeOpen)
+	}
+
+	srv.SetIRMode(hikvision.IRModeClose)
+	mode, err := cam.GetIRMode()
+	if err != nil {
+		t.Fatalf("GetIRMode: %v", err)
+	}
+	if mode != hikvision.IRModeClose {
+		t.Errorf("GetIRMode = %q, want %q", mode, hikvision.IRModeClose)
+	}
+}
+
+func TestSetIRCutFilterMode(t *testing.T) {
+	srv := hikvisiontest.NewServer()
+	defer srv.Close()
+
+	if err := srv.Camera().SetIRCutFilterMode(context.Background(), 1, hikvision.IRCutNight); err != nil {
+		t.Fatalf("SetIRCutFilterMode: %v", err)
+	}
+	if got := srv.DayNight(); got != hikvision.IRCutNight {
+		t.Errorf("server day/night = %q, want %q", got, hikvision.IRCutNight)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := hikvisiontest.NewServer()
+	defer srv.Close()
+
+	r, err := srv.Camera().Snapshot(context.Background(), hikvision.StreamingChannelID(1, 1))
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	defer r.Close()
+	if _, err := jpeg.Decode(r); err != nil {
+		t.Errorf("decode snapshot: %v", err)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	srv := hikvisiontest.NewServer()
+	defer srv.Close()
+	cam := hikvision.NewCamera(srv.Host(), hikvision.WithCredentials(srv.Username, "wrong"))
+
+	if _, err := cam.GetIRMode(); !errors.Is(err, hikvision.ErrUnauthorized) {
+		t.Errorf("GetIRMode with a wrong password = %v, want ErrUnauthorized", err)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("server served %d authenticated requests, want 0", n)
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	srv := hikvisiontest.NewServer()
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := srv.Camera().SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeEvents: %v", err)
+	}
+	defer s.Close()
+	for srv.Subscribers() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("alert stream never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv.Emit(hikvision.Event{Type: hikvision.EventMotion, State: "active"})
+	select {
+	case e, ok := <-s.C:
+		if !ok {
+			t.Fatalf("stream closed: %v", s.Err())
+		}
+		if e.Type != hikvision.EventMotion || !e.Active() || e.ChannelID != 1 {
+			t.Errorf("event = %+v, want an active VMD on channel 1", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("no event received")
+	}
+}
@@ -0,0 +1,77 @@
+package hikvision
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SplitHost parses a camera address: an IPv4 or IPv6 literal or a host name,
+// optionally with a port, such as "192.168.1.4", "cam.example.com:8080",
+// "::1" or "[fe80::1%eth0]:8443". port is zero when the address has none.
+func SplitHost(host string) (name string, port int, err error) {
+	name = host
+	if h, p, serr := net.SplitHostPort(host); serr == nil {
+		name = h
+		if port, err = strconv.Atoi(p); err != nil || port < 1 || port > 65535 {
+			return "", 0, fmt.Errorf("invalid port in host %q", host)
+		}
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		name = host[1 : len(host)-1]
+	}
+
+	if strings.Contains(name, ":") {
+		if _, err := netip.ParseAddr(name); err != nil {
+			return "", 0, fmt.Errorf("invalid host %q: want host, host:port or [IPv6]:port", host)
+		}
+		return name, port, nil
+	}
+	if name == "" || len(name) > 253 {
+		return "", 0, fmt.Errorf("invalid host %q", host)
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 || strings.Trim(label, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+			return "", 0, fmt.Errorf("invalid host %q", host)
+		}
+	}
+	return name, port, nil
+}
+
+// JoinHost is the inverse of SplitHost: it brackets IPv6 literals and adds
+// the port unless it is zero.
+func JoinHost(name string, port int) string {
+	if port > 0 {
+		return net.JoinHostPort(name, strconv.Itoa(port))
+	}
+	if strings.Contains(name, ":") {
+		return "[" + name + "]"
+	}
+	return name
+}
+
+// baseURL returns the scheme and normalised host of the camera, such as
+// "http://[::1]:8080". A Host that does not parse is used as is, so the error
+// surfaces from the request.
+func (c *Camera) baseURL() string {
+	scheme := c.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := c.Host
+	if name, port, err := SplitHost(host); err == nil {
+		host = JoinHost(name, port)
+	}
+	// url.URL escapes the % of an IPv6 zone.
+	return (&url.URL{Scheme: scheme, Host: host}).String()
+}
+
+// hostname returns the Host without its port or IPv6 brackets.
+func hostname(host string) string {
+	if name, _, err := SplitHost(host); err == nil {
+		return name
+	}
+	return host
+}
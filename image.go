@@ -0,0 +1,503 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+func imagePath(channel int, setting string) string {
+	return fmt.Sprintf("/ISAPI/Image/channels/%d/%s", channel, setting)
+}
+
+// ImageColor is the picture adjustment of a video channel, as served by
+// /ISAPI/Image/channels/N/color. Levels are 0-100. Elements the client does
+// not model, such as hueLevel and grayScale, are kept and sent back
+// unchanged by SetImageColor.
+type ImageColor struct {
+	XMLName    xml.Name     `xml:"Color"`
+	Brightness int          `xml:"brightnessLevel"`
+	Contrast   int          `xml:"contrastLevel"`
+	Saturation int          `xml:"saturationLevel"`
+	Extra      []rawElement `xml:",any"`
+}
+
+// GetImageColor returns the brightness, contrast and saturation of a video
+// channel.
+func (c *Camera) GetImageColor(ctx context.Context, channel int) (*ImageColor, error) {
+	var col ImageColor
+	if err := c.getXML(ctx, imagePath(channel, "color"), &col); err != nil {
+		return nil, err
+	}
+	return &col, nil
+}
+
+// SetImageColor replaces the brightness, contrast and saturation of a video
+// channel. Start from GetImageColor so that unmodelled settings are
+// preserved.
+func (c *Camera) SetImageColor(ctx context.Context, channel int, col *ImageColor) error {
+	return c.putXML(ctx, imagePath(channel, "color"), col)
+}
+
+// Sharpness is the sharpening level (0-100) of a video channel, as served by
+// /ISAPI/Image/channels/N/sharpness.
+type Sharpness struct {
+	XMLName xml.Name     `xml:"Sharpness"`
+	Level   int          `xml:"SharpnessLevel"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetSharpness returns the sharpening level of a video channel.
+func (c *Camera) GetSharpness(ctx context.Context, channel int) (int, error) {
+	var s Sharpness
+	if err := c.getXML(ctx, imagePath(channel, "sharpness"), &s); err != nil {
+		return 0, err
+	}
+	return s.Level, nil
+}
+
+// SetSharpness sets the sharpening level (0-100) of a video channel, keeping
+// the rest of its sharpness settings.
+func (c *Camera) SetSharpness(ctx context.Context, channel int, level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("sharpness %d out of range 0-100", level)
+	}
+	var s Sharpness
+	if err := c.getXML(ctx, imagePath(channel, "sharpness"), &s); err != nil {
+		return err
+	}
+	s.Level = level
+	return c.putXML(ctx, imagePath(channel, "sharpness"), &s)
+}
+
+// ExposureMode selects how a video channel controls exposure.
+type ExposureMode string
+
+// Exposure modes: fully automatic, automatic with the iris, shutter or gain
+// held at the configured value, or fully manual.
+const (
+	ExposureAuto         ExposureMode = "auto"
+	ExposureIrisFirst    ExposureMode = "IrisFirst"
+	ExposureShutterFirst ExposureMode = "ShutterFirst"
+	ExposureGainFirst    ExposureMode = "GainFirst"
+	ExposureManual       ExposureMode = "manual"
+)
+
+// Exposure is the exposure configuration of a video channel, which ISAPI
+// splits across /ISAPI/Image/channels/N/exposure, /shutter and /gain.
+type Exposure struct {
+	Mode ExposureMode
+	// Shutter is the shutter time, e.g. "1/25". In the automatic modes it is
+	// the longest shutter the camera will use.
+	Shutter string
+	// Gain is the gain level 0-100. In the automatic modes it is the upper
+	// limit.
+	Gain int
+}
+
+// exposureXML, shutterXML and gainXML keep the elements the client does not
+// model, such as the iris level, so that SetExposure changes only the one
+// setting of each document.
+type exposureXML struct {
+	XMLName xml.Name     `xml:"Exposure"`
+	Type    ExposureMode `xml:"ExposureType"`
+	Extra   []rawElement `xml:",any"`
+}
+
+type shutterXML struct {
+	XMLName xml.Name     `xml:"Shutter"`
+	Level   string       `xml:"ShutterLevel"`
+	Extra   []rawElement `xml:",any"`
+}
+
+type gainXML struct {
+	XMLName xml.Name     `xml:"Gain"`
+	Level   int          `xml:"GainLevel"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetExposure returns the exposure configuration of a video channel. On
+// cameras without a shutter or gain setting, Shutter is empty and Gain is -1.
+func (c *Camera) GetExposure(ctx context.Context, channel int) (*Exposure, error) {
+	var ex exposureXML
+	if err := c.getXML(ctx, imagePath(channel, "exposure"), &ex); err != nil {
+		return nil, err
+	}
+	e := &Exposure{Mode: ex.Type, Gain: -1}
+
+	var sh shutterXML
+	switch err := c.getXML(ctx, imagePath(channel, "shutter"), &sh); {
+	case err == nil:
+		e.Shutter = sh.Level
+	case !errors.Is(err, ErrNotSupported):
+		return nil, err
+	}
+	var g gainXML
+	switch err := c.getXML(ctx, imagePath(channel, "gain"), &g); {
+	case err == nil:
+		e.Gain = g.Level
+	case !errors.Is(err, ErrNotSupported):
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetExposure changes the exposure configuration of a video channel, keeping
+// the settings Exposure does not model. An empty Shutter or a negative Gain
+// leaves that setting unchanged.
+func (c *Camera) SetExposure(ctx context.Context, channel int, e *Exposure) error {
+	if e.Gain > 100 {
+		return fmt.Errorf("gain %d out of range 0-100", e.Gain)
+	}
+	var ex exposureXML
+	if err := c.getXML(ctx, imagePath(channel, "exposure"), &ex); err != nil {
+		return err
+	}
+	ex.Type = e.Mode
+	if err := c.putXML(ctx, imagePath(channel, "exposure"), &ex); err != nil {
+		return err
+	}
+	if e.Shutter != "" {
+		var sh shutterXML
+		if err := c.getXML(ctx, imagePath(channel, "shutter"), &sh); err != nil {
+			return err
+		}
+		sh.Level = e.Shutter
+		if err := c.putXML(ctx, imagePath(channel, "shutter"), &sh); err != nil {
+			return err
+		}
+	}
+	if e.Gain >= 0 {
+		var g gainXML
+		if err := c.getXML(ctx, imagePath(channel, "gain"), &g); err != nil {
+			return err
+		}
+		g.Level = e.Gain
+		if err := c.putXML(ctx, imagePath(channel, "gain"), &g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WDRMode is the wide dynamic range mode of a video channel.
+type WDRMode string
+
+// WDR modes.
+const (
+	WDROn   WDRMode = "open"
+	WDROff  WDRMode = "close"
+	WDRAuto WDRMode = "auto"
+)
+
+// WDR is the wide dynamic range configuration of a video channel, as served
+// by /ISAPI/Image/channels/N/WDR.
+type WDR struct {
+	XMLName xml.Name `xml:"WDR"`
+	Mode    WDRMode  `xml:"mode"`
+	// Level is the WDR strength 0-100, used when Mode is WDROn.
+	Level int          `xml:"WDRLevel"`
+	Extra []rawElement `xml:",any"`
+}
+
+// GetWDR returns the wide dynamic range configuration of a video channel.
+func (c *Camera) GetWDR(ctx context.Context, channel int) (*WDR, error) {
+	var wdr WDR
+	if err := c.getXML(ctx, imagePath(channel, "WDR"), &wdr); err != nil {
+		return nil, err
+	}
+	return &wdr, nil
+}
+
+// SetWDR replaces the wide dynamic range configuration of a video channel.
+// Start from GetWDR so that unmodelled settings are preserved.
+func (c *Camera) SetWDR(ctx context.Context, channel int, wdr *WDR) error {
+	return c.putXML(ctx, imagePath(channel, "WDR"), wdr)
+}
+
+// BLCArea is the part of the picture backlight compensation exposes for.
+type BLCArea string
+
+// BLC areas.
+const (
+	BLCUp     BLCArea = "UP"
+	BLCDown   BLCArea = "DOWN"
+	BLCLeft   BLCArea = "LEFT"
+	BLCRight  BLCArea = "RIGHT"
+	BLCCenter BLCArea = "CENTER"
+)
+
+// BLC is the backlight compensation configuration of a video channel, as
+// served by /ISAPI/Image/channels/N/BLC.
+type BLC struct {
+	XMLName xml.Name     `xml:"BLC"`
+	Enabled bool         `xml:"enabled"`
+	Area    BLCArea      `xml:"BLCMode,omitempty"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetBLC returns the backlight compensation configuration of a video channel.
+func (c *Camera) GetBLC(ctx context.Context, channel int) (*BLC, error) {
+	var blc BLC
+	if err := c.getXML(ctx, imagePath(channel, "BLC"), &blc); err != nil {
+		return nil, err
+	}
+	return &blc, nil
+}
+
+// SetBLC replaces the backlight compensation configuration of a video channel.
+// Start from GetBLC so that unmodelled settings are preserved.
+func (c *Camera) SetBLC(ctx context.Context, channel int, blc *BLC) error {
+	return c.putXML(ctx, imagePath(channel, "BLC"), blc)
+}
+
+// HLC is the highlight compensation configuration of a video channel, as
+// served by /ISAPI/Image/channels/N/HLC. HLC masks strong light sources such
+// as headlights.
+type HLC struct {
+	XMLName xml.Name `xml:"HLC"`
+	Enabled bool     `xml:"enabled"`
+	// Level is the compensation strength 0-100.
+	Level int          `xml:"HLCLevel"`
+	Extra []rawElement `xml:",any"`
+}
+
+// GetHLC returns the highlight compensation configuration of a video channel.
+func (c *Camera) GetHLC(ctx context.Context, channel int) (*HLC, error) {
+	var hlc HLC
+	if err := c.getXML(ctx, imagePath(channel, "HLC"), &hlc); err != nil {
+		return nil, err
+	}
+	return &hlc, nil
+}
+
+// SetHLC replaces the highlight compensation configuration of a video channel.
+// Start from GetHLC so that unmodelled settings are preserved.
+func (c *Camera) SetHLC(ctx context.Context, channel int, hlc *HLC) error {
+	return c.putXML(ctx, imagePath(channel, "HLC"), hlc)
+}
+
+// NoiseReductionMode is the digital noise reduction (DNR) mode of a video
+// channel.
+type NoiseReductionMode string
+
+// Noise reduction modes. Expert mode sets spatial and temporal strength
+// separately.
+const (
+	NoiseReductionOff     NoiseReductionMode = "close"
+	NoiseReductionGeneral NoiseReductionMode = "general"
+	NoiseReductionExpert  NoiseReductionMode = "advanced"
+)
+
+// NoiseReduction is the DNR configuration of a video channel, as served by
+// /ISAPI/Image/channels/N/noiseReduce. Levels are 0-100. General and Expert
+// are nil when the camera does not report them, and are then not sent back.
+type NoiseReduction struct {
+	XMLName xml.Name               `xml:"NoiseReduce"`
+	Mode    NoiseReductionMode     `xml:"mode"`
+	General *GeneralNoiseReduction `xml:"GeneralMode,omitempty"`
+	Expert  *ExpertNoiseReduction  `xml:"AdvancedMode,omitempty"`
+	Extra   []rawElement           `xml:",any"`
+}
+
+// GeneralNoiseReduction is the strength of NoiseReductionGeneral.
+type GeneralNoiseReduction struct {
+	Level int          `xml:"generalLevel"`
+	Extra []rawElement `xml:",any"`
+}
+
+// ExpertNoiseReduction is the strength of NoiseReductionExpert, within a
+// frame (spatial) and across frames (temporal).
+type ExpertNoiseReduction struct {
+	SpatialLevel  int          `xml:"FrameNoiseReduceLevel"`
+	TemporalLevel int          `xml:"InterFrameNoiseReduceLevel"`
+	Extra         []rawElement `xml:",any"`
+}
+
+// GetNoiseReduction returns the DNR configuration of a video channel.
+func (c *Camera) GetNoiseReduction(ctx context.Context, channel int) (*NoiseReduction, error) {
+	var nr NoiseReduction
+	if err := c.getXML(ctx, imagePath(channel, "noiseReduce"), &nr); err != nil {
+		return nil, err
+	}
+	return &nr, nil
+}
+
+// SetNoiseReduction replaces the DNR configuration of a video channel.
+// Start from GetNoiseReduction so that unmodelled settings are preserved.
+func (c *Camera) SetNoiseReduction(ctx context.Context, channel int, nr *NoiseReduction) error {
+	return c.putXML(ctx, imagePath(channel, "noiseReduce"), nr)
+}
+
+// WhiteBalanceMode is the white balance style of a video channel.
+type WhiteBalanceMode string
+
+// White balance modes: automatic, manual (WhiteBalance.Red and Blue), locked
+// at the current value, or a preset for a light source.
+const (
+	WhiteBalanceAuto         WhiteBalanceMode = "auto"
+	WhiteBalanceManual       WhiteBalanceMode = "manual"
+	WhiteBalanceLocked       WhiteBalanceMode = "locked"
+	WhiteBalanceIndoor       WhiteBalanceMode = "indoor"
+	WhiteBalanceOutdoor      WhiteBalanceMode = "outdoor"
+	WhiteBalanceFluorescent  WhiteBalanceMode = "fluorescentlamp"
+	WhiteBalanceIncandescent WhiteBalanceMode = "incandescentlamp"
+	WhiteBalanceWarmLight    WhiteBalanceMode = "warmlightlamp"
+	WhiteBalanceNaturalLight WhiteBalanceMode = "naturallight"
+	WhiteBalanceSodiumLamp   WhiteBalanceMode = "sodiumlamp"
+	WhiteBalanceMercuryLamp  WhiteBalanceMode = "mercurylamp"
+)
+
+// WhiteBalance is the white balance configuration of a video channel, as
+// served by /ISAPI/Image/channels/N/whiteBalance.
+type WhiteBalance struct {
+	XMLName xml.Name         `xml:"WhiteBalance"`
+	Mode    WhiteBalanceMode `xml:"WhiteBalanceStyle"`
+	// Red and Blue are the 0-100 channel gains used in manual mode, nil
+	// when the camera does not report them, so that a gain of 0 can be
+	// told apart and sent.
+	Red   *int         `xml:"WhiteBalanceRed,omitempty"`
+	Blue  *int         `xml:"WhiteBalanceBlue,omitempty"`
+	Extra []rawElement `xml:",any"`
+}
+
+// GetWhiteBalance returns the white balance configuration of a video channel.
+func (c *Camera) GetWhiteBalance(ctx context.Context, channel int) (*WhiteBalance, error) {
+	var wb WhiteBalance
+	if err := c.getXML(ctx, imagePath(channel, "whiteBalance"), &wb); err != nil {
+		return nil, err
+	}
+	return &wb, nil
+}
+
+// SetWhiteBalance replaces the white balance configuration of a video
+// channel. Start from GetWhiteBalance so that unmodelled settings are
+// preserved.
+func (c *Camera) SetWhiteBalance(ctx context.Context, channel int, wb *WhiteBalance) error {
+	return c.putXML(ctx, imagePath(channel, "whiteBalance"), wb)
+}
+
+// FlipStyle is how ImageFlip transforms the picture.
+type FlipStyle string
+
+// Flip styles: mirrored left to right, flipped upside down, or rotated 180°
+// (both at once).
+const (
+	FlipMirror     FlipStyle = "LEFTRIGHT"
+	FlipUpsideDown FlipStyle = "UPDOWN"
+	FlipRotate180  FlipStyle = "CENTER"
+)
+
+// ImageFlip is the mirror/flip configuration of a video channel, as served by
+// /ISAPI/Image/channels/N/imageFlip.
+type ImageFlip struct {
+	XMLName xml.Name     `xml:"ImageFlip"`
+	Enabled bool         `xml:"enabled"`
+	Style   FlipStyle    `xml:"ImageFlipStyle,omitempty"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetImageFlip returns the mirror/flip configuration of a video channel.
+func (c *Camera) GetImageFlip(ctx context.Context, channel int) (*ImageFlip, error) {
+	var f ImageFlip
+	if err := c.getXML(ctx, imagePath(channel, "imageFlip"), &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetImageFlip replaces the mirror/flip configuration of a video channel.
+// Start from GetImageFlip so that unmodelled settings are preserved.
+func (c *Camera) SetImageFlip(ctx context.Context, channel int, f *ImageFlip) error {
+	return c.putXML(ctx, imagePath(channel, "imageFlip"), f)
+}
+
+type corridorXML struct {
+	XMLName xml.Name     `xml:"Corridor"`
+	Enabled bool         `xml:"enabled"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetCorridorMode reports whether corridor mode, which rotates the picture
+// 90° into a portrait frame for hallways, is enabled on a video channel.
+func (c *Camera) GetCorridorMode(ctx context.Context, channel int) (bool, error) {
+	var cor corridorXML
+	if err := c.getXML(ctx, imagePath(channel, "corridor"), &cor); err != nil {
+		return false, err
+	}
+	return cor.Enabled, nil
+}
+
+// SetCorridorMode enables or disables corridor mode on a video channel,
+// keeping the rest of its corridor settings.
+func (c *Camera) SetCorridorMode(ctx context.Context, channel int, enabled bool) error {
+	var cor corridorXML
+	if err := c.getXML(ctx, imagePath(channel, "corridor"), &cor); err != nil {
+		return err
+	}
+	cor.Enabled = enabled
+	return c.putXML(ctx, imagePath(channel, "corridor"), &cor)
+}
+
+// DefogMode is the defog (dehaze) mode of a video channel.
+type DefogMode string
+
+// Defog modes.
+const (
+	DefogOn   DefogMode = "open"
+	DefogOff  DefogMode = "close"
+	DefogAuto DefogMode = "auto"
+)
+
+// Defog is the defog configuration of a video channel, as served by
+// /ISAPI/Image/channels/N/dehaze.
+type Defog struct {
+	XMLName xml.Name  `xml:"Dehaze"`
+	Mode    DefogMode `xml:"DehazeMode"`
+	// Level is the defog strength 0-100, used when Mode is DefogOn.
+	Level int          `xml:"DehazeLevel,omitempty"`
+	Extra []rawElement `xml:",any"`
+}
+
+// GetDefog returns the defog configuration of a video channel.
+func (c *Camera) GetDefog(ctx context.Context, channel int) (*Defog, error) {
+	var d Defog
+	if err := c.getXML(ctx, imagePath(channel, "dehaze"), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SetDefog replaces the defog configuration of a video channel. Start from
+// GetDefog so that unmodelled settings are preserved.
+func (c *Camera) SetDefog(ctx context.Context, channel int, d *Defog) error {
+	return c.putXML(ctx, imagePath(channel, "dehaze"), d)
+}
+
+type eisXML struct {
+	XMLName xml.Name     `xml:"EIS"`
+	Enabled bool         `xml:"enabled"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetEIS reports whether electronic image stabilization is enabled on a
+// video channel.
+func (c *Camera) GetEIS(ctx context.Context, channel int) (bool, error) {
+	var e eisXML
+	if err := c.getXML(ctx, imagePath(channel, "EIS"), &e); err != nil {
+		return false, err
+	}
+	return e.Enabled, nil
+}
+
+// SetEIS enables or disables electronic image stabilization on a video
+// channel, keeping the rest of its stabilization settings.
+func (c *Camera) SetEIS(ctx context.Context, channel int, enabled bool) error {
+	var e eisXML
+	if err := c.getXML(ctx, imagePath(channel, "EIS"), &e); err != nil {
+		return err
+	}
+	e.Enabled = enabled
+	return c.putXML(ctx, imagePath(channel, "EIS"), &e)
+}
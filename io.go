@@ -0,0 +1,133 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// AlarmOutput is the state of one alarm (relay) output of the camera.
+type AlarmOutput struct {
+	ID     int
+	Active bool
+}
+
+type ioPortStatusList struct {
+	XMLName xml.Name `xml:"IOPortStatusList"`
+	Ports   []struct {
+		ID    int    `xml:"ioPortID"`
+		State string `xml:"ioState"`
+	} `xml:"IOPortStatus"`
+}
+
+// getIOStatus returns whether each port in an IOPortStatusList is active, by
+// port ID.
+func (c *Camera) getIOStatus(ctx context.Context, path string) (ids []int, active map[int]bool, err error) {
+	var list ioPortStatusList
+	if err := c.getXML(ctx, path, &list); err != nil {
+		return nil, nil, err
+	}
+	active = make(map[int]bool, len(list.Ports))
+	for _, p := range list.Ports {
+		ids = append(ids, p.ID)
+		active[p.ID] = p.State == "active"
+	}
+	return ids, active, nil
+}
+
+// GetAlarmOutputs returns the state of every alarm output, as served by
+// /ISAPI/System/IO/outputs/status.
+func (c *Camera) GetAlarmOutputs(ctx context.Context) ([]AlarmOutput, error) {
+	ids, active, err := c.getIOStatus(ctx, "/ISAPI/System/IO/outputs/status")
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([]AlarmOutput, len(ids))
+	for i, id := range ids {
+		outputs[i] = AlarmOutput{ID: id, Active: active[id]}
+	}
+	return outputs, nil
+}
+
+type ioPortData struct {
+	XMLName     xml.Name `xml:"IOPortData"`
+	OutputState string   `xml:"outputState"`
+}
+
+// SetAlarmOutput activates or deactivates alarm output id. Calls PUT
+// /ISAPI/System/IO/outputs/<id>/trigger.
+func (c *Camera) SetAlarmOutput(ctx context.Context, id int, active bool) error {
+	state := "low"
+	if active {
+		state = "high"
+	}
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/IO/outputs/%d/trigger", id), &ioPortData{OutputState: state})
+}
+
+// PulseAlarmOutput activates alarm output id for d, then deactivates it. The
+// output is deactivated even if ctx is cancelled during the pulse.
+func (c *Camera) PulseAlarmOutput(ctx context.Context, id int, d time.Duration) error {
+	if err := c.SetAlarmOutput(ctx, id, true); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		t.Stop()
+	case <-t.C:
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+	return c.SetAlarmOutput(ctx, id, false)
+}
+
+// Alarm input triggering levels for AlarmInput.Triggering.
+const (
+	// TriggerLow fires when the contact closes (normally open, "NO").
+	TriggerLow = "low"
+	// TriggerHigh fires when the contact opens (normally closed, "NC").
+	TriggerHigh = "high"
+)
+
+// AlarmInput is one alarm input of the camera, such as a wired PIR sensor, as
+// served by /ISAPI/System/IO/inputs/<id>.
+type AlarmInput struct {
+	XMLName    xml.Name `xml:"IOInputPort"`
+	ID         int      `xml:"id"`
+	Name       string   `xml:"name,omitempty"`
+	Enabled    bool     `xml:"enabled"`
+	Triggering string   `xml:"triggering"`
+	// Active is the current state, read from /ISAPI/System/IO/inputs/status.
+	// It is not sent by SetAlarmInput.
+	Active bool         `xml:"-"`
+	Extra  []rawElement `xml:",any"`
+}
+
+type ioInputPortList struct {
+	XMLName xml.Name     `xml:"IOInputPortList"`
+	Ports   []AlarmInput `xml:"IOInputPort"`
+}
+
+// GetAlarmInputs returns the configuration and current state of every alarm
+// input.
+func (c *Camera) GetAlarmInputs(ctx context.Context) ([]AlarmInput, error) {
+	var list ioInputPortList
+	if err := c.getXML(ctx, "/ISAPI/System/IO/inputs", &list); err != nil {
+		return nil, err
+	}
+	_, active, err := c.getIOStatus(ctx, "/ISAPI/System/IO/inputs/status")
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Ports {
+		list.Ports[i].Active = active[list.Ports[i].ID]
+	}
+	return list.Ports, nil
+}
+
+// SetAlarmInput replaces the configuration of alarm input in.ID.
+func (c *Camera) SetAlarmInput(ctx context.Context, in *AlarmInput) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/IO/inputs/%d", in.ID), in)
+}
@@ -0,0 +1,104 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// IRCutFilterMode selects when the IR-cut filter is engaged.
+type IRCutFilterMode string
+
+const (
+	// IRCutDay keeps the filter in (colour image, no IR sensitivity).
+	IRCutDay IRCutFilterMode = "day"
+	// IRCutNight keeps the filter out (monochrome, IR sensitive).
+	IRCutNight IRCutFilterMode = "night"
+	// IRCutAuto switches based on the camera's light sensor.
+	IRCutAuto IRCutFilterMode = "auto"
+	// IRCutSchedule switches at the times given in IRCutFilter.Schedule.
+	IRCutSchedule IRCutFilterMode = "schedule"
+)
+
+// IRCutFilter is the day/night configuration of a video channel, as served by
+// /ISAPI/Image/channels/N/ircutFilter.
+type IRCutFilter struct {
+	XMLName xml.Name        `xml:"IrcutFilter"`
+	Type    IRCutFilterMode `xml:"IrcutFilterType"`
+	// NightToDayFilterLevel is the auto-mode switching sensitivity (0-7).
+	NightToDayFilterLevel int `xml:"nightToDayFilterLevel,omitempty"`
+	// NightToDayFilterTime is the auto-mode switching delay in seconds.
+	NightToDayFilterTime int               `xml:"nightToDayFilterTime,omitempty"`
+	Schedule             *DayNightSchedule `xml:"Schedule,omitempty"`
+}
+
+// DayNightSchedule is the daytime window used in IRCutSchedule mode.
+// Times are "HH:MM:SS" in camera local time.
+type DayNightSchedule struct {
+	ScheduleType string `xml:"scheduleType,omitempty"`
+	BeginTime    string `xml:"TimeRange>beginTime"`
+	EndTime      string `xml:"TimeRange>endTime"`
+}
+
+// legacy reports whether IR-cut requests go to the PSIA API: once the camera
+// is detected to run legacy firmware, or when IREndpointLegacy is forced.
+func (c *Camera) legacy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.irEndpoint == IREndpointLegacy || c.IREndpoint == IREndpointLegacy
+}
+
+// GetIRCutFilter returns the day/night configuration of a video channel.
+// On firmware older than 5.x, once detected by DetectIREndpoint or forced
+// with IREndpointLegacy, the legacy PSIA resource is used instead.
+func (c *Camera) GetIRCutFilter(ctx context.Context, channel int) (*IRCutFilter, error) {
+	if c.legacy() {
+		return c.psia(channel).GetIRCutFilter(ctx, channel)
+	}
+	var f IRCutFilter
+	if err := c.getXML(ctx, fmt.Sprintf("/ISAPI/Image/channels/%d/ircutFilter", channel), &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetIRCutFilter replaces the day/night configuration of a video channel.
+func (c *Camera) SetIRCutFilter(ctx context.Context, channel int, f *IRCutFilter) error {
+	if c.legacy() {
+		return c.psia(channel).SetIRCutFilter(ctx, channel, f)
+	}
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/Image/channels/%d/ircutFilter", channel), f)
+}
+
+// SetIRCutFilterMode changes only the day/night mode of a video channel,
+// keeping the camera's other IR-cut settings.
+func (c *Camera) SetIRCutFilterMode(ctx context.Context, channel int, mode IRCutFilterMode) error {
+	f, err := c.GetIRCutFilter(ctx, channel)
+	if err != nil {
+		return err
+	}
+	f.Type = mode
+	return c.SetIRCutFilter(ctx, channel, f)
+}
+
+// irCutForIR returns the day/night mode that turns the IR to mode on cameras
+// whose IR LEDs follow the IR-cut filter.
+func irCutForIR(mode IRMode) (IRCutFilterMode, error) {
+	filter := map[IRMode]IRCutFilterMode{IRModeOpen: IRCutNight, IRModeClose: IRCutDay, IRModeAuto: IRCutAuto}[mode]
+	if filter == "" {
+		return "", fmt.Errorf("IR mode %q has no day/night equivalent", mode)
+	}
+	return filter, nil
+}
+
+// irForIRCut returns the IR mode that the day/night mode amounts to on
+// cameras whose IR LEDs follow the IR-cut filter.
+func irForIRCut(mode IRCutFilterMode) IRMode {
+	switch mode {
+	case IRCutNight:
+		return IRModeOpen
+	case IRCutDay:
+		return IRModeClose
+	}
+	return IRModeAuto
+}
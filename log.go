@@ -0,0 +1,163 @@
+package hikvision
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelTrace is the log level below slog.LevelDebug at which a Camera logs
+// the headers and bodies of its HTTP exchanges, besides the request lines
+// logged at slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// maxTraceBody caps how much of a body is logged at LevelTrace.
+const maxTraceBody = 4096
+
+// logTransport logs the HTTP exchanges of base. It sits underneath
+// authentication, so challenges and retried requests are logged as sent.
+type logTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.logger.Enabled(ctx, slog.LevelDebug) {
+		return t.base.RoundTrip(req)
+	}
+	trace := t.logger.Enabled(ctx, LevelTrace)
+	if trace {
+		attrs := []any{"method", req.Method, "url", req.URL.Redacted(), "header", redactHeader(req.Header)}
+		if req.GetBody != nil && req.ContentLength != 0 {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(io.LimitReader(body, maxTraceBody))
+				body.Close()
+				attrs = append(attrs, "body", traceBody(req.Header.Get("Content-Type"), data, req.ContentLength))
+			}
+		}
+		t.logger.Log(ctx, LevelTrace, "http request", attrs...)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.logger.DebugContext(ctx, "http", "method", req.Method, "url", req.URL.Redacted(), "elapsed", time.Since(start), "err", err)
+		return nil, err
+	}
+	t.logger.DebugContext(ctx, "http", "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "elapsed", time.Since(start))
+	if trace {
+		// The body is logged once it has been read, so long-lived
+		// streams such as the alert stream are not held up.
+		resp.Body = &tracedBody{
+			ReadCloser: resp.Body,
+			limit:      maxTraceBody,
+			log: func(data []byte, n int64) {
+				t.logger.Log(ctx, LevelTrace, "http response",
+					"method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode,
+					"header", redactHeader(resp.Header), "body", traceBody(resp.Header.Get("Content-Type"), data, n))
+			},
+		}
+	}
+	return resp, nil
+}
+
+// tracedBody keeps the first limit bytes of a response body and logs them at
+// EOF or Close, whichever comes first.
+type tracedBody struct {
+	io.ReadCloser
+	limit int
+	log   func(data []byte, n int64)
+
+	once sync.Once
+	buf  bytes.Buffer
+	n    int64
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	b.n += int64(n)
+	if err == io.EOF {
+		b.flush()
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	b.flush()
+	return b.ReadCloser.Close()
+}
+
+func (b *tracedBody) flush() {
+	b.once.Do(func() { b.log(b.buf.Bytes(), b.n) })
+}
+
+// traceBody returns the loggable form of a body of n bytes starting with data:
+// text with passwords and keys redacted, or only the size of binary content.
+func traceBody(contentType string, data []byte, n int64) string {
+	if n < 0 {
+		n = int64(len(data))
+	}
+	if !isText(contentType) {
+		return "[" + strconv.FormatInt(n, 10) + " bytes " + contentType + "]"
+	}
+	s := redactBody(string(data))
+	if n > int64(len(data)) {
+		s += "… [" + strconv.FormatInt(n, 10) + " bytes]"
+	}
+	return s
+}
+
+// isText reports whether a Content-Type is XML, JSON or other text, or a
+// multipart stream of them such as the alert stream.
+func isText(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return ct == "" || strings.Contains(ct, "xml") || strings.Contains(ct, "json") ||
+		strings.HasPrefix(ct, "text/") || strings.HasPrefix(ct, "multipart/")
+}
+
+// redactedHeaders carry credentials.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactHeader returns a copy of h with credentials replaced, keeping the
+// auth scheme so that digest and basic can still be told apart.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redactedHeaders {
+		for i, v := range h[name] {
+			scheme, _, found := strings.Cut(v, " ")
+			if found && (name == "Authorization" || name == "Proxy-Authorization") {
+				h[name][i] = scheme + " REDACTED"
+			} else {
+				h[name][i] = "REDACTED"
+			}
+		}
+	}
+	return h
+}
+
+var (
+	// xmlSecret matches the start of elements such as <password>,
+	// <loginPassword>, <wsse:Password Type="...">, the Wi-Fi <sharedKey>,
+	// the SNMP <readCommunity> and <privacyKey>, and their text.
+	xmlSecret = regexp.MustCompile(`(?i)(<(?:[\w.-]+:)?[\w.-]*(?:password|key|community)(?:\s[^>]*)?>)[^<]*`)
+	// jsonSecret matches JSON members such as "password": "...",
+	// "sharedKey": "..." or "writeCommunity": "...".
+	jsonSecret = regexp.MustCompile(`(?i)("[\w.-]*(?:password|key|community)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// redactBody replaces the passwords, keys and SNMP communities in an XML or
+// JSON body.
+func redactBody(s string) string {
+	s = xmlSecret.ReplaceAllString(s, "${1}REDACTED")
+	return jsonSecret.ReplaceAllString(s, `${1}"REDACTED"`)
+}
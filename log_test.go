@@ -0,0 +1,62 @@
+package hikvision
+
+import "testing"
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "xml password",
+			in:   `<User><userName>admin</userName><password>hik12345</password></User>`,
+			want: `<User><userName>admin</userName><password>REDACTED</password></User>`,
+		},
+		{
+			name: "wsse password",
+			in:   `<wsse:Password Type="PasswordDigest">c2VjcmV0</wsse:Password>`,
+			want: `<wsse:Password Type="PasswordDigest">REDACTED</wsse:Password>`,
+		},
+		{
+			name: "json password",
+			in:   `{"userName": "admin", "loginPassword": "hik\"12345"}`,
+			want: `{"userName": "admin", "loginPassword": "REDACTED"}`,
+		},
+		{
+			name: "wifi shared key",
+			in:   `<WPA><algorithmType>AES</algorithmType><sharedKey>wifi-secret</sharedKey><wpaKeyLength>11</wpaKeyLength></WPA>`,
+			want: `<WPA><algorithmType>AES</algorithmType><sharedKey>REDACTED</sharedKey><wpaKeyLength>11</wpaKeyLength></WPA>`,
+		},
+		{
+			name: "json key",
+			in:   `{"ssid": "site", "key": "wifi-secret"}`,
+			want: `{"ssid": "site", "key": "REDACTED"}`,
+		},
+		{
+			name: "snmp v2c communities",
+			in:   `<SNMPv2c><readCommunity>public-ro</readCommunity><writeCommunity>private-rw</writeCommunity></SNMPv2c>`,
+			want: `<SNMPv2c><readCommunity>REDACTED</readCommunity><writeCommunity>REDACTED</writeCommunity></SNMPv2c>`,
+		},
+		{
+			name: "snmp v3 keys",
+			in:   `<SNMPUser><userName>ops</userName><authenticationKey>auth-secret</authenticationKey><privacyKey>priv-secret</privacyKey></SNMPUser>`,
+			want: `<SNMPUser><userName>ops</userName><authenticationKey>REDACTED</authenticationKey><privacyKey>REDACTED</privacyKey></SNMPUser>`,
+		},
+		{
+			name: "json community",
+			in:   `{"readCommunity": "public-ro"}`,
+			want: `{"readCommunity": "REDACTED"}`,
+		},
+		{
+			name: "no secrets",
+			in:   `<IrLightSwitch><mode>auto</mode></IrLightSwitch>`,
+			want: `<IrLightSwitch><mode>auto</mode></IrLightSwitch>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(tt.in); got != tt.want {
+				t.Errorf("redactBody(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
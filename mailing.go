@@ -0,0 +1,94 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// EmailConfig is the SMTP account the camera sends alarm emails with and
+// their recipients, as served by /ISAPI/System/Network/mailing. Which events
+// send email is set per event by its linkage ("send email").
+type EmailConfig struct {
+	XMLName xml.Name         `xml:"mailing"`
+	ID      int              `xml:"id"`
+	Sender  EmailSender      `xml:"sender"`
+	To      []EmailRecipient `xml:"receiverList>receiver"`
+	Extra   []rawElement     `xml:",any"`
+}
+
+// EmailSender is the From address of alarm emails and the SMTP server they
+// are sent through.
+type EmailSender struct {
+	Address string       `xml:"emailAddress"`
+	Name    string       `xml:"name,omitempty"`
+	SMTP    SMTPServer   `xml:"smtp"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// SMTPServer is the mail server of an EmailSender. With Auth set the camera
+// logs in as User; SSL connects over TLS from the start and TLS upgrades
+// the connection with STARTTLS.
+type SMTPServer struct {
+	Auth bool `xml:"enableAuthorization"`
+	SSL  bool `xml:"enableSSL"`
+	TLS  bool `xml:"enableTLS,omitempty"`
+	// AddressingFormat is "hostname" or "ipaddress" and selects which of
+	// HostName and IPAddress is used.
+	AddressingFormat string       `xml:"addressingFormatType"`
+	HostName         string       `xml:"hostName,omitempty"`
+	IPAddress        string       `xml:"ipAddress,omitempty"`
+	Port             int          `xml:"portNo"`
+	User             string       `xml:"accountName,omitempty"`
+	Password         string       `xml:"password,omitempty"`
+	Extra            []rawElement `xml:",any"`
+}
+
+// Address returns the configured host name or IP address.
+func (s *SMTPServer) Address() string {
+	if s.AddressingFormat == "ipaddress" {
+		return s.IPAddress
+	}
+	return s.HostName
+}
+
+// EmailRecipient is one recipient of alarm emails. Cameras have a fixed
+// number of recipients, unset ones with an empty Address.
+type EmailRecipient struct {
+	ID      int          `xml:"id"`
+	Name    string       `xml:"name"`
+	Address string       `xml:"emailAddress"`
+	Extra   []rawElement `xml:",any"`
+}
+
+type mailingList struct {
+	XMLName  xml.Name      `xml:"mailingList"`
+	Mailings []EmailConfig `xml:"mailing"`
+}
+
+// GetEmailConfig returns the email settings of the camera.
+// Calls GET /ISAPI/System/Network/mailing.
+func (c *Camera) GetEmailConfig(ctx context.Context) (*EmailConfig, error) {
+	var list mailingList
+	if err := c.getXML(ctx, "/ISAPI/System/Network/mailing", &list); err != nil {
+		return nil, err
+	}
+	if len(list.Mailings) == 0 {
+		return nil, fmt.Errorf("camera has no email settings")
+	}
+	return &list.Mailings[0], nil
+}
+
+// SetEmailConfig replaces the email settings of the camera.
+// Calls PUT /ISAPI/System/Network/mailing.
+func (c *Camera) SetEmailConfig(ctx context.Context, e *EmailConfig) error {
+	return c.putXML(ctx, "/ISAPI/System/Network/mailing", mailingList{Mailings: []EmailConfig{*e}})
+}
+
+// TestEmail makes the camera send a test email with settings e to its
+// recipients, and fails if the server refused it. e need not be saved
+// first.
+// Calls POST /ISAPI/System/Network/mailing/test.
+func (c *Camera) TestEmail(ctx context.Context, e *EmailConfig) error {
+	return c.postXML(ctx, "/ISAPI/System/Network/mailing/test", e)
+}
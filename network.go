@@ -0,0 +1,366 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Addressing types for IPAddress.AddressingType.
+const (
+	AddressingStatic = "static"
+	AddressingDHCP   = "dynamic"
+)
+
+// NetworkInterface is the configuration of one network interface, as served
+// by /ISAPI/System/Network/interfaces/<id>.
+type NetworkInterface struct {
+	XMLName   xml.Name      `xml:"NetworkInterface"`
+	ID        int           `xml:"id"`
+	IPAddress IPAddress     `xml:"IPAddress"`
+	Link      InterfaceLink `xml:"Link"`
+	Extra     []rawElement  `xml:",any"`
+}
+
+// IPAddress is the IPv4 addressing of a network interface. Address,
+// SubnetMask, Gateway and the DNS servers are ignored by the camera while
+// AddressingType is AddressingDHCP.
+type IPAddress struct {
+	Version        string `xml:"ipVersion"`
+	AddressingType string `xml:"addressingType"`
+	Address        string `xml:"ipAddress,omitempty"`
+	SubnetMask     string `xml:"subnetMask,omitempty"`
+	// IPv6 gateway and DNS addresses are not kept by a get-modify-set cycle.
+	Gateway      string       `xml:"DefaultGateway>ipAddress,omitempty"`
+	PrimaryDNS   string       `xml:"PrimaryDNS>ipAddress,omitempty"`
+	SecondaryDNS string       `xml:"SecondaryDNS>ipAddress,omitempty"`
+	Extra        []rawElement `xml:",any"`
+}
+
+// InterfaceLink is the link layer of a network interface.
+type InterfaceLink struct {
+	MACAddress string       `xml:"MACAddress,omitempty"`
+	Extra      []rawElement `xml:",any"`
+}
+
+type networkInterfaceList struct {
+	XMLName    xml.Name           `xml:"NetworkInterfaceList"`
+	Interfaces []NetworkInterface `xml:"NetworkInterface"`
+}
+
+// GetNetworkInterfaces returns the configuration of every network interface.
+func (c *Camera) GetNetworkInterfaces(ctx context.Context) ([]NetworkInterface, error) {
+	var list networkInterfaceList
+	if err := c.getXML(ctx, "/ISAPI/System/Network/interfaces", &list); err != nil {
+		return nil, err
+	}
+	return list.Interfaces, nil
+}
+
+// SetNetworkInterface replaces the configuration of network interface n.ID.
+// A new address takes effect as soon as the camera has answered, so further
+// requests must go to the new address; some models reboot first.
+func (c *Camera) SetNetworkInterface(ctx context.Context, n *NetworkInterface) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/Network/interfaces/%d", n.ID), n)
+}
+
+// Service protocols for ServicePort.Protocol.
+const (
+	ProtocolHTTP  = "HTTP"
+	ProtocolHTTPS = "HTTPS"
+	ProtocolRTSP  = "RTSP"
+	// ProtocolSDK is the proprietary SDK/iVMS service, port 8000 by default.
+	ProtocolSDK = "DEV_MANAGE"
+)
+
+// ServicePort is one network service the camera listens on, as served by
+// /ISAPI/Security/adminAccesses.
+type ServicePort struct {
+	XMLName  xml.Name     `xml:"AdminAccessProtocol"`
+	ID       int          `xml:"id"`
+	Enabled  bool         `xml:"enabled"`
+	Protocol string       `xml:"protocol"`
+	Port     int          `xml:"portNo"`
+	Extra    []rawElement `xml:",any"`
+}
+
+type servicePortList struct {
+	XMLName xml.Name      `xml:"AdminAccessProtocolList"`
+	Ports   []ServicePort `xml:"AdminAccessProtocol"`
+}
+
+// GetServicePorts returns the ports of the camera's HTTP, HTTPS, RTSP and SDK
+// services.
+func (c *Camera) GetServicePorts(ctx context.Context) ([]ServicePort, error) {
+	var list servicePortList
+	if err := c.getXML(ctx, "/ISAPI/Security/adminAccesses", &list); err != nil {
+		return nil, err
+	}
+	return list.Ports, nil
+}
+
+// SetServicePorts replaces the service port list. Pass the list from
+// GetServicePorts with changes applied: services left out are not kept. The
+// camera restarts the changed services, so a new HTTP port applies to the
+// next request.
+func (c *Camera) SetServicePorts(ctx context.Context, ports []ServicePort) error {
+	return c.putXML(ctx, "/ISAPI/Security/adminAccesses", servicePortList{Ports: ports})
+}
+
+// UPnP is the camera's UPnP announcement and port forwarding setting.
+type UPnP struct {
+	XMLName xml.Name `xml:"UPnP"`
+	Enabled bool     `xml:"enabled"`
+	// Name is the friendly name the camera announces.
+	Name  string       `xml:"name,omitempty"`
+	Extra []rawElement `xml:",any"`
+}
+
+// GetUPnP returns the UPnP setting.
+func (c *Camera) GetUPnP(ctx context.Context) (*UPnP, error) {
+	var u UPnP
+	if err := c.getXML(ctx, "/ISAPI/System/Network/UPnP", &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// SetUPnP replaces the UPnP setting. Disabling UPnP stops the camera opening
+// ports on the router by itself.
+func (c *Camera) SetUPnP(ctx context.Context, u *UPnP) error {
+	return c.putXML(ctx, "/ISAPI/System/Network/UPnP", u)
+}
+
+// IP filter permissions for IPFilter.Permission.
+const (
+	FilterAllow = "allow"
+	FilterDeny  = "deny"
+)
+
+// IP filter address types for IPFilterAddress.Type.
+const (
+	IPFilterSingle = "mask"
+	IPFilterRange  = "range"
+)
+
+// IPFilter is the address access list of a network interface, as served by
+// /ISAPI/System/Network/interfaces/<id>/ipFilter. With Permission FilterAllow
+// only the listed addresses may connect; with FilterDeny they may not.
+type IPFilter struct {
+	XMLName    xml.Name          `xml:"IPFilter"`
+	Enabled    bool              `xml:"enabled"`
+	Permission string            `xml:"permissionType"`
+	Addresses  []IPFilterAddress `xml:"IPFilterAddressList>IPFilterAddress"`
+	Extra      []rawElement      `xml:",any"`
+}
+
+// IPFilterAddress is one entry of an IPFilter: a single address when Type is
+// IPFilterSingle, or the range Start-End when it is IPFilterRange.
+type IPFilterAddress struct {
+	ID int `xml:"id"`
+	// Permission repeats IPFilter.Permission on firmwares that store it per
+	// entry.
+	Permission string       `xml:"permissionType,omitempty"`
+	Type       string       `xml:"addressFilterType"`
+	Address    string       `xml:"Mask>ipAddress,omitempty"`
+	Start      string       `xml:"AddressRange>startIPAddress>ipAddress,omitempty"`
+	End        string       `xml:"AddressRange>endIPAddress>ipAddress,omitempty"`
+	Extra      []rawElement `xml:",any"`
+}
+
+func ipFilterPath(iface int) string {
+	return fmt.Sprintf("/ISAPI/System/Network/interfaces/%d/ipFilter", iface)
+}
+
+// GetIPFilter returns the IP filter of a network interface.
+func (c *Camera) GetIPFilter(ctx context.Context, iface int) (*IPFilter, error) {
+	var f IPFilter
+	if err := c.getXML(ctx, ipFilterPath(iface), &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetIPFilter replaces the IP filter of a network interface. Enabling an
+// allow list that does not include the caller's address locks it out.
+func (c *Camera) SetIPFilter(ctx context.Context, iface int, f *IPFilter) error {
+	return c.putXML(ctx, ipFilterPath(iface), f)
+}
+
+// MarshalXML writes only the Mask or AddressRange element that a.Type uses,
+// rather than an empty one for the other.
+func (a IPFilterAddress) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type ip struct {
+		Address string `xml:"ipAddress"`
+	}
+	type addressRange struct {
+		Start ip `xml:"startIPAddress"`
+		End   ip `xml:"endIPAddress"`
+	}
+	out := struct {
+		ID         int           `xml:"id"`
+		Permission string        `xml:"permissionType,omitempty"`
+		Type       string        `xml:"addressFilterType"`
+		Mask       *ip           `xml:"Mask"`
+		Range      *addressRange `xml:"AddressRange"`
+		Extra      []rawElement  `xml:",any"`
+	}{ID: a.ID, Permission: a.Permission, Type: a.Type, Extra: a.Extra}
+	if a.Type == IPFilterRange {
+		out.Range = &addressRange{ip{a.Start}, ip{a.End}}
+	} else {
+		out.Mask = &ip{a.Address}
+	}
+	return e.EncodeElement(out, start)
+}
+
+// DDNS providers for DDNS.Provider.
+const (
+	DDNSDynDNS   = "DynDNS"
+	DDNSNoIP     = "NO-IP"
+	DDNSHiDDNS   = "HiDDNS"
+	DDNSIPServer = "IPServer"
+)
+
+// DDNS is a dynamic DNS registration of the camera, as served by
+// /ISAPI/System/Network/DDNS/<id>. Server is the provider's update server,
+// which HiDDNS does not need.
+type DDNS struct {
+	XMLName  xml.Name `xml:"DDNS"`
+	ID       int      `xml:"id"`
+	Enabled  bool     `xml:"enabled"`
+	Provider string   `xml:"provider"`
+	// ServerFormat is "hostname" or "ipaddress".
+	ServerFormat string       `xml:"serverAddress>addressingFormatType,omitempty"`
+	Server       string       `xml:"serverAddress>hostName,omitempty"`
+	Port         int          `xml:"portNo,omitempty"`
+	Domain       string       `xml:"deviceDomainName,omitempty"`
+	User         string       `xml:"userName,omitempty"`
+	Password     string       `xml:"password,omitempty"`
+	Extra        []rawElement `xml:",any"`
+}
+
+type ddnsList struct {
+	XMLName xml.Name `xml:"DDNSList"`
+	DDNS    []DDNS   `xml:"DDNS"`
+}
+
+// GetDDNS returns the dynamic DNS registrations of the camera, usually one.
+// Calls GET /ISAPI/System/Network/DDNS.
+func (c *Camera) GetDDNS(ctx context.Context) ([]DDNS, error) {
+	var list ddnsList
+	if err := c.getXML(ctx, "/ISAPI/System/Network/DDNS", &list); err != nil {
+		return nil, err
+	}
+	return list.DDNS, nil
+}
+
+// SetDDNS replaces dynamic DNS registration d.ID.
+// Calls PUT /ISAPI/System/Network/DDNS/<id>.
+func (c *Camera) SetDDNS(ctx context.Context, d *DDNS) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/Network/DDNS/%d", d.ID), d)
+}
+
+// SNMPv2c is the community-based SNMP agent of the camera, as served by
+// /ISAPI/System/Network/SNMP/V2c. Traps go to TrapAddress, if set.
+type SNMPv2c struct {
+	XMLName        xml.Name     `xml:"SNMPv2c"`
+	Enabled        bool         `xml:"enabled"`
+	ReadCommunity  string       `xml:"readCommunity"`
+	WriteCommunity string       `xml:"writeCommunity"`
+	TrapAddress    string       `xml:"trapAddress,omitempty"`
+	TrapPort       int          `xml:"trapPort,omitempty"`
+	Extra          []rawElement `xml:",any"`
+}
+
+// SNMP v3 authentication and privacy methods for SNMPv3.
+const (
+	SNMPAuthMD5    = "MD5"
+	SNMPAuthSHA    = "SHA"
+	SNMPPrivacyDES = "DES"
+	SNMPPrivacyAES = "AES"
+	SNMPMethodNone = "none"
+)
+
+// SNMPv3 is the user-based SNMP agent of the camera, as served by
+// /ISAPI/System/Network/SNMP/V3: User authenticates with AuthKey using
+// AuthMethod and encrypts with PrivacyKey using PrivacyMethod, either
+// SNMPMethodNone to go without. The keys are not returned.
+type SNMPv3 struct {
+	XMLName       xml.Name     `xml:"SNMPv3"`
+	Enabled       bool         `xml:"enabled"`
+	User          string       `xml:"userName"`
+	AuthMethod    string       `xml:"authenticationMethod"`
+	AuthKey       string       `xml:"authenticationKey,omitempty"`
+	PrivacyMethod string       `xml:"privacyMethod"`
+	PrivacyKey    string       `xml:"privacyKey,omitempty"`
+	Extra         []rawElement `xml:",any"`
+}
+
+// GetSNMPv2c returns the SNMP v2c agent settings.
+func (c *Camera) GetSNMPv2c(ctx context.Context) (*SNMPv2c, error) {
+	var s SNMPv2c
+	if err := c.getXML(ctx, "/ISAPI/System/Network/SNMP/V2c", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetSNMPv2c replaces the SNMP v2c agent settings.
+func (c *Camera) SetSNMPv2c(ctx context.Context, s *SNMPv2c) error {
+	return c.putXML(ctx, "/ISAPI/System/Network/SNMP/V2c", s)
+}
+
+// GetSNMPv3 returns the SNMP v3 agent settings.
+func (c *Camera) GetSNMPv3(ctx context.Context) (*SNMPv3, error) {
+	var s SNMPv3
+	if err := c.getXML(ctx, "/ISAPI/System/Network/SNMP/V3", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetSNMPv3 replaces the SNMP v3 agent settings.
+func (c *Camera) SetSNMPv3(ctx context.Context, s *SNMPv3) error {
+	return c.putXML(ctx, "/ISAPI/System/Network/SNMP/V3", s)
+}
+
+// 802.1X EAP methods for IEEE8021X.Protocol.
+const (
+	EAPMD5  = "EAP-MD5"
+	EAPTLS  = "EAP-TLS"
+	EAPPEAP = "EAP-PEAP"
+)
+
+// IEEE8021X is the port-based network access control of a network
+// interface, as served by /ISAPI/System/Network/interfaces/<id>/ieee802.1x.
+// EAP-TLS uses the client certificate installed on the camera instead of
+// User and Password.
+type IEEE8021X struct {
+	XMLName  xml.Name `xml:"IEEE802_1x"`
+	Enabled  bool     `xml:"enabled"`
+	Protocol string   `xml:"protocol"`
+	// EAPOLVersion is 1 or 2.
+	EAPOLVersion int          `xml:"EAPOLVersion"`
+	User         string       `xml:"userName,omitempty"`
+	Password     string       `xml:"password,omitempty"`
+	Extra        []rawElement `xml:",any"`
+}
+
+func ieee8021XPath(iface int) string {
+	return fmt.Sprintf("/ISAPI/System/Network/interfaces/%d/ieee802.1x", iface)
+}
+
+// GetIEEE8021X returns the 802.1X settings of a network interface.
+func (c *Camera) GetIEEE8021X(ctx context.Context, iface int) (*IEEE8021X, error) {
+	var x IEEE8021X
+	if err := c.getXML(ctx, ieee8021XPath(iface), &x); err != nil {
+		return nil, err
+	}
+	return &x, nil
+}
+
+// SetIEEE8021X replaces the 802.1X settings of a network interface. With
+// wrong credentials an 802.1X switch port shuts the camera out.
+func (c *Camera) SetIEEE8021X(ctx context.Context, iface int, x *IEEE8021X) error {
+	return c.putXML(ctx, ieee8021XPath(iface), x)
+}
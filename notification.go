@@ -0,0 +1,79 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// Notification body formats for HTTPHost.ParameterFormat.
+const (
+	NotificationXML  = "XML"
+	NotificationJSON = "JSON"
+)
+
+// HTTPHost is an alarm receiver the camera pushes event notifications to
+// over HTTP, as served by /ISAPI/Event/notification/httpHosts/<id>. Which
+// events are pushed is set per event by its linkage ("notify surveillance
+// center").
+type HTTPHost struct {
+	XMLName xml.Name `xml:"HttpHostNotification"`
+	ID      int      `xml:"id"`
+	// URL is the path the notifications are posted to, such as /alarm.
+	URL string `xml:"url"`
+	// Protocol is "HTTP" or "HTTPS".
+	Protocol string `xml:"protocolType"`
+	// ParameterFormat is NotificationXML or NotificationJSON.
+	ParameterFormat string `xml:"parameterFormatType,omitempty"`
+	// AddressingFormat is "hostname" or "ipaddress" and selects which of
+	// HostName and IPAddress is used.
+	AddressingFormat string `xml:"addressingFormatType"`
+	HostName         string `xml:"hostName,omitempty"`
+	IPAddress        string `xml:"ipAddress,omitempty"`
+	Port             int    `xml:"portNo"`
+	// Auth is the receiver's authentication: "none", "MD5digest" or
+	// "base64", with Username and Password.
+	Auth     string       `xml:"httpAuthenticationMethod,omitempty"`
+	Username string       `xml:"userName,omitempty"`
+	Password string       `xml:"password,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// Address returns the configured host name or IP address.
+func (h *HTTPHost) Address() string {
+	if h.AddressingFormat == "ipaddress" {
+		return h.IPAddress
+	}
+	return h.HostName
+}
+
+type httpHostList struct {
+	XMLName xml.Name   `xml:"HttpHostNotificationList"`
+	Hosts   []HTTPHost `xml:"HttpHostNotification"`
+}
+
+func httpHostPath(id int) string {
+	return fmt.Sprintf("/ISAPI/Event/notification/httpHosts/%d", id)
+}
+
+// GetHTTPHosts returns the alarm receivers of the camera. Most models have a
+// fixed list of one to three, unset ones with an empty address.
+func (c *Camera) GetHTTPHosts(ctx context.Context) ([]HTTPHost, error) {
+	var list httpHostList
+	if err := c.getXML(ctx, "/ISAPI/Event/notification/httpHosts", &list); err != nil {
+		return nil, err
+	}
+	return list.Hosts, nil
+}
+
+// SetHTTPHost replaces alarm receiver h.ID.
+func (c *Camera) SetHTTPHost(ctx context.Context, h *HTTPHost) error {
+	return c.putXML(ctx, httpHostPath(h.ID), h)
+}
+
+// TestHTTPHost makes the camera send a test notification to alarm receiver
+// id, and fails if the receiver could not be reached.
+func (c *Camera) TestHTTPHost(ctx context.Context, id int) error {
+	return c.command(ctx, http.MethodPost, httpHostPath(id)+"/test", nil)
+}
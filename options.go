@@ -0,0 +1,205 @@
+package hikvision
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// An Option configures a Camera created by NewCamera.
+type Option func(*options)
+
+type options struct {
+	username, password string
+	client             *http.Client
+	transport          http.RoundTripper
+	proxy              *url.URL
+	tls                *TLSOptions
+	scheme             string
+	port               int
+	timeout            time.Duration
+	channel            int
+	format             Format
+	userAgent          string
+	auth               AuthMode
+	logger             *slog.Logger
+	tracerProvider     trace.TracerProvider
+	meterProvider      metric.MeterProvider
+	dump               DumpFunc
+}
+
+// WithCredentials sets the username and password the camera is logged in with.
+func WithCredentials(username, password string) Option {
+	return func(o *options) { o.username, o.password = username, password }
+}
+
+// WithHTTPClient sends requests through client. Digest auth is layered on top
+// of its Transport (http.DefaultTransport if nil); its other settings, such as
+// Timeout, Jar or CheckRedirect, are kept. The client itself is not modified.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.client = client }
+}
+
+// WithTransport carries requests over rt, underneath digest auth, for example
+// a Transport that dials through a jump host. It replaces the Transport of a
+// WithHTTPClient client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) { o.transport = rt }
+}
+
+// WithProxy sends requests through an http, https or socks5 proxy URL, such
+// as socks5://127.0.0.1:1080 from "ssh -D 1080 jumphost". Without it the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply, as for
+// http.DefaultTransport. Like WithTLS, it only applies to an *http.Transport.
+func WithProxy(proxy *url.URL) Option {
+	return func(o *options) { o.proxy = proxy }
+}
+
+// WithTLS selects HTTPS and verifies the camera certificate according to
+// opts. It applies to the Transport of a WithHTTPClient client only if that
+// is an *http.Transport, which is cloned rather than changed.
+func WithTLS(opts TLSOptions) Option {
+	return func(o *options) { o.tls = &opts }
+}
+
+// WithTimeout sets Camera.Timeout, the bound on each HTTP attempt.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithScheme sets Camera.Scheme, "http" or "https".
+func WithScheme(scheme string) Option {
+	return func(o *options) { o.scheme = scheme }
+}
+
+// WithPort connects to port instead of the scheme's default, replacing any
+// port already given in the host.
+func WithPort(port int) Option {
+	return func(o *options) { o.port = port }
+}
+
+// WithChannel sets Camera.Channel, the video channel IR operations act on.
+func WithChannel(channel int) Option {
+	return func(o *options) { o.channel = channel }
+}
+
+// WithFormat sets Camera.Format, the encoding of request and response
+// bodies on endpoints that offer both XML and JSON.
+func WithFormat(f Format) Option {
+	return func(o *options) { o.format = f }
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(o *options) { o.userAgent = ua }
+}
+
+// WithAuth selects how the camera is authenticated. The default, AuthAuto,
+// uses digest auth, or basic auth if that is all the camera offers.
+func WithAuth(mode AuthMode) Option {
+	return func(o *options) { o.auth = mode }
+}
+
+// WithLogger logs every HTTP exchange to logger: the request line, status and
+// duration at slog.LevelDebug, and the headers and bodies at LevelTrace, with
+// credentials and passwords redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithTracerProvider creates the OpenTelemetry span of every ISAPI call with
+// tp instead of the global provider. Spans are named after the method and the
+// endpoint, such as "GET /ISAPI/Image/channels/{id}/ircutFilter", and cover
+// retries; their attributes give the path, status code and attempt count.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) { o.tracerProvider = tp }
+}
+
+// WithMeterProvider records the hikvision.client.request.duration histogram
+// of ISAPI calls with mp instead of the global provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) { o.meterProvider = mp }
+}
+
+// WithDump writes the full request and response of every HTTP exchange,
+// authentication challenges included, to the writer dump opens for it once
+// the response body has been read. XML and JSON bodies are indented;
+// credentials and passwords are redacted. It is meant for reporting firmware
+// quirks, see DumpWriter.
+func WithDump(dump DumpFunc) Option {
+	return func(o *options) { o.dump = dump }
+}
+
+// NewCamera creates a Camera for host, an address or host:port, with an HTTP
+// client that authenticates as WithAuth selects:
+//
+//	cam := hikvision.NewCamera("192.168.1.4",
+//		hikvision.WithCredentials("admin", "secret"),
+//		hikvision.WithTimeout(5*time.Second))
+func NewCamera(host string, opts ...Option) *Camera {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	client := new(http.Client)
+	if o.client != nil {
+		*client = *o.client
+	}
+	base := client.Transport
+	if o.transport != nil {
+		base = o.transport
+	}
+	if o.tls != nil || o.proxy != nil {
+		t, ok := base.(*http.Transport)
+		if base == nil {
+			t, ok = http.DefaultTransport.(*http.Transport), true
+		}
+		if ok {
+			t = t.Clone()
+			if o.tls != nil {
+				t.TLSClientConfig = o.tls.config()
+			}
+			if o.proxy != nil {
+				t.Proxy = http.ProxyURL(o.proxy)
+			}
+			base = t
+		}
+	}
+	scheme := o.scheme
+	if scheme == "" && o.tls != nil {
+		scheme = "https"
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if o.dump != nil {
+		base = &dumpTransport{base: base, open: o.dump}
+	}
+	if o.logger != nil {
+		base = &logTransport{base: base, logger: o.logger}
+	}
+	client.Transport = newAuthTransport(o.auth, o.username, o.password, base)
+
+	if o.port > 0 {
+		host = JoinHost(hostname(host), o.port)
+	}
+	return &Camera{
+		Host:      host,
+		Username:  o.username,
+		Password:  o.password,
+		Scheme:    scheme,
+		Timeout:   o.timeout,
+		Channel:   o.channel,
+		Format:    o.format,
+		client:    client,
+		transport: base,
+		auth:      o.auth,
+		userAgent: o.userAgent,
+		telemetry: newTelemetry(o.tracerProvider, o.meterProvider),
+	}
+}
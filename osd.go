@@ -0,0 +1,93 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Date and time formats for DateTimeOverlay.
+const (
+	DateYMD = "YYYY-MM-DD"
+	DateDMY = "DD-MM-YYYY"
+	DateMDY = "MM-DD-YYYY"
+
+	Time24Hour = "24hour"
+	Time12Hour = "12hour"
+)
+
+// Overlays is the on-screen display of a video channel, as served by
+// /ISAPI/System/Video/inputs/channels/N/overlays. Positions are in the
+// camera's normalized 704x576 grid, measured from the bottom left. Each
+// overlay keeps the elements the client does not model, such as fontSize,
+// alignment and frontColor, so that setting a GetOverlays overlay back does
+// not reset its styling.
+type Overlays struct {
+	XMLName     xml.Name            `xml:"VideoOverlay"`
+	Text        []TextOverlay       `xml:"TextOverlayList>TextOverlay"`
+	DateTime    *DateTimeOverlay    `xml:"DateTimeOverlay"`
+	ChannelName *ChannelNameOverlay `xml:"channelNameOverlay"`
+	Extra       []rawElement        `xml:",any"`
+}
+
+// TextOverlay is one line of custom OSD text.
+type TextOverlay struct {
+	XMLName xml.Name     `xml:"TextOverlay"`
+	ID      int          `xml:"id"`
+	Enabled bool         `xml:"enabled"`
+	X       int          `xml:"positionX"`
+	Y       int          `xml:"positionY"`
+	Text    string       `xml:"displayText"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// DateTimeOverlay is the timestamp OSD.
+type DateTimeOverlay struct {
+	XMLName xml.Name `xml:"DateTimeOverlay"`
+	Enabled bool     `xml:"enabled"`
+	X       int      `xml:"positionX"`
+	Y       int      `xml:"positionY"`
+	// DateStyle is one of the Date* formats and TimeStyle Time24Hour or
+	// Time12Hour.
+	DateStyle   string       `xml:"dateStyle"`
+	TimeStyle   string       `xml:"timeStyle"`
+	DisplayWeek bool         `xml:"displayWeek"`
+	Extra       []rawElement `xml:",any"`
+}
+
+// ChannelNameOverlay is the OSD showing the channel (camera) name.
+type ChannelNameOverlay struct {
+	XMLName xml.Name     `xml:"channelNameOverlay"`
+	Enabled bool         `xml:"enabled"`
+	X       int          `xml:"positionX"`
+	Y       int          `xml:"positionY"`
+	Extra   []rawElement `xml:",any"`
+}
+
+func overlaysPath(channel int) string {
+	return fmt.Sprintf("/ISAPI/System/Video/inputs/channels/%d/overlays", channel)
+}
+
+// GetOverlays returns the on-screen display configuration of a video channel.
+func (c *Camera) GetOverlays(ctx context.Context, channel int) (*Overlays, error) {
+	var o Overlays
+	if err := c.getXML(ctx, overlaysPath(channel), &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// SetTextOverlay replaces the custom text overlay with ID t.ID.
+func (c *Camera) SetTextOverlay(ctx context.Context, channel int, t *TextOverlay) error {
+	return c.putXML(ctx, fmt.Sprintf("%s/text/%d", overlaysPath(channel), t.ID), t)
+}
+
+// SetDateTimeOverlay replaces the timestamp overlay of a video channel.
+func (c *Camera) SetDateTimeOverlay(ctx context.Context, channel int, d *DateTimeOverlay) error {
+	return c.putXML(ctx, overlaysPath(channel)+"/dateTimeOverlay", d)
+}
+
+// SetChannelNameOverlay replaces the channel name overlay of a video channel.
+func (c *Camera) SetChannelNameOverlay(ctx context.Context, channel int, n *ChannelNameOverlay) error {
+	return c.putXML(ctx, overlaysPath(channel)+"/channelNameOverlay", n)
+}
@@ -0,0 +1,93 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// PTZPatrol is a tour of presets, as served by
+// /ISAPI/PTZCtrl/channels/<id>/patrols/<patrol>.
+type PTZPatrol struct {
+	XMLName xml.Name     `xml:"PTZPatrol"`
+	ID      int          `xml:"id"`
+	Name    string       `xml:"patrolName,omitempty"`
+	Steps   []PatrolStep `xml:"PatrolSequenceList>PatrolSequence"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// PatrolStep is one stop of a patrol: the dome goes to Preset at Speed, 1 to
+// 40, and stays there for Dwell seconds.
+type PatrolStep struct {
+	Preset int          `xml:"presetID"`
+	Speed  int          `xml:"speed"`
+	Dwell  int          `xml:"delay"`
+	Extra  []rawElement `xml:",any"`
+}
+
+type ptzPatrolList struct {
+	XMLName xml.Name    `xml:"PTZPatrolList"`
+	Patrols []PTZPatrol `xml:"PTZPatrol"`
+}
+
+// GetPTZPatrols returns the patrols of the PTZ of a channel, including the
+// empty ones most firmware list.
+func (c *Camera) GetPTZPatrols(ctx context.Context, channel int) ([]PTZPatrol, error) {
+	var list ptzPatrolList
+	if err := c.getXML(ctx, ptzPath(channel, "patrols"), &list); err != nil {
+		return nil, err
+	}
+	return list.Patrols, nil
+}
+
+// SetPTZPatrol replaces patrol p.ID of the PTZ of a channel.
+func (c *Camera) SetPTZPatrol(ctx context.Context, channel int, p *PTZPatrol) error {
+	return c.putXML(ctx, ptzPath(channel, fmt.Sprintf("patrols/%d", p.ID)), p)
+}
+
+// StartPTZPatrol starts a patrol of the PTZ of a channel, which runs until
+// StopPTZPatrol or another PTZ command. Calls PUT
+// /ISAPI/PTZCtrl/channels/<id>/patrols/<patrol>/start.
+func (c *Camera) StartPTZPatrol(ctx context.Context, channel, patrol int) error {
+	return c.putEmpty(ctx, ptzPath(channel, fmt.Sprintf("patrols/%d/start", patrol)))
+}
+
+// StopPTZPatrol stops a patrol of the PTZ of a channel.
+func (c *Camera) StopPTZPatrol(ctx context.Context, channel, patrol int) error {
+	return c.putEmpty(ctx, ptzPath(channel, fmt.Sprintf("patrols/%d/stop", patrol)))
+}
+
+// Park action types for ParkAction.Type. The misspelt autoscan is the
+// firmware's.
+const (
+	ParkPreset   = "preset"
+	ParkPatrol   = "patrol"
+	ParkPattern  = "pattern"
+	ParkAutoScan = "atuoscan"
+)
+
+// ParkAction is what a PTZ does once left idle: after Wait seconds without
+// a command it goes to preset Number, or runs patrol or pattern Number.
+type ParkAction struct {
+	XMLName xml.Name     `xml:"ParkAction"`
+	Enabled bool         `xml:"enabled"`
+	Wait    int          `xml:"Parktime"`
+	Type    string       `xml:"Action>ActionType"`
+	Number  int          `xml:"Action>ActionNum"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetParkAction returns the park action of the PTZ of a channel, as served
+// by /ISAPI/PTZCtrl/channels/<id>/parkaction.
+func (c *Camera) GetParkAction(ctx context.Context, channel int) (*ParkAction, error) {
+	var p ParkAction
+	if err := c.getXML(ctx, ptzPath(channel, "parkaction"), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetParkAction replaces the park action of the PTZ of a channel.
+func (c *Camera) SetParkAction(ctx context.Context, channel int, p *ParkAction) error {
+	return c.putXML(ctx, ptzPath(channel, "parkaction"), p)
+}
@@ -0,0 +1,107 @@
+package hikvision
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PSIAController is a Controller over the PSIA API that Hikvision firmware
+// before 5.x serves instead of ISAPI. Its IR follows the IR-cut filter under
+// /PSIA/Custom/SelfExt, which has no IR brightness; snapshots and events
+// come from the unprefixed paths that ISAPI later moved under /ISAPI.
+type PSIAController struct {
+	cam     *Camera
+	channel int
+}
+
+var _ Controller = (*PSIAController)(nil)
+
+// PSIA returns a PSIAController for the camera, whose IR methods act on
+// Camera.Channel.
+func (c *Camera) PSIA() *PSIAController {
+	return c.psia(c.irChannel())
+}
+
+func (c *Camera) psia(channel int) *PSIAController {
+	return &PSIAController{cam: c, channel: channel}
+}
+
+// legacyIRCutPath is the PSIA equivalent of the ircutFilter resource, which
+// takes the same IrcutFilter document.
+func legacyIRCutPath(channel int) string {
+	return fmt.Sprintf("/PSIA/Custom/SelfExt/Image/channels/%d/IrcutFilter", channel)
+}
+
+// GetIRModeContext returns the IR mode the day/night mode of the channel
+// amounts to.
+func (p *PSIAController) GetIRModeContext(ctx context.Context) (IRMode, error) {
+	f, err := p.GetIRCutFilter(ctx, p.channel)
+	if err != nil {
+		return "", err
+	}
+	return irForIRCut(f.Type), nil
+}
+
+// SetIRModeContext sets the day/night mode of the channel that turns its IR
+// to mode.
+func (p *PSIAController) SetIRModeContext(ctx context.Context, mode IRMode) error {
+	filter, err := irCutForIR(mode)
+	if err != nil {
+		return err
+	}
+	return p.SetIRCutFilterMode(ctx, p.channel, filter)
+}
+
+// GetIRBrightness reports no brightness: PSIA has no IR brightness control.
+func (p *PSIAController) GetIRBrightness(ctx context.Context) (brightness int, ok bool, err error) {
+	return 0, false, nil
+}
+
+// SetIRBrightness fails: PSIA has no IR brightness control.
+func (p *PSIAController) SetIRBrightness(ctx context.Context, brightness int) error {
+	return fmt.Errorf("IR brightness is not adjustable through %s", IREndpointLegacy)
+}
+
+// GetIRCutFilter returns the day/night configuration of a video channel.
+func (p *PSIAController) GetIRCutFilter(ctx context.Context, channel int) (*IRCutFilter, error) {
+	var f IRCutFilter
+	if err := p.cam.getXML(ctx, legacyIRCutPath(channel), &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetIRCutFilter replaces the day/night configuration of a video channel.
+func (p *PSIAController) SetIRCutFilter(ctx context.Context, channel int, f *IRCutFilter) error {
+	return p.cam.putXML(ctx, legacyIRCutPath(channel), f)
+}
+
+// SetIRCutFilterMode changes only the day/night mode of a video channel,
+// keeping the camera's other IR-cut settings.
+func (p *PSIAController) SetIRCutFilterMode(ctx context.Context, channel int, mode IRCutFilterMode) error {
+	f, err := p.GetIRCutFilter(ctx, channel)
+	if err != nil {
+		return err
+	}
+	f.Type = mode
+	return p.SetIRCutFilter(ctx, channel, f)
+}
+
+// Snapshot captures a JPEG still from a streaming ID such as 101.
+// Calls GET /Streaming/channels/<id>/picture. The caller must close the
+// returned reader.
+func (p *PSIAController) Snapshot(ctx context.Context, id int) (io.ReadCloser, error) {
+	resp, err := p.cam.do(ctx, http.MethodGet, fmt.Sprintf("/Streaming/channels/%d/picture", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// SubscribeEvents opens /Event/notification/alertStream; see
+// Camera.SubscribeEvents.
+func (p *PSIAController) SubscribeEvents(ctx context.Context) (*EventStream, error) {
+	return p.cam.subscribeEvents(ctx, "/Event/notification/alertStream")
+}
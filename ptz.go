@@ -0,0 +1,239 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// PTZMove is a continuous PTZ movement. Each speed is -100 to 100: negative
+// pans left, tilts down or zooms out, and 0 stops that axis.
+type PTZMove struct {
+	Pan, Tilt, Zoom int
+}
+
+// PTZPosition is where a PTZ camera points. Azimuth is degrees clockwise
+// from the camera's zero, 0 to 360; Elevation is degrees below the
+// horizon, negative above it; Zoom is the zoom factor, 1 being widest.
+type PTZPosition struct {
+	Azimuth, Elevation, Zoom float64
+}
+
+// PTZRelative shifts the view from where it is. X and Y move the centre by
+// a share of the picture, -100 to 100 with positive right and up; Zoom
+// zooms in or out likewise.
+type PTZRelative struct {
+	X, Y, Zoom int
+}
+
+// ptzData is the body of the PTZCtrl movement endpoints.
+type ptzData struct {
+	XMLName  xml.Name         `xml:"PTZData"`
+	Pan      *int             `xml:"pan,omitempty"`
+	Tilt     *int             `xml:"tilt,omitempty"`
+	Zoom     *int             `xml:"zoom,omitempty"`
+	Absolute *ptzAbsoluteHigh `xml:"AbsoluteHigh,omitempty"`
+	Relative *ptzRelative     `xml:"Relative,omitempty"`
+}
+
+// ptzAbsoluteHigh holds a position in tenths of degrees and of the zoom
+// factor.
+type ptzAbsoluteHigh struct {
+	Elevation int `xml:"elevation"`
+	Azimuth   int `xml:"azimuth"`
+	Zoom      int `xml:"absoluteZoom"`
+}
+
+type ptzRelative struct {
+	X    int `xml:"positionX"`
+	Y    int `xml:"positionY"`
+	Zoom int `xml:"relativeZoom"`
+}
+
+type ptzStatus struct {
+	XMLName  xml.Name        `xml:"PTZStatus"`
+	Absolute ptzAbsoluteHigh `xml:"AbsoluteHigh"`
+}
+
+func ptzPath(channel int, op string) string {
+	return fmt.Sprintf("/ISAPI/PTZCtrl/channels/%d/%s", channel, op)
+}
+
+// MovePTZ starts moving the PTZ of a channel at the speeds of m, until
+// another move or StopPTZ. Calls PUT /ISAPI/PTZCtrl/channels/<id>/continuous.
+func (c *Camera) MovePTZ(ctx context.Context, channel int, m PTZMove) error {
+	return c.putXML(ctx, ptzPath(channel, "continuous"), &ptzData{Pan: &m.Pan, Tilt: &m.Tilt, Zoom: &m.Zoom})
+}
+
+// StopPTZ stops every movement of the PTZ of a channel.
+func (c *Camera) StopPTZ(ctx context.Context, channel int) error {
+	return c.MovePTZ(ctx, channel, PTZMove{})
+}
+
+// MovePTZFor moves the PTZ of a channel at the speeds of m for d, then stops
+// it. The PTZ is stopped even if ctx is cancelled during the move.
+func (c *Camera) MovePTZFor(ctx context.Context, channel int, m PTZMove, d time.Duration) error {
+	if err := c.MovePTZ(ctx, channel, m); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		t.Stop()
+	case <-t.C:
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+	return c.StopPTZ(ctx, channel)
+}
+
+// GetPTZPosition returns where the PTZ of a channel points. Calls GET
+// /ISAPI/PTZCtrl/channels/<id>/status.
+func (c *Camera) GetPTZPosition(ctx context.Context, channel int) (PTZPosition, error) {
+	var s ptzStatus
+	if err := c.getXML(ctx, ptzPath(channel, "status"), &s); err != nil {
+		return PTZPosition{}, err
+	}
+	return PTZPosition{
+		Azimuth:   float64(s.Absolute.Azimuth) / 10,
+		Elevation: float64(s.Absolute.Elevation) / 10,
+		Zoom:      float64(s.Absolute.Zoom) / 10,
+	}, nil
+}
+
+// SetPTZPosition turns the PTZ of a channel to p. The camera answers at
+// once and moves meanwhile. Calls PUT /ISAPI/PTZCtrl/channels/<id>/absolute.
+func (c *Camera) SetPTZPosition(ctx context.Context, channel int, p PTZPosition) error {
+	abs := &ptzAbsoluteHigh{
+		Elevation: int(math.Round(p.Elevation * 10)),
+		Azimuth:   int(math.Round(p.Azimuth * 10)),
+		Zoom:      int(math.Round(p.Zoom * 10)),
+	}
+	return c.putXML(ctx, ptzPath(channel, "absolute"), &ptzData{Absolute: abs})
+}
+
+// MovePTZRelative shifts the view of the PTZ of a channel by r. Calls PUT
+// /ISAPI/PTZCtrl/channels/<id>/relative.
+func (c *Camera) MovePTZRelative(ctx context.Context, channel int, r PTZRelative) error {
+	return c.putXML(ctx, ptzPath(channel, "relative"), &ptzData{Relative: &ptzRelative{X: r.X, Y: r.Y, Zoom: r.Zoom}})
+}
+
+// PTZPreset is a stored PTZ position, as served by
+// /ISAPI/PTZCtrl/channels/<id>/presets.
+type PTZPreset struct {
+	XMLName xml.Name `xml:"PTZPreset"`
+	ID      int      `xml:"id"`
+	// Enabled reports whether a position is stored under the preset.
+	Enabled bool         `xml:"enabled"`
+	Name    string       `xml:"presetName"`
+	Extra   []rawElement `xml:",any"`
+}
+
+type ptzPresetList struct {
+	XMLName xml.Name    `xml:"PTZPresetList"`
+	Presets []PTZPreset `xml:"PTZPreset"`
+}
+
+// GetPTZPresets returns the presets of the PTZ of a channel. Some firmware
+// list every preset number, with Enabled false for those not stored.
+func (c *Camera) GetPTZPresets(ctx context.Context, channel int) ([]PTZPreset, error) {
+	var list ptzPresetList
+	if err := c.getXML(ctx, ptzPath(channel, "presets"), &list); err != nil {
+		return nil, err
+	}
+	return list.Presets, nil
+}
+
+// SetPTZPreset stores the current position of the PTZ of a channel as preset
+// p.ID, named p.Name. Calls PUT /ISAPI/PTZCtrl/channels/<id>/presets/<preset>.
+func (c *Camera) SetPTZPreset(ctx context.Context, channel int, p *PTZPreset) error {
+	p.Enabled = true
+	return c.putXML(ctx, ptzPath(channel, fmt.Sprintf("presets/%d", p.ID)), p)
+}
+
+// DeletePTZPreset deletes a preset of the PTZ of a channel.
+func (c *Camera) DeletePTZPreset(ctx context.Context, channel, preset int) error {
+	return c.deletePath(ctx, ptzPath(channel, fmt.Sprintf("presets/%d", preset)))
+}
+
+// GotoPTZPreset turns the PTZ of a channel to a preset. The camera answers
+// at once and moves meanwhile. Calls PUT
+// /ISAPI/PTZCtrl/channels/<id>/presets/<preset>/goto.
+func (c *Camera) GotoPTZPreset(ctx context.Context, channel, preset int) error {
+	return c.putEmpty(ctx, ptzPath(channel, fmt.Sprintf("presets/%d/goto", preset)))
+}
+
+// Auxiliary device types for PTZAux.Type.
+const (
+	AuxWiper  = "WIPER"
+	AuxLight  = "LIGHT"
+	AuxHeater = "HEATER"
+	AuxFan    = "FAN"
+)
+
+// PTZAux is an auxiliary device of a PTZ or its outdoor housing, such as the
+// wiper or heater, as served by /ISAPI/PTZCtrl/channels/<id>/auxcontrols.
+type PTZAux struct {
+	XMLName xml.Name `xml:"PTZAux"`
+	ID      int      `xml:"id"`
+	Type    string   `xml:"type"`
+	// Status is "on" or "off".
+	Status string       `xml:"status"`
+	Extra  []rawElement `xml:",any"`
+}
+
+// On reports whether the device is switched on.
+func (a PTZAux) On() bool {
+	return strings.EqualFold(a.Status, "on")
+}
+
+type ptzAuxList struct {
+	XMLName xml.Name `xml:"PTZAuxList"`
+	Aux     []PTZAux `xml:"PTZAux"`
+}
+
+// GetPTZAux returns the auxiliary devices of the PTZ of a channel.
+func (c *Camera) GetPTZAux(ctx context.Context, channel int) ([]PTZAux, error) {
+	var list ptzAuxList
+	if err := c.getXML(ctx, ptzPath(channel, "auxcontrols"), &list); err != nil {
+		return nil, err
+	}
+	return list.Aux, nil
+}
+
+// SetPTZAux switches auxiliary device a.ID of the PTZ of a channel to
+// a.Status. A wiper switched on wipes once on some models and until switched
+// off on others. Calls PUT
+// /ISAPI/PTZCtrl/channels/<id>/auxcontrols/<aux>.
+func (c *Camera) SetPTZAux(ctx context.Context, channel int, a *PTZAux) error {
+	return c.putXML(ctx, ptzPath(channel, fmt.Sprintf("auxcontrols/%d", a.ID)), a)
+}
+
+// SmartTracking is the auto-tracking of a smart PTZ, which follows a person
+// or vehicle once a smart event picks it up, as served by
+// /ISAPI/PTZCtrl/channels/<id>/smartTracking.
+type SmartTracking struct {
+	XMLName xml.Name     `xml:"SmartTracking"`
+	Enabled bool         `xml:"enabled"`
+	Extra   []rawElement `xml:",any"`
+}
+
+// GetSmartTracking returns the auto-tracking settings of the PTZ of a
+// channel.
+func (c *Camera) GetSmartTracking(ctx context.Context, channel int) (*SmartTracking, error) {
+	var s SmartTracking
+	if err := c.getXML(ctx, ptzPath(channel, "smartTracking"), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetSmartTracking replaces the auto-tracking settings of the PTZ of a
+// channel.
+func (c *Camera) SetSmartTracking(ctx context.Context, channel int, s *SmartTracking) error {
+	return c.putXML(ctx, ptzPath(channel, "smartTracking"), s)
+}
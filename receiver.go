@@ -0,0 +1,132 @@
+package hikvision
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// maxNotification caps the size of a pushed notification, pictures included.
+const maxNotification = 32 << 20
+
+// EventPicture is an image pushed with an event notification.
+type EventPicture struct {
+	// Name is the file name of its multipart part, such as
+	// "linedetectionPicture.jpg", if the camera gave one.
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// NewEventReceiver returns an http.Handler for the notifications cameras push
+// to an alarm host (see SetHTTPHost). Each notification is decoded, whether
+// XML or JSON and whether or not it is multipart with pictures, into events
+// passed to handle with the request they came in, and the camera is
+// answered 200 OK. handle may be called from several goroutines at once.
+func NewEventReceiver(handle func(r *http.Request, e Event)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		events, err := decodeNotification(r.Header.Get("Content-Type"), http.MaxBytesReader(w, r.Body, maxNotification))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, e := range events {
+			handle(r, e)
+		}
+	})
+}
+
+// decodeNotification decodes a pushed notification. In a multipart body,
+// pictures belong to the event before them or, if none, the first after.
+func decodeNotification(contentType string, r io.Reader) ([]Event, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("read notification: %w", err)
+		}
+		e, err := decodeEvent(data)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{e}, nil
+	}
+
+	var events []Event
+	var pending []EventPicture
+	mr := multipart.NewReader(r, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read notification: %w", err)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("read notification: %w", err)
+		}
+		ct := part.Header.Get("Content-Type")
+		if ct == "" || strings.Contains(ct, "xml") || strings.Contains(ct, "json") || strings.HasPrefix(ct, "text/") {
+			e, err := decodeEvent(data)
+			if err != nil {
+				return nil, err
+			}
+			e.Pictures = append(pending, e.Pictures...)
+			pending = nil
+			events = append(events, e)
+			continue
+		}
+		p := EventPicture{Name: part.FileName(), ContentType: ct, Data: data}
+		if len(events) == 0 {
+			pending = append(pending, p)
+		} else {
+			last := &events[len(events)-1]
+			last.Pictures = append(last.Pictures, p)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("notification holds no event")
+	}
+	return events, nil
+}
+
+// decodeEvent decodes one EventNotificationAlert, in XML or in JSON, where it
+// may or may not be wrapped in an object of that name. JSON fields of an
+// unexpected type, which vary between firmwares, are left unset.
+func decodeEvent(data []byte) (Event, error) {
+	var e Event
+	if !isJSON(data) {
+		if err := xml.Unmarshal(data, &e); err != nil {
+			return e, fmt.Errorf("decode event: %w", err)
+		}
+		return e, nil
+	}
+	var doc struct {
+		Alert json.RawMessage `json:"EventNotificationAlert"`
+	}
+	if json.Unmarshal(data, &doc) == nil && len(doc.Alert) > 0 {
+		data = doc.Alert
+	}
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(bytes.TrimSpace(data), &e); err != nil && !errors.As(err, &typeErr) {
+		return e, fmt.Errorf("decode event: %w", err)
+	}
+	if e.Type == "" {
+		return e, fmt.Errorf("decode event: no eventType")
+	}
+	return e, nil
+}
@@ -0,0 +1,158 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordingTrackID returns the ID of the track recording the main stream of a
+// video channel, e.g. 101 for channel 1.
+func RecordingTrackID(channel int) int {
+	return StreamingChannelID(channel, 1)
+}
+
+// RecordingSearch selects the recordings SearchRecordings returns.
+type RecordingSearch struct {
+	// TrackID is the recording track, see RecordingTrackID.
+	TrackID int
+	// From and To bound the recording time. Cameras take them as times of
+	// their own clock, so they are sent as wall-clock times in their
+	// location.
+	From, To time.Time
+	// PageSize is how many matches are asked for per request; 0 means 50.
+	PageSize int
+}
+
+// Recording is a recorded segment found by SearchRecordings.
+type Recording struct {
+	TrackID int
+	// Start and End are in the location of the search's From.
+	Start, End time.Time
+	// RecordType is why the segment was recorded, such as "timing" for
+	// continuous recording or "motion", when the camera says.
+	RecordType string
+	// PlaybackURI is the RTSP URI of the segment, which DownloadRecording
+	// takes.
+	PlaybackURI string
+	// Name and Size are the file name and size in bytes the camera gives in
+	// PlaybackURI, if any.
+	Name string
+	Size int64
+}
+
+// cmSearchDescription is the body of POST /ISAPI/ContentMgmt/search.
+type cmSearchDescription struct {
+	XMLName  xml.Name `xml:"CMSearchDescription"`
+	SearchID string   `xml:"searchID"`
+	TrackIDs []int    `xml:"trackList>trackID"`
+	Start    string   `xml:"timeSpanList>timeSpan>startTime"`
+	End      string   `xml:"timeSpanList>timeSpan>endTime"`
+	Max      int      `xml:"maxResults"`
+	// searchResultPostion is misspelt by the firmware.
+	Position   int    `xml:"searchResultPostion"`
+	Descriptor string `xml:"metadataList>metadataDescriptor"`
+}
+
+// cmSearchResult is the reply of POST /ISAPI/ContentMgmt/search.
+type cmSearchResult struct {
+	XMLName xml.Name `xml:"CMSearchResult"`
+	// Status is OK when the last match has been returned, MORE when
+	// further searches continue the list and NO MATCHES.
+	Status  string `xml:"responseStatusStrg"`
+	Matches []struct {
+		TrackID     int    `xml:"trackID"`
+		Start       string `xml:"timeSpan>startTime"`
+		End         string `xml:"timeSpan>endTime"`
+		PlaybackURI string `xml:"mediaSegmentDescriptor>playbackURI"`
+		Descriptor  string `xml:"metadataMatches>metadataDescriptor"`
+	} `xml:"matchList>searchMatchItem"`
+}
+
+// recordingTime is the layout of search times. Cameras mark them UTC but
+// read and write them in their own time zone.
+const recordingTime = "2006-01-02T15:04:05Z"
+
+// SearchRecordings lists the recorded segments of a track that overlap the
+// search times, oldest first, asking again while the camera has more.
+// Calls POST /ISAPI/ContentMgmt/search.
+func (c *Camera) SearchRecordings(ctx context.Context, s RecordingSearch) ([]Recording, error) {
+	page := s.PageSize
+	if page <= 0 {
+		page = 50
+	}
+	loc := s.From.Location()
+	req := cmSearchDescription{
+		SearchID:   strings.ToUpper(newUUID()),
+		TrackIDs:   []int{s.TrackID},
+		Start:      s.From.Format(recordingTime),
+		End:        s.To.Format(recordingTime),
+		Max:        page,
+		Descriptor: "//recordType.meta.std-cgi.com",
+	}
+	var recs []Recording
+	for {
+		body, err := xml.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("encode search: %w", err)
+		}
+		// The search only reads, so a dry run sends it.
+		resp, err := c.do(withRead(ctx), http.MethodPost, "/ISAPI/ContentMgmt/search", body)
+		if err != nil {
+			return nil, err
+		}
+		var res cmSearchResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode search result: %w", err)
+		}
+		for _, m := range res.Matches {
+			r := Recording{TrackID: m.TrackID, PlaybackURI: m.PlaybackURI}
+			r.Start, _ = time.ParseInLocation(recordingTime, m.Start, loc)
+			r.End, _ = time.ParseInLocation(recordingTime, m.End, loc)
+			if i := strings.LastIndex(m.Descriptor, "/"); i >= 0 {
+				r.RecordType = m.Descriptor[i+1:]
+			}
+			if u, err := url.Parse(m.PlaybackURI); err == nil {
+				q := u.Query()
+				r.Name = q.Get("name")
+				r.Size, _ = strconv.ParseInt(q.Get("size"), 10, 64)
+			}
+			recs = append(recs, r)
+		}
+		if !strings.EqualFold(res.Status, "MORE") || len(res.Matches) == 0 {
+			return recs, nil
+		}
+		req.Position += len(res.Matches)
+	}
+}
+
+// downloadRequest is the body of GET /ISAPI/ContentMgmt/download.
+type downloadRequest struct {
+	XMLName     xml.Name `xml:"downloadRequest"`
+	PlaybackURI string   `xml:"playbackURI"`
+}
+
+// DownloadRecording opens the file of a recorded segment, given its
+// PlaybackURI from SearchRecordings. size is the length of the download, or
+// -1 if the camera does not say. c.Timeout only bounds the wait for the
+// download to start. The caller must close the returned reader.
+// Calls GET /ISAPI/ContentMgmt/download.
+func (c *Camera) DownloadRecording(ctx context.Context, playbackURI string) (_ io.ReadCloser, size int64, err error) {
+	body, err := xml.Marshal(downloadRequest{PlaybackURI: playbackURI})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode download: %w", err)
+	}
+	resp, err := c.stream(ctx, http.MethodGet, "/ISAPI/ContentMgmt/download", body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
@@ -0,0 +1,347 @@
+package hikvision
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail with a network error or a 5xx
+// response are retried. The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles after
+	// every further attempt. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts. Defaults to 10s.
+	MaxDelay time.Duration
+}
+
+// RequestInfo describes one completed HTTP attempt, for Camera.OnRequest.
+type RequestInfo struct {
+	Method string
+	// Path is the ISAPI path as sent, after NVR proxy rewriting.
+	Path    string
+	Attempt int
+	// StatusCode is zero when the attempt failed without a response.
+	StatusCode int
+	// Duration covers the round trip up to the response headers.
+	Duration time.Duration
+	Err      error
+}
+
+// backoff returns a jittered delay to wait before attempt n+1 (n >= 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	d := base << (n - 1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	// Full jitter: spread retries from many clients across the whole window.
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// do sends an ISAPI request for a camera path, routed through the NVR proxy
+// endpoints when c.NVR is set. See send.
+func (c *Camera) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	return c.send(ctx, method, c.route(path), body)
+}
+
+// send sends an ISAPI request, retrying according to c.Retry, and returns the
+// response if the camera answered 200 OK. The caller must close the body.
+func (c *Camera) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	return c.sendRetry(ctx, method, path, body, c.Retry)
+}
+
+// sendRetry is send with an explicit retry policy.
+func (c *Camera) sendRetry(ctx context.Context, method, path string, body []byte, retry RetryPolicy) (_ *http.Response, err error) {
+	ctx, end := c.startCall(ctx, method, path)
+	var status, attempts int
+	defer func() { end(status, attempts, err) }()
+
+	url := c.url(path)
+	if resp := c.dryRun(ctx, method, url, body); resp != nil {
+		status, attempts = resp.StatusCode, 0
+		return resp, nil
+	}
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err := c.attempt(ctx, method, url, body)
+		attempts, status = attempt, 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		if c.OnRequest != nil {
+			info := RequestInfo{Method: method, Path: path, Attempt: attempt, Duration: time.Since(start), Err: err}
+			if resp != nil {
+				info.StatusCode = resp.StatusCode
+			}
+			c.OnRequest(info)
+		}
+		retryable := err != nil || resp.StatusCode >= 500
+		if !retryable || attempt >= retry.MaxAttempts || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, url, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				defer resp.Body.Close()
+				msg, _ := io.ReadAll(resp.Body)
+				return nil, newISAPIError(resp.StatusCode, msg)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		t := time.NewTimer(retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("%s %s: %w", method, url, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// attempt performs a single HTTP round trip, bounded by c.Timeout if set.
+func (c *Camera) attempt(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	cancel := func() {}
+	if c.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := c.newRequest(ctx, method, url, r)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType(body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// newRequest creates a request carrying the camera's User-Agent, if set.
+func (c *Camera) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err == nil && c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return req, err
+}
+
+// contentType guesses the Content-Type of a request body: ISAPI accepts XML
+// everywhere and JSON on some newer endpoints, while ONVIF wants SOAP.
+// Anything else, such as a firmware image, is sent as binary.
+func contentType(body []byte) string {
+	b := bytes.TrimSpace(body)
+	switch {
+	case len(b) > 0 && (b[0] == '{' || b[0] == '['):
+		return "application/json"
+	case len(b) > 0 && b[0] == '<' && bytes.Contains(b, []byte(soapNS)):
+		return "application/soap+xml; charset=utf-8"
+	case len(b) == 0 || b[0] == '<':
+		return "application/xml"
+	}
+	return "application/octet-stream"
+}
+
+// Do sends an arbitrary ISAPI request, such as GET /ISAPI/System/status, with
+// the camera's credentials, timeout and retry policy. It is an escape hatch
+// for endpoints the client does not wrap. body may be nil. The path is sent
+// exactly as given, without NVR proxy rewriting. A non-200 response is
+// returned as an *ISAPIError; otherwise the caller must close the response
+// body.
+func (c *Camera) Do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	return c.send(ctx, method, path, body)
+}
+
+// GetRaw GETs a camera ISAPI path and returns the response body. Unlike Do,
+// per-channel paths are routed through the NVR proxy endpoints when c.NVR is
+// set.
+func (c *Camera) GetRaw(ctx context.Context, path string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	return data, nil
+}
+
+// stream opens a long-lived request, such as the alert stream or a
+// recording download, whose body is read incrementally. body may be nil.
+// Unlike do, c.Timeout only bounds the wait for the response headers and the
+// request is not retried. The caller must close the body.
+func (c *Camera) stream(ctx context.Context, method, path string, body []byte) (_ *http.Response, err error) {
+	path = c.route(path)
+	// The span ends with the response headers; the stream itself may stay
+	// open for days.
+	ctx, end := c.startCall(ctx, method, path)
+	var status int
+	defer func() { end(status, 1, err) }()
+	ctx, cancel := context.WithCancel(ctx)
+	url := c.url(path)
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := c.newRequest(ctx, method, url, r)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType(body))
+	}
+
+	if c.Timeout > 0 {
+		t := time.AfterFunc(c.Timeout, cancel)
+		defer t.Stop()
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, newISAPIError(resp.StatusCode, msg)
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the per-attempt timeout once the body is closed, so the
+// timeout covers reading the response as well as receiving the headers.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// getXML GETs an ISAPI path and decodes the XML response into out.
+func (c *Camera) getXML(ctx context.Context, path string, out any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// putXML marshals in and PUTs it to an ISAPI path.
+func (c *Camera) putXML(ctx context.Context, path string, in any) error {
+	return c.sendXML(ctx, http.MethodPut, path, in)
+}
+
+// postXML marshals in and POSTs it to an ISAPI path, as used to add an item
+// to a list such as /ISAPI/Security/users.
+func (c *Camera) postXML(ctx context.Context, path string, in any) error {
+	return c.sendXML(ctx, http.MethodPost, path, in)
+}
+
+func (c *Camera) sendXML(ctx context.Context, method, path string, in any) error {
+	payload, err := xml.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshal xml: %w", err)
+	}
+	return c.command(ctx, method, path, append([]byte(xml.Header), payload...))
+}
+
+// putEmpty sends a PUT with no body, as used by command-style endpoints such
+// as /ISAPI/System/reboot.
+func (c *Camera) putEmpty(ctx context.Context, path string) error {
+	return c.command(ctx, http.MethodPut, path, nil)
+}
+
+// deletePath sends a DELETE for an ISAPI path.
+func (c *Camera) deletePath(ctx context.Context, path string) error {
+	return c.command(ctx, http.MethodDelete, path, nil)
+}
+
+// command sends a request whose reply is at most a ResponseStatus.
+func (c *Camera) command(ctx context.Context, method, path string, body []byte) error {
+	resp, err := c.do(ctx, method, path, body)
+	return commandReply(resp, err)
+}
+
+// commandOnce is like command but never retries the request, for operations
+// that must not run twice, such as a reboot the camera drops the connection
+// for.
+func (c *Camera) commandOnce(ctx context.Context, method, path string, body []byte) error {
+	resp, err := c.sendRetry(ctx, method, path, body, RetryPolicy{})
+	return commandReply(resp, err)
+}
+
+// commandReply checks the reply of a command.
+func commandReply(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	return checkResponseStatus(reply)
+}
+
+// rawElement keeps an XML element the client does not model, so that a
+// get-modify-set cycle does not drop it.
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// MarshalXML writes the element back without the namespace it was read
+// with, which would otherwise be repeated on every kept element.
+func (e rawElement) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: e.XMLName.Local}, Attr: e.Attrs}
+	return enc.EncodeElement(struct {
+		Inner []byte `xml:",innerxml"`
+	}{e.Inner}, start)
+}
@@ -0,0 +1,195 @@
+package hikvision
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icholy/digest"
+)
+
+// DefaultRTSPPort is the port cameras serve RTSP on unless reconfigured.
+const DefaultRTSPPort = 554
+
+// RTSPURL returns the RTSP URL of a streaming channel such as 101 (see
+// StreamingChannelID), including the camera's credentials. A port of 0 means
+// DefaultRTSPPort. Behind an NVR the same URL form reaches the camera on
+// that channel.
+func (c *Camera) RTSPURL(id, port int) string {
+	u := c.rtspURL(id, port)
+	u.User = url.UserPassword(c.Username, c.Password)
+	return u.String()
+}
+
+func (c *Camera) rtspURL(id, port int) *url.URL {
+	if port == 0 {
+		port = DefaultRTSPPort
+	}
+	return &url.URL{
+		Scheme: "rtsp",
+		Host:   JoinHost(hostname(c.Host), port),
+		Path:   fmt.Sprintf("/Streaming/Channels/%d", id),
+	}
+}
+
+// StreamInfo describes a live stream as announced by RTSP DESCRIBE.
+type StreamInfo struct {
+	// Codec is the RTP encoding name of the video track, e.g. "H264" or
+	// "H265".
+	Codec string
+	// Width and Height are zero when the camera does not announce the
+	// dimensions in its SDP.
+	Width, Height int
+	// SDP is the full session description.
+	SDP string
+}
+
+// ProbeStream connects to the RTSP server of the camera and sends DESCRIBE
+// for a streaming channel such as 101, verifying that the stream is live. A
+// port of 0 means DefaultRTSPPort. c.Timeout bounds the whole exchange.
+func (c *Camera) ProbeStream(ctx context.Context, id, port int) (*StreamInfo, error) {
+	u := c.rtspURL(id, port)
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("RTSP %s: %w", u.Host, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	r := bufio.NewReader(conn)
+	status, header, body, err := rtspDescribe(conn, r, u.String(), 1, "")
+	if err == nil && status == http.StatusUnauthorized {
+		var auth string
+		if auth, err = c.rtspAuthorization(header, u.String()); err == nil {
+			status, header, body, err = rtspDescribe(conn, r, u.String(), 2, auth)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("RTSP DESCRIBE %s: %w", u, err)
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return nil, fmt.Errorf("RTSP DESCRIBE %s: %w", u, ErrUnauthorized)
+	case status == http.StatusNotFound:
+		return nil, fmt.Errorf("RTSP DESCRIBE %s: %w", u, ErrNotSupported)
+	case status != http.StatusOK:
+		return nil, fmt.Errorf("RTSP DESCRIBE %s: status %d", u, status)
+	}
+	return parseSDP(string(body)), nil
+}
+
+// rtspAuthorization answers the Digest or Basic challenge of a 401 response.
+func (c *Camera) rtspAuthorization(header textproto.MIMEHeader, uri string) (string, error) {
+	if chal, err := digest.FindChallenge(http.Header(header)); err == nil {
+		cred, err := digest.Digest(chal, digest.Options{
+			Method:   "DESCRIBE",
+			URI:      uri,
+			Count:    1,
+			Username: c.Username,
+			Password: c.Password,
+		})
+		if err != nil {
+			return "", err
+		}
+		return cred.String(), nil
+	}
+	req := http.Request{Header: http.Header{}}
+	req.SetBasicAuth(c.Username, c.Password)
+	return req.Header.Get("Authorization"), nil
+}
+
+// rtspDescribe sends one DESCRIBE request and reads the response.
+func rtspDescribe(w io.Writer, r *bufio.Reader, uri string, cseq int, auth string) (int, textproto.MIMEHeader, []byte, error) {
+	req := fmt.Sprintf("DESCRIBE %s RTSP/1.0\r\nCSeq: %d\r\nAccept: application/sdp\r\nUser-Agent: hikvision-ir\r\n", uri, cseq)
+	if auth != "" {
+		req += "Authorization: " + auth + "\r\n"
+	}
+	if _, err := io.WriteString(w, req+"\r\n"); err != nil {
+		return 0, nil, nil, err
+	}
+
+	tp := textproto.NewReader(r)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	proto, rest, _ := strings.Cut(line, " ")
+	code, _, _ := strings.Cut(rest, " ")
+	status, err := strconv.Atoi(code)
+	if !strings.HasPrefix(proto, "RTSP/") || err != nil {
+		return 0, nil, nil, fmt.Errorf("malformed status line %q", line)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	var body []byte
+	if n, _ := strconv.Atoi(header.Get("Content-Length")); n > 0 {
+		body = make([]byte, n)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	return status, header, body, nil
+}
+
+// parseSDP extracts the codec and dimensions of the first video track.
+func parseSDP(sdp string) *StreamInfo {
+	info := &StreamInfo{SDP: sdp}
+	video := false
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "m=") {
+			if video {
+				break
+			}
+			video = strings.HasPrefix(line, "m=video")
+			continue
+		}
+		if !video {
+			continue
+		}
+		switch key, val, _ := strings.Cut(line, ":"); key {
+		case "a=rtpmap":
+			// a=rtpmap:96 H264/90000
+			if _, enc, ok := strings.Cut(val, " "); ok {
+				info.Codec, _, _ = strings.Cut(enc, "/")
+			}
+		case "a=x-dimensions":
+			// a=x-dimensions:1920,1080
+			w, h, _ := strings.Cut(val, ",")
+			info.Width, _ = strconv.Atoi(w)
+			info.Height, _ = strconv.Atoi(h)
+		case "a=framesize":
+			// a=framesize:96 1920-1080
+			if _, dims, ok := strings.Cut(val, " "); ok {
+				w, h, _ := strings.Cut(dims, "-")
+				info.Width, _ = strconv.Atoi(w)
+				info.Height, _ = strconv.Atoi(h)
+			}
+		}
+	}
+	return info
+}
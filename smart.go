@@ -0,0 +1,190 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Point is a position in the normalized 1000x1000 grid smart events use,
+// measured from the bottom left.
+type Point struct {
+	X int `xml:"positionX" json:"positionX" yaml:"x"`
+	Y int `xml:"positionY" json:"positionY" yaml:"y"`
+}
+
+// Detection targets for DetectionLine.Target and DetectionRegion.Target.
+// Models with target classification also accept "human,vehicle".
+const (
+	TargetAll     = "all"
+	TargetHuman   = "human"
+	TargetVehicle = "vehicle"
+)
+
+// Line crossing directions for DetectionLine.Direction.
+const (
+	CrossAny       = "any"
+	CrossLeftRight = "left-right"
+	CrossRightLeft = "right-left"
+)
+
+// LineDetection is the line crossing configuration of a video channel, as
+// served by /ISAPI/Smart/LineDetection/N.
+type LineDetection struct {
+	XMLName xml.Name        `xml:"LineDetection" json:"-" yaml:"-"`
+	ID      int             `xml:"id" json:"id" yaml:"-"`
+	Enabled bool            `xml:"enabled" json:"enabled" yaml:"enabled"`
+	Lines   []DetectionLine `xml:"LineItemList>LineItem" json:"LineItemList" yaml:"lines"`
+	Extra   []rawElement    `xml:",any" json:"-" yaml:"-"`
+}
+
+// DetectionLine is one tripwire of a LineDetection.
+type DetectionLine struct {
+	ID      int  `xml:"id" json:"id" yaml:"id"`
+	Enabled bool `xml:"enabled" json:"enabled" yaml:"enabled"`
+	// Sensitivity is 1-100.
+	Sensitivity int    `xml:"sensitivityLevel" json:"sensitivityLevel" yaml:"sensitivity"`
+	Direction   string `xml:"directionSensitivity" json:"directionSensitivity" yaml:"direction"`
+	// Target filters what may trigger the line; empty means all.
+	Target string       `xml:"detectionTarget,omitempty" json:"detectionTarget,omitempty" yaml:"target,omitempty"`
+	Points []Point      `xml:"CoordinatesList>Coordinates" json:"CoordinatesList" yaml:"points"`
+	Extra  []rawElement `xml:",any" json:"-" yaml:"-"`
+}
+
+// FieldDetection is the intrusion detection configuration of a video channel,
+// as served by /ISAPI/Smart/FieldDetection/N.
+type FieldDetection struct {
+	XMLName xml.Name          `xml:"FieldDetection" json:"-" yaml:"-"`
+	ID      int               `xml:"id" json:"id" yaml:"-"`
+	Enabled bool              `xml:"enabled" json:"enabled" yaml:"enabled"`
+	Regions []DetectionRegion `xml:"FieldDetectionRegionList>FieldDetectionRegion" json:"FieldDetectionRegionList" yaml:"regions"`
+	Extra   []rawElement      `xml:",any" json:"-" yaml:"-"`
+}
+
+// DetectionRegion is one polygon of a FieldDetection.
+type DetectionRegion struct {
+	ID      int  `xml:"id" json:"id" yaml:"id"`
+	Enabled bool `xml:"enabled" json:"enabled" yaml:"enabled"`
+	// Sensitivity is 1-100.
+	Sensitivity int `xml:"sensitivityLevel" json:"sensitivityLevel" yaml:"sensitivity"`
+	// Threshold is how many seconds a target must stay inside before the
+	// event fires.
+	Threshold int `xml:"timeThreshold" json:"timeThreshold" yaml:"threshold"`
+	// Target filters what may trigger the region; empty means all.
+	Target string       `xml:"detectionTarget,omitempty" json:"detectionTarget,omitempty" yaml:"target,omitempty"`
+	Points []Point      `xml:"RegionCoordinatesList>RegionCoordinates" json:"RegionCoordinatesList" yaml:"points"`
+	Extra  []rawElement `xml:",any" json:"-" yaml:"-"`
+}
+
+func smartPath(event string, channel int) string {
+	return fmt.Sprintf("/ISAPI/Smart/%s/%d", event, channel)
+}
+
+// GetLineDetection returns the line crossing configuration of a video
+// channel.
+func (c *Camera) GetLineDetection(ctx context.Context, channel int) (*LineDetection, error) {
+	var ld LineDetection
+	if err := c.getDoc(ctx, smartPath("LineDetection", channel), &ld); err != nil {
+		return nil, err
+	}
+	return &ld, nil
+}
+
+// SetLineDetection replaces the line crossing configuration of a video
+// channel.
+func (c *Camera) SetLineDetection(ctx context.Context, channel int, ld *LineDetection) error {
+	ld.ID = channel
+	return c.putDoc(ctx, smartPath("LineDetection", channel), ld)
+}
+
+// GetFieldDetection returns the intrusion detection configuration of a video
+// channel.
+func (c *Camera) GetFieldDetection(ctx context.Context, channel int) (*FieldDetection, error) {
+	var fd FieldDetection
+	if err := c.getDoc(ctx, smartPath("FieldDetection", channel), &fd); err != nil {
+		return nil, err
+	}
+	return &fd, nil
+}
+
+// SetFieldDetection replaces the intrusion detection configuration of a video
+// channel.
+func (c *Camera) SetFieldDetection(ctx context.Context, channel int, fd *FieldDetection) error {
+	fd.ID = channel
+	return c.putDoc(ctx, smartPath("FieldDetection", channel), fd)
+}
+
+// SceneChangeDetection is the scene change detection of a video channel, as
+// served by /ISAPI/Smart/SceneChangeDetection/N. It raises EventSceneChange
+// when the picture changes as it would if the camera were turned away.
+type SceneChangeDetection struct {
+	XMLName xml.Name `xml:"SceneChangeDetection" json:"-" yaml:"-"`
+	ID      int      `xml:"id" json:"id" yaml:"-"`
+	Enabled bool     `xml:"enabled" json:"enabled" yaml:"enabled"`
+	// Sensitivity is 0-100.
+	Sensitivity int          `xml:"sensitivityLevel" json:"sensitivityLevel" yaml:"sensitivity"`
+	Extra       []rawElement `xml:",any" json:"-" yaml:"-"`
+}
+
+// GetSceneChangeDetection returns the scene change detection configuration
+// of a video channel.
+func (c *Camera) GetSceneChangeDetection(ctx context.Context, channel int) (*SceneChangeDetection, error) {
+	var sd SceneChangeDetection
+	if err := c.getDoc(ctx, smartPath("SceneChangeDetection", channel), &sd); err != nil {
+		return nil, err
+	}
+	return &sd, nil
+}
+
+// SetSceneChangeDetection replaces the scene change detection configuration
+// of a video channel.
+func (c *Camera) SetSceneChangeDetection(ctx context.Context, channel int, sd *SceneChangeDetection) error {
+	sd.ID = channel
+	return c.putDoc(ctx, smartPath("SceneChangeDetection", channel), sd)
+}
+
+// AudioDetection is the audio exception detection of an audio input, as
+// served by /ISAPI/Smart/AudioDetection/channels/N. Each enabled check
+// raises EventAudioException.
+type AudioDetection struct {
+	XMLName xml.Name `xml:"AudioDetection" json:"-" yaml:"-"`
+	ID      int      `xml:"id" json:"id" yaml:"-"`
+	// InputException checks for the audio input failing.
+	InputException AudioExceptionCheck `xml:"audioInputException" json:"audioInputException" yaml:"input_exception"`
+	// SteepRise checks for a sudden rise in sound intensity, beyond
+	// Threshold decibels if set, and SteepDrop for a sudden drop.
+	SteepRise AudioExceptionCheck `xml:"audioSteepRise" json:"audioSteepRise" yaml:"steep_rise"`
+	SteepDrop AudioExceptionCheck `xml:"audioSteepDrop" json:"audioSteepDrop" yaml:"steep_drop"`
+	Extra     []rawElement        `xml:",any" json:"-" yaml:"-"`
+}
+
+// AudioExceptionCheck is a check of AudioDetection: the input failing, or a
+// change in sound intensity.
+type AudioExceptionCheck struct {
+	Enabled bool `xml:"enabled" json:"enabled" yaml:"enabled"`
+	// Sensitivity is 1-100, and 0 where the check has none.
+	Sensitivity int          `xml:"sensitivityLevel,omitempty" json:"sensitivityLevel,omitempty" yaml:"sensitivity,omitempty"`
+	Threshold   int          `xml:"threshold,omitempty" json:"threshold,omitempty" yaml:"threshold,omitempty"`
+	Extra       []rawElement `xml:",any" json:"-" yaml:"-"`
+}
+
+func audioDetectionPath(channel int) string {
+	return fmt.Sprintf("/ISAPI/Smart/AudioDetection/channels/%d", channel)
+}
+
+// GetAudioDetection returns the audio exception detection configuration of
+// an audio input.
+func (c *Camera) GetAudioDetection(ctx context.Context, channel int) (*AudioDetection, error) {
+	var ad AudioDetection
+	if err := c.getDoc(ctx, audioDetectionPath(channel), &ad); err != nil {
+		return nil, err
+	}
+	return &ad, nil
+}
+
+// SetAudioDetection replaces the audio exception detection configuration of
+// an audio input.
+func (c *Camera) SetAudioDetection(ctx context.Context, channel int, ad *AudioDetection) error {
+	ad.ID = channel
+	return c.putDoc(ctx, audioDetectionPath(channel), ad)
+}
@@ -0,0 +1,27 @@
+package hikvision
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StreamingChannelID returns the ISAPI streaming channel ID for a video
+// channel and stream number, e.g. 101 for the main stream (1) of channel 1
+// and 102 for its sub-stream (2).
+func StreamingChannelID(channel, stream int) int {
+	return channel*100 + stream
+}
+
+// Snapshot captures a JPEG still from a streaming ID such as 101, as
+// returned by StreamingChannelID; it is not a video channel number.
+// Calls GET /ISAPI/Streaming/channels/<id>/picture. The caller must close the
+// returned reader.
+func (c *Camera) Snapshot(ctx context.Context, id int) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/ISAPI/Streaming/channels/%d/picture", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
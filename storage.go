@@ -0,0 +1,150 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// Storage is the recording storage of a camera or NVR, as served by
+// /ISAPI/ContentMgmt/Storage: its SD card or disks, and network storage.
+type Storage struct {
+	XMLName xml.Name `xml:"storage"`
+	HDDs    []HDD    `xml:"hddList>hdd"`
+	NAS     []NAS    `xml:"nasList>nas"`
+	// WorkMode is how recordings are spread over the disks, "group" or
+	// "quota".
+	WorkMode string       `xml:"workMode,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// HDD is an SD card or disk. Capacity and FreeSpace are in MiB.
+type HDD struct {
+	ID        int    `xml:"id"`
+	Name      string `xml:"hddName,omitempty"`
+	Path      string `xml:"hddPath,omitempty"`
+	Type      string `xml:"hddType,omitempty"`
+	Status    string `xml:"status"`
+	Capacity  int64  `xml:"capacity"`
+	FreeSpace int64  `xml:"freeSpace"`
+	// Property is RW, RO or Redund.
+	Property string       `xml:"property,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// NAS is a network storage mount. Capacity and FreeSpace are in MiB.
+type NAS struct {
+	XMLName xml.Name `xml:"nas"`
+	ID      int      `xml:"id"`
+	Address string   `xml:"ipAddress,omitempty"`
+	Path    string   `xml:"path,omitempty"`
+	Type    string   `xml:"nasType,omitempty"`
+	// MountType is NASMountNFS or NASMountSMB.
+	MountType string       `xml:"mountType,omitempty"`
+	User      string       `xml:"userName,omitempty"`
+	Password  string       `xml:"password,omitempty"`
+	Status    string       `xml:"status"`
+	Capacity  int64        `xml:"capacity"`
+	FreeSpace int64        `xml:"freeSpace"`
+	Property  string       `xml:"property,omitempty"`
+	Extra     []rawElement `xml:",any"`
+}
+
+// NAS mount types for NAS.MountType. SMB mounts take a user and password.
+const (
+	NASMountNFS = "NFS"
+	NASMountSMB = "SMB/CIFS"
+)
+
+// HDD statuses that need attention.
+const (
+	HDDStatusOK          = "ok"
+	HDDStatusIdle        = "idle" // spun down, healthy
+	HDDStatusUnformatted = "unformatted"
+	HDDStatusError       = "error"
+	HDDStatusFormatting  = "formating" // sic
+)
+
+// Healthy reports whether the disk can be recorded to.
+func (h HDD) Healthy() bool {
+	return h.Status == HDDStatusOK || h.Status == HDDStatusIdle
+}
+
+// GetStorage returns the SD cards, disks and network storage of the camera.
+// Calls GET /ISAPI/ContentMgmt/Storage.
+func (c *Camera) GetStorage(ctx context.Context) (*Storage, error) {
+	var s Storage
+	if err := c.getXML(ctx, "/ISAPI/ContentMgmt/Storage", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SMARTStatus is the health a disk reports of itself, as served by
+// /ISAPI/ContentMgmt/Storage/hdd/<id>/SMARTTest/status. SD cards mostly do
+// not report it.
+type SMARTStatus struct {
+	XMLName xml.Name `xml:"SMARTTestStatus"`
+	// Temperature is in degrees Celsius; the element is misspelt by the
+	// firmware.
+	Temperature int `xml:"temprature"`
+	PowerOnDays int `xml:"powerOnDay"`
+	// Evaluation is the overall verdict, "ok" or e.g. "error".
+	Evaluation string       `xml:"allEvaluaingStatus"`
+	Extra      []rawElement `xml:",any"`
+}
+
+// GetSMARTStatus returns the self-reported health of a disk.
+func (c *Camera) GetSMARTStatus(ctx context.Context, id int) (*SMARTStatus, error) {
+	var s SMARTStatus
+	if err := c.getXML(ctx, fmt.Sprintf("/ISAPI/ContentMgmt/Storage/hdd/%d/SMARTTest/status", id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FormatStatus is the progress of formatting a disk, as served by
+// /ISAPI/ContentMgmt/Storage/hdd/<id>/formatStatus.
+type FormatStatus struct {
+	XMLName xml.Name `xml:"formatStatus"`
+	// Formatting is misspelt by the firmware.
+	Formatting bool `xml:"formating"`
+	Percent    int  `xml:"percent"`
+}
+
+// GetFormatStatus returns the progress of formatting a disk.
+func (c *Camera) GetFormatStatus(ctx context.Context, id int) (*FormatStatus, error) {
+	var s FormatStatus
+	if err := c.getXML(ctx, fmt.Sprintf("/ISAPI/ContentMgmt/Storage/hdd/%d/formatStatus", id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FormatHDD erases an SD card or disk, and every recording on it. Some
+// firmware answer once formatting is done, which takes minutes for a disk, so
+// c.Timeout must allow for it; others answer at once. Poll GetFormatStatus
+// for progress either way. The request is never retried.
+// Calls PUT /ISAPI/ContentMgmt/Storage/hdd/<id>/format.
+func (c *Camera) FormatHDD(ctx context.Context, id int) error {
+	return c.commandOnce(ctx, http.MethodPut, fmt.Sprintf("/ISAPI/ContentMgmt/Storage/hdd/%d/format", id), nil)
+}
+
+func nasPath(op string) string {
+	return "/ISAPI/ContentMgmt/Storage/nas/" + op
+}
+
+// SetNAS replaces network storage mount n.ID. The camera mounts it anew,
+// and a new mount must be formatted before it is recorded to.
+// Calls PUT /ISAPI/ContentMgmt/Storage/nas/<id>.
+func (c *Camera) SetNAS(ctx context.Context, n *NAS) error {
+	return c.putXML(ctx, nasPath(fmt.Sprint(n.ID)), n)
+}
+
+// TestNAS makes the camera try to mount n, which need not be saved first,
+// and fails if it could not.
+// Calls POST /ISAPI/ContentMgmt/Storage/nas/test.
+func (c *Camera) TestNAS(ctx context.Context, n *NAS) error {
+	return c.postXML(ctx, nasPath("test"), n)
+}
@@ -0,0 +1,84 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// VideoCodec is the encoding of a stream.
+type VideoCodec string
+
+// Video codecs.
+const (
+	CodecH264  VideoCodec = "H.264"
+	CodecH265  VideoCodec = "H.265"
+	CodecMJPEG VideoCodec = "MJPEG"
+)
+
+// BitrateType selects constant or variable bitrate encoding.
+type BitrateType string
+
+// Bitrate types.
+const (
+	BitrateConstant BitrateType = "CBR"
+	BitrateVariable BitrateType = "VBR"
+)
+
+// StreamConfig is the configuration of a streaming channel such as 101, as
+// served by /ISAPI/Streaming/channels/<id>. Elements the client does not
+// model, such as Transport and Audio, are kept and sent back unchanged by
+// SetStreamConfig.
+type StreamConfig struct {
+	XMLName xml.Name      `xml:"StreamingChannel"`
+	ID      int           `xml:"id"`
+	Name    string        `xml:"channelName,omitempty"`
+	Enabled bool          `xml:"enabled"`
+	Video   VideoEncoding `xml:"Video"`
+	Extra   []rawElement  `xml:",any"`
+}
+
+// VideoEncoding is the video part of a StreamConfig.
+type VideoEncoding struct {
+	Codec  VideoCodec `xml:"videoCodecType"`
+	Width  int        `xml:"videoResolutionWidth"`
+	Height int        `xml:"videoResolutionHeight"`
+	// BitrateType selects which of ConstantBitrate and MaxBitrate (both in
+	// kbps) applies.
+	BitrateType     BitrateType `xml:"videoQualityControlType"`
+	ConstantBitrate int         `xml:"constantBitRate,omitempty"`
+	MaxBitrate      int         `xml:"vbrUpperCap,omitempty"`
+	// MaxFrameRate is in hundredths of a frame per second: 2500 is 25 fps.
+	MaxFrameRate int `xml:"maxFrameRate"`
+	// GOPLength is the I-frame interval in frames.
+	GOPLength int `xml:"GovLength,omitempty"`
+	// SmartCodec is H.264+/H.265+ on models that support it.
+	SmartCodec *SmartCodec  `xml:"SmartCodec,omitempty"`
+	Extra      []rawElement `xml:",any"`
+}
+
+// SmartCodec toggles H.264+/H.265+, which lowers the bitrate of mostly still
+// scenes.
+type SmartCodec struct {
+	Enabled bool `xml:"enabled"`
+}
+
+func streamPath(id int) string {
+	return fmt.Sprintf("/ISAPI/Streaming/channels/%d", id)
+}
+
+// GetStreamConfig returns the configuration of a streaming channel such as
+// 101; see StreamingChannelID.
+func (c *Camera) GetStreamConfig(ctx context.Context, id int) (*StreamConfig, error) {
+	var s StreamConfig
+	if err := c.getXML(ctx, streamPath(id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetStreamConfig replaces the configuration of streaming channel s.ID. Start
+// from GetStreamConfig so that unmodelled settings are preserved.
+func (c *Camera) SetStreamConfig(ctx context.Context, s *StreamConfig) error {
+	return c.putXML(ctx, streamPath(s.ID), s)
+}
@@ -0,0 +1,61 @@
+package hikvision
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// FactoryResetMode selects how much configuration a factory reset discards.
+type FactoryResetMode string
+
+const (
+	// FactoryResetBasic restores defaults but keeps network and user settings.
+	FactoryResetBasic FactoryResetMode = "basic"
+	// FactoryResetFull restores every setting, including network and users.
+	FactoryResetFull FactoryResetMode = "full"
+)
+
+// Reboot restarts the camera. Calls PUT /ISAPI/System/reboot. The camera is
+// unreachable for a minute or two afterwards. The request is never retried.
+func (c *Camera) Reboot(ctx context.Context) error {
+	return c.commandOnce(ctx, http.MethodPut, "/ISAPI/System/reboot", nil)
+}
+
+// FactoryReset restores factory defaults and reboots the camera.
+// Calls PUT /ISAPI/System/factoryReset?mode=<mode>. After FactoryResetFull the
+// camera returns to its default address and must be re-activated. The
+// request is never retried.
+func (c *Camera) FactoryReset(ctx context.Context, mode FactoryResetMode) error {
+	switch mode {
+	case FactoryResetBasic, FactoryResetFull:
+	default:
+		return fmt.Errorf("unknown factory reset mode %q", mode)
+	}
+	return c.commandOnce(ctx, http.MethodPut, "/ISAPI/System/factoryReset?mode="+string(mode), nil)
+}
+
+// ExportConfig downloads the camera's configuration as an encrypted blob,
+// for ImportConfig on the same or a replacement camera of the same model.
+// Calls GET /ISAPI/System/configurationData.
+func (c *Camera) ExportConfig(ctx context.Context) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/ISAPI/System/configurationData", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration: %w", err)
+	}
+	return data, nil
+}
+
+// ImportConfig restores a configuration blob from ExportConfig. The camera
+// reboots to apply it, and takes the exported network settings, so a clone
+// must be re-addressed afterwards. The request is never retried.
+func (c *Camera) ImportConfig(ctx context.Context, data []byte) error {
+	return c.commandOnce(ctx, http.MethodPut, "/ISAPI/System/configurationData", data)
+}
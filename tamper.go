@@ -0,0 +1,51 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// TamperDetection is the video tampering (lens covered) detection of a video
+// channel, as served by /ISAPI/System/Video/inputs/channels/N/tamperDetection.
+type TamperDetection struct {
+	XMLName xml.Name `xml:"TamperDetection"`
+	ID      int      `xml:"id"`
+	Enabled bool     `xml:"enabled"`
+	// ScreenWidth and ScreenHeight are the grid region points are given
+	// in, measured from the bottom left.
+	ScreenWidth  int            `xml:"normalizedScreenSize>normalizedScreenWidth"`
+	ScreenHeight int            `xml:"normalizedScreenSize>normalizedScreenHeight"`
+	Regions      []TamperRegion `xml:"TamperDetectionRegionList>TamperDetectionRegion"`
+	Extra        []rawElement   `xml:",any"`
+}
+
+// TamperRegion is an area of the picture watched for tampering.
+type TamperRegion struct {
+	ID int `xml:"id"`
+	// Sensitivity is 0-100.
+	Sensitivity int          `xml:"sensitivityLevel"`
+	Points      []Point      `xml:"RegionCoordinatesList>RegionCoordinates"`
+	Extra       []rawElement `xml:",any"`
+}
+
+func tamperPath(channel int) string {
+	return fmt.Sprintf("/ISAPI/System/Video/inputs/channels/%d/tamperDetection", channel)
+}
+
+// GetTamperDetection returns the tamper detection configuration of a video
+// channel.
+func (c *Camera) GetTamperDetection(ctx context.Context, channel int) (*TamperDetection, error) {
+	var td TamperDetection
+	if err := c.getXML(ctx, tamperPath(channel), &td); err != nil {
+		return nil, err
+	}
+	return &td, nil
+}
+
+// SetTamperDetection replaces the tamper detection configuration of a video
+// channel.
+func (c *Camera) SetTamperDetection(ctx context.Context, channel int, td *TamperDetection) error {
+	td.ID = channel
+	return c.putXML(ctx, tamperPath(channel), td)
+}
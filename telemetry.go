@@ -0,0 +1,108 @@
+package hikvision
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of the client.
+const instrumentationName = "hikvision-ir"
+
+// telemetry holds the OpenTelemetry tracer and instruments of a Camera.
+type telemetry struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+// newTelemetry instruments a Camera with tp and mp, or the global providers
+// where nil, which do nothing until an application installs real ones.
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) *telemetry {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	duration, err := mp.Meter(instrumentationName).Float64Histogram("hikvision.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of ISAPI calls, including retries."))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &telemetry{tracer: tp.Tracer(instrumentationName), duration: duration}
+}
+
+// startCall starts the span of one ISAPI call, which covers all its
+// attempts. The returned function ends the span with the outcome of the call
+// and records its duration.
+func (c *Camera) startCall(ctx context.Context, method, path string) (context.Context, func(status, attempts int, err error)) {
+	t := c.telemetry
+	if t == nil {
+		return ctx, func(int, int, error) {}
+	}
+	endpoint := endpointName(path)
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", method),
+		attribute.String("hikvision.endpoint", endpoint),
+	}
+	start := time.Now()
+	ctx, span := t.tracer.Start(ctx, method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(
+			attribute.String("url.path", path),
+			attribute.String("server.address", hostname(c.Host))))
+	return ctx, func(status, attempts int, err error) {
+		if status > 0 {
+			attrs = append(attrs, attribute.Int("http.response.status_code", status))
+		}
+		span.SetAttributes(attribute.Int("hikvision.attempts", attempts))
+		if err != nil {
+			attrs = append(attrs, attribute.String("error.type", errorType(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attrs...)
+		span.End()
+		if t.duration != nil {
+			t.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		}
+	}
+}
+
+// endpointName returns path without its query and with numeric segments,
+// such as channel or user IDs, replaced by {id}, so that span names and
+// metric attributes stay few.
+func endpointName(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if _, err := strconv.Atoi(p); err == nil {
+			parts[i] = "{id}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// errorType classifies a failed call for the error.type attribute.
+func errorType(err error) string {
+	var ie *ISAPIError
+	switch {
+	case errors.As(err, &ie):
+		return strconv.Itoa(ie.HTTPStatus)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "network"
+}
@@ -0,0 +1,114 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Time modes for TimeConfig.Mode.
+const (
+	TimeModeNTP    = "NTP"
+	TimeModeManual = "manual"
+)
+
+// TimeConfig is the clock configuration of the camera, as served by
+// /ISAPI/System/time.
+type TimeConfig struct {
+	XMLName xml.Name `xml:"Time"`
+	Mode    string   `xml:"timeMode"`
+	// LocalTime is the camera's local time, e.g. "2026-10-14T21:03:11+01:00".
+	// Some firmware omits the UTC offset.
+	LocalTime string `xml:"localTime"`
+	// TimeZone is in the camera's POSIX-like form, where the sign is
+	// inverted: "CST-1:00:00" is UTC+1.
+	TimeZone string       `xml:"timeZone,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// cameraTimeLayout is LocalTime without a UTC offset.
+const cameraTimeLayout = "2006-01-02T15:04:05"
+
+// Time parses LocalTime. A LocalTime without UTC offset is returned in UTC.
+func (t *TimeConfig) Time() (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, t.LocalTime); err == nil {
+		return ts, nil
+	}
+	return time.Parse(cameraTimeLayout, t.LocalTime)
+}
+
+// GetTime returns the clock configuration and current time of the camera.
+func (c *Camera) GetTime(ctx context.Context) (*TimeConfig, error) {
+	var t TimeConfig
+	if err := c.getXML(ctx, "/ISAPI/System/time", &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SetTime replaces the clock configuration of the camera. In manual mode the
+// camera clock is set to LocalTime.
+func (c *Camera) SetTime(ctx context.Context, t *TimeConfig) error {
+	return c.putXML(ctx, "/ISAPI/System/time", t)
+}
+
+// SyncTime switches the camera to manual time and sets its clock to now,
+// keeping the camera's time zone.
+func (c *Camera) SyncTime(ctx context.Context, now time.Time) error {
+	t, err := c.GetTime(ctx)
+	if err != nil {
+		return err
+	}
+	local := now.Format(time.RFC3339)
+	if cur, err := time.Parse(time.RFC3339, t.LocalTime); err == nil {
+		// Express now in the camera's own UTC offset.
+		local = now.In(cur.Location()).Format(cameraTimeLayout)
+	}
+	t.Mode, t.LocalTime = TimeModeManual, local
+	return c.SetTime(ctx, t)
+}
+
+// NTPServer is one time server the camera synchronises with in NTP mode, as
+// served by /ISAPI/System/time/ntpServers/<id>.
+type NTPServer struct {
+	XMLName xml.Name `xml:"NTPServer"`
+	ID      int      `xml:"id"`
+	// AddressingFormat is "hostname" or "ipaddress" and selects which of
+	// HostName and IPAddress is used.
+	AddressingFormat string `xml:"addressingFormatType"`
+	HostName         string `xml:"hostName,omitempty"`
+	IPAddress        string `xml:"ipAddress,omitempty"`
+	Port             int    `xml:"portNo,omitempty"`
+	// Interval is the synchronisation interval in minutes.
+	Interval int          `xml:"synchronizeInterval,omitempty"`
+	Extra    []rawElement `xml:",any"`
+}
+
+// Address returns the configured host name or IP address.
+func (s *NTPServer) Address() string {
+	if s.AddressingFormat == "ipaddress" {
+		return s.IPAddress
+	}
+	return s.HostName
+}
+
+type ntpServerList struct {
+	XMLName xml.Name    `xml:"NTPServerList"`
+	Servers []NTPServer `xml:"NTPServer"`
+}
+
+// GetNTP returns the NTP servers of the camera.
+func (c *Camera) GetNTP(ctx context.Context) ([]NTPServer, error) {
+	var list ntpServerList
+	if err := c.getXML(ctx, "/ISAPI/System/time/ntpServers", &list); err != nil {
+		return nil, err
+	}
+	return list.Servers, nil
+}
+
+// SetNTP replaces NTP server s.ID. It does not switch the camera to NTP mode;
+// see SetTime.
+func (c *Camera) SetNTP(ctx context.Context, s *NTPServer) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/System/time/ntpServers/%d", s.ID), s)
+}
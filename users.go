@@ -0,0 +1,130 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// User levels for User.Level.
+const (
+	UserAdministrator = "Administrator"
+	UserOperator      = "Operator"
+	UserViewer        = "Viewer"
+)
+
+// User is a camera account, as served by /ISAPI/Security/users/<id>.
+type User struct {
+	XMLName xml.Name `xml:"User"`
+	// ID is assigned by the camera; leave it zero for CreateUser.
+	ID   int    `xml:"id,omitempty"`
+	Name string `xml:"userName"`
+	// Password is never returned by the camera. Leave it empty in SetUser to
+	// keep the current password.
+	Password string `xml:"password,omitempty"`
+	Level    string `xml:"userLevel"`
+	// LoginPassword is the current password of the account making the
+	// request, which newer firmwares require to change a password.
+	LoginPassword string       `xml:"loginPassword,omitempty"`
+	Extra         []rawElement `xml:",any"`
+}
+
+type userList struct {
+	XMLName xml.Name `xml:"UserList"`
+	Users   []User   `xml:"User"`
+}
+
+// GetUsers returns the camera's accounts.
+func (c *Camera) GetUsers(ctx context.Context) ([]User, error) {
+	var list userList
+	if err := c.getXML(ctx, "/ISAPI/Security/users", &list); err != nil {
+		return nil, err
+	}
+	return list.Users, nil
+}
+
+// CreateUser adds an account. The camera rejects passwords that do not meet
+// its strength rules with StatusInvalidContent.
+func (c *Camera) CreateUser(ctx context.Context, u *User) error {
+	return c.postXML(ctx, "/ISAPI/Security/users", u)
+}
+
+// SetUser replaces account u.ID.
+func (c *Camera) SetUser(ctx context.Context, u *User) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/Security/users/%d", u.ID), u)
+}
+
+// DeleteUser removes an account. The admin account (ID 1) cannot be deleted.
+func (c *Camera) DeleteUser(ctx context.Context, id int) error {
+	return c.deletePath(ctx, fmt.Sprintf("/ISAPI/Security/users/%d", id))
+}
+
+// UserPermission is what an operator or viewer account may do remotely, as
+// served by /ISAPI/Security/UserPermission/<id>. Administrators always have
+// every permission.
+type UserPermission struct {
+	XMLName xml.Name         `xml:"UserPermission"`
+	ID      int              `xml:"id"`
+	UserID  int              `xml:"userID"`
+	Remote  RemotePermission `xml:"remotePermission"`
+	Extra   []rawElement     `xml:",any"`
+}
+
+// RemotePermission is the set of remote (network) permissions of an account.
+// Per-channel permission lists are kept in Extra.
+type RemotePermission struct {
+	Preview           bool         `xml:"preview"`
+	PlayBack          bool         `xml:"playBack"`
+	Record            bool         `xml:"record"`
+	PTZControl        bool         `xml:"ptzControl"`
+	ParameterConfig   bool         `xml:"parameterConfig"`
+	LogOrStateCheck   bool         `xml:"logOrStateCheck"`
+	Upgrade           bool         `xml:"upgrade"`
+	VoiceTalk         bool         `xml:"voiceTalk"`
+	RestartOrShutdown bool         `xml:"restartOrShutdown"`
+	AlarmOutOrUpload  bool         `xml:"alarmOutOrUpload"`
+	Extra             []rawElement `xml:",any"`
+}
+
+type userPermissionList struct {
+	XMLName     xml.Name         `xml:"UserPermissionList"`
+	Permissions []UserPermission `xml:"UserPermission"`
+}
+
+// GetUserPermission returns the permissions of the account with the given
+// user ID. Firmwares without per-user permissions return ErrNotSupported.
+func (c *Camera) GetUserPermission(ctx context.Context, userID int) (*UserPermission, error) {
+	var list userPermissionList
+	if err := c.getXML(ctx, "/ISAPI/Security/UserPermission", &list); err != nil {
+		return nil, err
+	}
+	for i := range list.Permissions {
+		if list.Permissions[i].UserID == userID {
+			return &list.Permissions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no permissions for user %d: %w", userID, ErrNotSupported)
+}
+
+// SetUserPermission replaces permission set p.ID.
+func (c *Camera) SetUserPermission(ctx context.Context, p *UserPermission) error {
+	return c.putXML(ctx, fmt.Sprintf("/ISAPI/Security/UserPermission/%d", p.ID), p)
+}
+
+// ChangePassword sets a new password for the named account. Firmwares that
+// verify the caller's identity get the camera's current Password as the
+// login password. Changing the camera's own account leaves c with the old
+// password; create a new Camera with the new one for further requests.
+func (c *Camera) ChangePassword(ctx context.Context, user, password string) error {
+	users, err := c.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Name == user {
+			u.Password, u.LoginPassword = password, c.Password
+			return c.SetUser(ctx, &u)
+		}
+	}
+	return fmt.Errorf("no user %q", user)
+}
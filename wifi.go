@@ -0,0 +1,97 @@
+package hikvision
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Wi-Fi security modes for WirelessSecurity.Mode and AccessPoint.Security.
+const (
+	WiFiSecurityNone = "disable"
+	WiFiSecurityWEP  = "WEP"
+	WiFiSecurityWPA  = "WPA-personal"
+	WiFiSecurityWPA2 = "WPA2-personal"
+)
+
+// Wireless is the Wi-Fi client configuration of a wireless network
+// interface, as served by /ISAPI/System/Network/interfaces/<id>/wireless.
+// Cameras with both a wired and a wireless port usually have the wireless
+// one as interface 2.
+type Wireless struct {
+	XMLName  xml.Name         `xml:"Wireless"`
+	Enabled  bool             `xml:"enabled"`
+	SSID     string           `xml:"ssid"`
+	Security WirelessSecurity `xml:"WirelessSecurity"`
+	Extra    []rawElement     `xml:",any"`
+}
+
+// WirelessSecurity is how a Wireless interface authenticates to its access
+// point. WPA is used with WiFiSecurityWPA and WiFiSecurityWPA2.
+type WirelessSecurity struct {
+	Mode  string       `xml:"securityMode"`
+	WPA   *WPASettings `xml:"WPA,omitempty"`
+	Extra []rawElement `xml:",any"`
+}
+
+// WPASettings is the WPA or WPA2 pre-shared key of a Wireless interface.
+// The key is not returned.
+type WPASettings struct {
+	// Algorithm is "TKIP", "AES" or "TKIP/AES".
+	Algorithm string `xml:"algorithmType"`
+	Key       string `xml:"sharedKey,omitempty"`
+	// KeyLength is the length of Key, which some firmwares require.
+	KeyLength int          `xml:"wpaKeyLength,omitempty"`
+	Extra     []rawElement `xml:",any"`
+}
+
+// AccessPoint is a Wi-Fi network seen by a wireless interface.
+type AccessPoint struct {
+	ID       int    `xml:"id"`
+	SSID     string `xml:"ssid"`
+	Security string `xml:"securityMode"`
+	Channel  string `xml:"channel"`
+	// Signal is the signal strength in percent.
+	Signal int `xml:"signalStrength"`
+	// Speed is the link speed in Mbit/s.
+	Speed int `xml:"speed"`
+	// Connected is set on the network the interface is joined to.
+	Connected bool `xml:"connected"`
+}
+
+type accessPointList struct {
+	XMLName      xml.Name      `xml:"accessPointList"`
+	AccessPoints []AccessPoint `xml:"accessPoint"`
+}
+
+func wirelessPath(iface int) string {
+	return fmt.Sprintf("/ISAPI/System/Network/interfaces/%d/wireless", iface)
+}
+
+// GetWireless returns the Wi-Fi configuration of network interface iface.
+// Interfaces without Wi-Fi return ErrNotSupported.
+func (c *Camera) GetWireless(ctx context.Context, iface int) (*Wireless, error) {
+	var w Wireless
+	if err := c.getXML(ctx, wirelessPath(iface), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SetWireless replaces the Wi-Fi configuration of network interface iface.
+// Joining another network drops the camera from the current one, so the
+// camera is only reachable again at its address on the new network.
+func (c *Camera) SetWireless(ctx context.Context, iface int, w *Wireless) error {
+	return c.putXML(ctx, wirelessPath(iface), w)
+}
+
+// GetAccessPoints scans for Wi-Fi networks with network interface iface and
+// returns those found, including the one it is joined to.
+// Calls GET /ISAPI/System/Network/interfaces/<id>/wireless/accessPointList.
+func (c *Camera) GetAccessPoints(ctx context.Context, iface int) ([]AccessPoint, error) {
+	var list accessPointList
+	if err := c.getXML(ctx, wirelessPath(iface)+"/accessPointList", &list); err != nil {
+		return nil, err
+	}
+	return list.AccessPoints, nil
+}